@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/mailer"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrEmailNotVerified is returned by CheckLogin when verification is required
+// and the user has not verified their address yet
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// AccountConfig controls the emailed verification and reset links
+type AccountConfig struct {
+	// AppURL is the frontend base URL the emailed links point at
+	AppURL               string
+	RequireVerifiedEmail bool
+	VerifyTTL            time.Duration
+	ResetTTL             time.Duration
+}
+
+// AccountService runs the email verification and password reset flows. Both
+// email the user a signed, expiring token that can be redeemed once.
+type AccountService struct {
+	cfg      AccountConfig
+	tokenCfg TokenConfig
+	users    repository.UserRepository
+	tokens   repository.AccountTokenRepository
+	sessions *SessionService
+	mailer   mailer.Mailer
+}
+
+func NewAccountService(cfg AccountConfig, tokenCfg TokenConfig, users repository.UserRepository, tokens repository.AccountTokenRepository, sessions *SessionService, m mailer.Mailer) *AccountService {
+	return &AccountService{
+		cfg:      cfg,
+		tokenCfg: tokenCfg,
+		users:    users,
+		tokens:   tokens,
+		sessions: sessions,
+		mailer:   m,
+	}
+}
+
+// CheckLogin rejects unverified users when REQUIRE_VERIFIED_EMAIL is on
+func (s *AccountService) CheckLogin(user *models.User) error {
+	if s.cfg.RequireVerifiedEmail && !user.EmailVerified() {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// SendVerification emails the user a link to confirm their address
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.issue(ctx, user, models.PurposeVerifyEmail, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your TaskMorph email address by opening this link:\n\n%s\n\nThe link expires in %s.\n",
+		user.Name, s.link("/verify-email", token), formatTTL(s.cfg.VerifyTTL))
+	return s.mailer.Send(ctx, user.Email, "Verify your TaskMorph email", body)
+}
+
+// ResendVerification sends a new verification email if the address belongs
+// to an unverified user. Unknown addresses are ignored so callers cannot
+// probe which emails are registered.
+func (s *AccountService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified() {
+		return nil
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail redeems a verification token. It only verifies the address the
+// token was sent to, so a token is void once the user changes their email.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	record, err := s.redeem(ctx, token, models.PurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	userID, err := primitive.ObjectIDFromHex(record.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	err = s.users.MarkEmailVerified(ctx, userID, record.Email, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	return err
+}
+
+// SendPasswordReset emails a reset link if the address is registered.
+// Unknown addresses are ignored, like in ResendVerification.
+func (s *AccountService) SendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.issue(ctx, user, models.PurposeResetPassword, s.cfg.ResetTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your TaskMorph password. If it was you, open this link to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not ask for this, you can ignore this email.\n",
+		user.Name, s.link("/reset-password", token), formatTTL(s.cfg.ResetTTL))
+	return s.mailer.Send(ctx, user.Email, "Reset your TaskMorph password", body)
+}
+
+// ResetPassword redeems a reset token and sets a new password. Every session
+// of the user is revoked, and the address counts as verified since the user
+// just proved they can read its mail. Like verification tokens, reset tokens
+// are void once the user changes their email.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	record, err := s.redeem(ctx, token, models.PurposeResetPassword)
+	if err != nil {
+		return err
+	}
+	userID, err := primitive.ObjectIDFromHex(record.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if user.Email != record.Email {
+		return ErrInvalidToken
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	err = s.users.SetPassword(ctx, userID, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	// ErrNotFound here means the email changed in the meantime, leaving
+	// nothing to verify
+	err = s.users.MarkEmailVerified(ctx, userID, record.Email, time.Now())
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return s.sessions.RevokeAll(ctx, record.UserID)
+}
+
+// issue stores a token record and returns the signed token for it
+func (s *AccountService) issue(ctx context.Context, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	record := &models.AccountToken{
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.tokens.Create(ctx, record); err != nil {
+		return "", err
+	}
+	return generateAccountToken(s.tokenCfg, record)
+}
+
+// redeem checks a token's signature and purpose, then consumes its record
+func (s *AccountService) redeem(ctx context.Context, token, purpose string) (*models.AccountToken, error) {
+	claims, err := parseAccountToken(s.tokenCfg, token, purpose)
+	if err != nil {
+		return nil, err
+	}
+	id, err := primitive.ObjectIDFromHex(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	record, err := s.tokens.Consume(ctx, id, purpose, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.UserID != claims.Subject || record.Email != claims.Email {
+		return nil, ErrInvalidToken
+	}
+	return record, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.cfg.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatTTL renders durations like 48h or 1h as "48 hours" or "1 hour"
+func formatTTL(ttl time.Duration) string {
+	if ttl%time.Hour == 0 {
+		if hours := int(ttl / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "1 hour"
+	}
+	return ttl.String()
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+// captureMailer records the emails sent instead of delivering them
+type captureMailer struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (m *captureMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, body)
+	return nil
+}
+
+// linkToken returns the token from the link in the latest email, checking
+// that the link points at path on the app
+func (m *captureMailer) linkToken(t *testing.T, path string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	body := m.sent[len(m.sent)-1]
+	start := strings.Index(body, "http://app.test"+path+"?token=")
+	if start < 0 {
+		t.Fatalf("no %s link in:\n%s", path, body)
+	}
+	link, err := url.Parse(strings.Fields(body[start:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+type accountFixture struct {
+	accounts *AccountService
+	sessions *SessionService
+	users    repository.UserRepository
+	user     *models.User
+	mail     *captureMailer
+}
+
+func newAccountFixture(t *testing.T, cfg AccountConfig) *accountFixture {
+	t.Helper()
+	tokenCfg := testTokenConfig(t)
+	sessions, user := newTestSessions(t, tokenCfg)
+	f := &accountFixture{sessions: sessions, users: sessions.users, user: user, mail: &captureMailer{}}
+	f.accounts = NewAccountService(cfg, tokenCfg, f.users, repository.NewMemoryAccountTokenRepository(), sessions, f.mail)
+	return f
+}
+
+func defaultAccountConfig() AccountConfig {
+	return AccountConfig{AppURL: "http://app.test", VerifyTTL: 48 * time.Hour, ResetTTL: time.Hour}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	f := newAccountFixture(t, defaultAccountConfig())
+	ctx := context.Background()
+
+	if err := f.accounts.SendVerification(ctx, f.user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	token := f.mail.linkToken(t, "/verify-email")
+	if !strings.Contains(f.mail.sent[0], "expires in 48 hours") {
+		t.Errorf("email does not give the expiry:\n%s", f.mail.sent[0])
+	}
+
+	if err := f.accounts.ResetPassword(ctx, token, "new password 1"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("verification token used for a reset: %v, want ErrInvalidToken", err)
+	}
+	if err := f.accounts.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	user, err := f.users.FindByID(ctx, f.user.ID)
+	if err != nil || !user.EmailVerified() {
+		t.Fatalf("user = %+v, %v, want verified", user, err)
+	}
+	if err := f.accounts.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("second VerifyEmail: %v, want ErrInvalidToken", err)
+	}
+	for _, token := range []string{"", "junk", token + "x"} {
+		if err := f.accounts.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("VerifyEmail(%q) = %v, want ErrInvalidToken", token, err)
+		}
+	}
+
+	// A verified address gets no more verification emails
+	if err := f.accounts.ResendVerification(ctx, user.Email); err != nil || len(f.mail.sent) != 1 {
+		t.Errorf("ResendVerification for a verified user: %v, %d emails", err, len(f.mail.sent))
+	}
+	if err := f.accounts.ResendVerification(ctx, "nobody@example.com"); err != nil || len(f.mail.sent) != 1 {
+		t.Errorf("ResendVerification for an unknown address: %v, %d emails", err, len(f.mail.sent))
+	}
+}
+
+func TestVerifyEmailExpired(t *testing.T) {
+	cfg := defaultAccountConfig()
+	cfg.VerifyTTL = -time.Minute
+	f := newAccountFixture(t, cfg)
+	if err := f.accounts.SendVerification(context.Background(), f.user); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.accounts.VerifyEmail(context.Background(), f.mail.linkToken(t, "/verify-email")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired token: %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	f := newAccountFixture(t, defaultAccountConfig())
+	ctx := context.Background()
+	session := mustStart(t, f.sessions, f.user)
+
+	if err := f.accounts.SendPasswordReset(ctx, "nobody@example.com"); err != nil || len(f.mail.sent) != 0 {
+		t.Fatalf("reset for an unknown address: %v, %d emails", err, len(f.mail.sent))
+	}
+	if err := f.accounts.SendPasswordReset(ctx, f.user.Email); err != nil {
+		t.Fatalf("SendPasswordReset: %v", err)
+	}
+	token := f.mail.linkToken(t, "/reset-password")
+
+	if err := f.accounts.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reset token used for verification: %v, want ErrInvalidToken", err)
+	}
+	if err := f.accounts.ResetPassword(ctx, token, "new password 1"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	user, err := f.users.FindByID(ctx, f.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !CheckPassword(user.PasswordHash, "new password 1") {
+		t.Error("the new password does not match")
+	}
+	if !user.EmailVerified() {
+		t.Error("resetting the password did not verify the address")
+	}
+	if _, err := f.sessions.Authenticate(ctx, session.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("session from before the reset: %v, want ErrInvalidToken", err)
+	}
+	if err := f.accounts.ResetPassword(ctx, token, "another password 2"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("second ResetPassword: %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestCheckLogin(t *testing.T) {
+	verified := time.Now()
+	for _, tt := range []struct {
+		require bool
+		user    models.User
+		want    error
+	}{
+		{false, models.User{}, nil},
+		{true, models.User{}, ErrEmailNotVerified},
+		{true, models.User{EmailVerifiedAt: &verified}, nil},
+	} {
+		cfg := defaultAccountConfig()
+		cfg.RequireVerifiedEmail = tt.require
+		accounts := NewAccountService(cfg, TokenConfig{}, nil, nil, nil, nil)
+		if err := accounts.CheckLogin(&tt.user); !errors.Is(err, tt.want) {
+			t.Errorf("require %v, verified %v: %v, want %v", tt.require, tt.user.EmailVerified(), err, tt.want)
+		}
+	}
+}
+
+func TestFormatTTL(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:        "1 hour",
+		48 * time.Hour:   "48 hours",
+		90 * time.Minute: "1h30m0s",
+	}
+	for ttl, want := range tests {
+		if got := formatTTL(ttl); got != want {
+			t.Errorf("formatTTL(%v) = %q, want %q", ttl, got, want)
+		}
+	}
+}
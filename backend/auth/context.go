@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// claimsKey is the gin context key the middleware stores verified claims under
+const claimsKey = "auth.claims"
+
+// SetClaims records the verified claims of the current request
+func SetClaims(c *gin.Context, claims *Claims) {
+	c.Set(claimsKey, claims)
+}
+
+// ClaimsFrom returns the claims stored by SetClaims
+func ClaimsFrom(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(claimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// UserID returns the ID of the authenticated user. The claims were validated
+// when they were set, so the conversion only fails if there are none.
+func UserID(c *gin.Context) (primitive.ObjectID, bool) {
+	claims, ok := ClaimsFrom(c)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(claims.UserID)
+	return id, err == nil
+}
+
+// BearerToken returns the token from an "Authorization: Bearer" header, or ""
+func BearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is one key in a KeySet. Sign is nil for keys that are only
+// still accepted for verification.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Sign   interface{}
+	Verify interface{}
+}
+
+// KeySet signs new tokens with its active key and verifies tokens with any
+// of its keys, chosen by the "kid" header. Rotating means making a new key
+// active and keeping the old one as a verification key until the tokens it
+// signed have expired.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet builds a key set. active must be able to sign; retired keys only
+// verify.
+func NewKeySet(active SigningKey, retired ...SigningKey) (*KeySet, error) {
+	if active.Sign == nil {
+		return nil, fmt.Errorf("active key %q cannot sign", active.ID)
+	}
+
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+	for _, key := range append([]SigningKey{active}, retired...) {
+		key := key
+		if _, dup := ks.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		ks.keys[key.ID] = &key
+	}
+	ks.active = ks.keys[active.ID]
+	return ks, nil
+}
+
+// methods lists the algorithms the set can verify, for the parser allow-list
+func (ks *KeySet) methods() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range ks.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// sign signs claims with the active key and stamps its kid
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Sign)
+}
+
+// keyFunc picks the verification key by kid. The token's alg must match the
+// key's, so a public RSA key can never be used as an HMAC secret.
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not use %s", kid, t.Method.Alg())
+	}
+	return key.Verify, nil
+}
+
+// HMACKey wraps a shared secret as an HS256 key. Its ID is derived from the
+// secret, so the same secret gets the same kid on every instance.
+func HMACKey(secret string) SigningKey {
+	sum := sha256.Sum256([]byte("taskmorph-kid:" + secret))
+	return SigningKey{
+		ID:     "hs-" + hex.EncodeToString(sum[:6]),
+		Method: jwt.SigningMethodHS256,
+		Sign:   []byte(secret),
+		Verify: []byte(secret),
+	}
+}
+
+// LoadPrivateKey reads an RSA (RS256) or Ed25519 (EdDSA) private key from a
+// PEM file
+func LoadPrivateKey(path string) (SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		if key.N.BitLen() < 2048 {
+			return SigningKey{}, fmt.Errorf("%s: RSA keys must be at least 2048 bits", path)
+		}
+		return publicKeyID(jwt.SigningMethodRS256, key, &key.PublicKey)
+	}
+	if key, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		edKey := key.(ed25519.PrivateKey)
+		return publicKeyID(jwt.SigningMethodEdDSA, edKey, edKey.Public())
+	}
+	return SigningKey{}, fmt.Errorf("%s: not an RSA or Ed25519 private key", path)
+}
+
+// LoadPublicKey reads an RSA or Ed25519 public key from a PEM file, for
+// verifying tokens signed by a retired private key
+func LoadPublicKey(path string) (SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return publicKeyID(jwt.SigningMethodRS256, nil, key)
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return publicKeyID(jwt.SigningMethodEdDSA, nil, key)
+	}
+	return SigningKey{}, fmt.Errorf("%s: not an RSA or Ed25519 public key", path)
+}
+
+// publicKeyID derives the kid from the public key, so a private key and its
+// public half always share an ID
+func publicKeyID(method jwt.SigningMethod, private interface{}, public crypto.PublicKey) (SigningKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	sum := sha256.Sum256(der)
+
+	prefix := "rs-"
+	if _, ok := public.(*rsa.PublicKey); !ok {
+		prefix = "ed-"
+	}
+
+	key := SigningKey{
+		ID:     prefix + hex.EncodeToString(sum[:6]),
+		Method: method,
+		Verify: public,
+	}
+	if private != nil {
+		key.Sign = private
+	}
+	return key, nil
+}
+
+// KeyConfig names the signing keys:
+//
+//   - PrivateKeyFile: PEM private key that signs new tokens. When set,
+//     Secret is only used to verify tokens issued before the switch.
+//   - Secret: HMAC secret that signs new tokens otherwise. It must be at
+//     least MinSecretBytes long.
+//   - PreviousSecrets: retired secrets, verify only. They may be shorter,
+//     so that a short secret can still be rotated out.
+//   - PublicKeyFiles: PEM public keys of retired private keys, verify only.
+type KeyConfig struct {
+	Secret          string
+	PreviousSecrets []string
+	PrivateKeyFile  string
+	PublicKeyFiles  []string
+}
+
+// MinSecretBytes is the shortest JWT_SECRET accepted. HS256 needs a key as
+// long as its 32-byte hash to be as strong as the hash.
+const MinSecretBytes = 32
+
+// LoadKeySet reads the key files and builds the key set
+func LoadKeySet(cfg KeyConfig) (*KeySet, error) {
+	var active SigningKey
+	var retired []SigningKey
+
+	if cfg.Secret != "" && len(cfg.Secret) < MinSecretBytes {
+		return nil, fmt.Errorf("JWT_SECRET must be at least %d bytes, got %d", MinSecretBytes, len(cfg.Secret))
+	}
+
+	if cfg.PrivateKeyFile != "" {
+		key, err := LoadPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		active = key
+		if cfg.Secret != "" {
+			retired = append(retired, verifyOnly(HMACKey(cfg.Secret)))
+		}
+	} else if cfg.Secret != "" {
+		active = HMACKey(cfg.Secret)
+	} else {
+		return nil, fmt.Errorf("JWT_SECRET or JWT_PRIVATE_KEY_FILE must be set")
+	}
+
+	for _, previous := range cfg.PreviousSecrets {
+		retired = append(retired, verifyOnly(HMACKey(previous)))
+	}
+	for _, path := range cfg.PublicKeyFiles {
+		key, err := LoadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		retired = append(retired, key)
+	}
+
+	return NewKeySet(active, retired...)
+}
+
+func verifyOnly(key SigningKey) SigningKey {
+	key.Sign = nil
+	return key
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// writePEM writes a PEM block to a file in the test's temp dir
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rsaKeyFiles writes a fresh RSA key pair and returns the private and
+// public PEM paths
+func rsaKeyFiles(t *testing.T, bits int) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+		writePEM(t, "rsa.pub.pem", "PUBLIC KEY", public)
+}
+
+// edKeyFiles writes a fresh Ed25519 key pair and returns the private and
+// public PEM paths
+func edKeyFiles(t *testing.T) (string, string) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "ed.pem", "PRIVATE KEY", privateDER), writePEM(t, "ed.pub.pem", "PUBLIC KEY", publicDER)
+}
+
+func mustLoad(t *testing.T, load func(string) (SigningKey, error), path string) SigningKey {
+	t.Helper()
+	key, err := load(path)
+	if err != nil {
+		t.Fatalf("load %s: %v", path, err)
+	}
+	return key
+}
+
+func tokenConfig(t *testing.T, active SigningKey, retired ...SigningKey) TokenConfig {
+	t.Helper()
+	keys, err := NewKeySet(active, retired...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return TokenConfig{Keys: keys, AccessTTL: time.Minute, RefreshTTL: time.Hour}
+}
+
+func issue(t *testing.T, cfg TokenConfig) string {
+	t.Helper()
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com"}
+	token, err := generateAccessToken(cfg, user, primitive.NewObjectID().Hex(), time.Now())
+	if err != nil {
+		t.Fatalf("generateAccessToken: %v", err)
+	}
+	return token
+}
+
+// header decodes the JOSE header of a token without verifying it
+func header(t *testing.T, token string) map[string]any {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Header
+}
+
+func TestNewTokensUseTheActiveKey(t *testing.T) {
+	rsaPrivate, _ := rsaKeyFiles(t, 2048)
+	edPrivate, _ := edKeyFiles(t)
+	for _, active := range []SigningKey{
+		HMACKey(currentSecret),
+		mustLoad(t, LoadPrivateKey, rsaPrivate),
+		mustLoad(t, LoadPrivateKey, edPrivate),
+	} {
+		cfg := tokenConfig(t, active, verifyOnly(HMACKey("old secret")))
+		h := header(t, issue(t, cfg))
+		if h["kid"] != active.ID || h["alg"] != active.Method.Alg() {
+			t.Errorf("token header = %v, want kid %s and alg %s", h, active.ID, active.Method.Alg())
+		}
+	}
+}
+
+func TestRetiredKeysStillVerify(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeyFiles(t, 2048)
+	edPrivate, edPublic := edKeyFiles(t)
+
+	tests := []struct {
+		name    string
+		old     SigningKey
+		retired SigningKey
+	}{
+		{"HS secret", HMACKey("old secret"), verifyOnly(HMACKey("old secret"))},
+		{"RS public key", mustLoad(t, LoadPrivateKey, rsaPrivate), mustLoad(t, LoadPublicKey, rsaPublic)},
+		{"Ed public key", mustLoad(t, LoadPrivateKey, edPrivate), mustLoad(t, LoadPublicKey, edPublic)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.old.ID != tt.retired.ID {
+				t.Fatalf("retired key ID %s differs from the signing key ID %s", tt.retired.ID, tt.old.ID)
+			}
+			token := issue(t, tokenConfig(t, tt.old))
+
+			rotated := tokenConfig(t, HMACKey("new secret"), tt.retired)
+			if _, err := parseAccessToken(rotated, token); err != nil {
+				t.Errorf("token of the retired key: %v", err)
+			}
+			withoutOld := tokenConfig(t, HMACKey("new secret"))
+			if _, err := parseAccessToken(withoutOld, token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("token of a dropped key: %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestUnknownOrMissingKidIsRejected(t *testing.T) {
+	key := HMACKey("test secret")
+	cfg := tokenConfig(t, key)
+	claims := jwt.MapClaims{
+		"userId": primitive.NewObjectID().Hex(),
+		"sid":    primitive.NewObjectID().Hex(),
+		"jti":    primitive.NewObjectID().Hex(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	}
+
+	for name, kid := range map[string]any{"unknown": "hs-000000000000", "missing": nil, "not a string": 7} {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		// Signed with the right secret, so only the kid is wrong
+		signed, err := token.SignedString(key.Sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseAccessToken(cfg, signed); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s kid: %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+// TestAlgorithmConfusionIsRejected signs an HS256 token with the RSA public
+// key as the HMAC secret, the classic attack on verifiers that let the token
+// pick the algorithm
+func TestAlgorithmConfusionIsRejected(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeyFiles(t, 2048)
+	rsaKey := mustLoad(t, LoadPrivateKey, rsaPrivate)
+	publicPEM, err := os.ReadFile(rsaPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An HS key in the set puts HS256 on the allowed method list as well
+	cfg := tokenConfig(t, rsaKey, verifyOnly(HMACKey("old secret")))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": primitive.NewObjectID().Hex(),
+		"sid":    primitive.NewObjectID().Hex(),
+		"jti":    primitive.NewObjectID().Hex(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = rsaKey.ID
+	for _, secret := range [][]byte{publicPEM, []byte(rsaKey.ID)} {
+		signed, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseAccessToken(cfg, signed); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("HS256 token with the RS kid: %v, want ErrInvalidToken", err)
+		}
+	}
+}
+
+func TestNewKeySetValidates(t *testing.T) {
+	if _, err := NewKeySet(verifyOnly(HMACKey("secret"))); err == nil {
+		t.Error("NewKeySet accepted an active key that cannot sign")
+	}
+	if _, err := NewKeySet(HMACKey("secret"), verifyOnly(HMACKey("secret"))); err == nil {
+		t.Error("NewKeySet accepted a duplicate key ID")
+	}
+	if HMACKey("a").ID == HMACKey("b").ID || HMACKey("a").ID != HMACKey("a").ID {
+		t.Error("HMAC key IDs are not derived from the secret")
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeyFiles(t, 2048)
+	if key := mustLoad(t, LoadPrivateKey, rsaPrivate); !strings.HasPrefix(key.ID, "rs-") || key.Sign == nil {
+		t.Errorf("RSA private key = %+v", key)
+	}
+	if key := mustLoad(t, LoadPublicKey, rsaPublic); key.Sign != nil {
+		t.Error("a public key can sign")
+	}
+
+	edPrivate, _ := edKeyFiles(t)
+	if key := mustLoad(t, LoadPrivateKey, edPrivate); !strings.HasPrefix(key.ID, "ed-") || key.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("Ed25519 private key = %+v", key)
+	}
+
+	small, _ := rsaKeyFiles(t, 1024)
+	if _, err := LoadPrivateKey(small); err == nil {
+		t.Error("LoadPrivateKey accepted a 1024-bit RSA key")
+	}
+	if _, err := LoadPrivateKey(rsaPublic); err == nil {
+		t.Error("LoadPrivateKey accepted a public key")
+	}
+	if _, err := LoadPublicKey(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("LoadPublicKey accepted a missing file")
+	}
+}
+
+// currentSecret is exactly MinSecretBytes long
+const currentSecret = "current secret, 32 bytes long..."
+
+func TestLoadKeySet(t *testing.T) {
+	rsaPrivate, _ := rsaKeyFiles(t, 2048)
+	_, edPublic := edKeyFiles(t)
+
+	keys, err := LoadKeySet(KeyConfig{
+		Secret:          currentSecret,
+		PrivateKeyFile:  rsaPrivate,
+		PreviousSecrets: []string{"old secret", "older secret"},
+		PublicKeyFiles:  []string{edPublic},
+	})
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	if keys.active.ID != mustLoad(t, LoadPrivateKey, rsaPrivate).ID {
+		t.Errorf("active key = %s, want the private key file", keys.active.ID)
+	}
+	for _, id := range []string{
+		HMACKey(currentSecret).ID,
+		HMACKey("old secret").ID,
+		HMACKey("older secret").ID,
+		mustLoad(t, LoadPublicKey, edPublic).ID,
+	} {
+		key, ok := keys.keys[id]
+		if !ok || key.Sign != nil {
+			t.Errorf("key %s = %+v, want a verify-only key", id, key)
+		}
+	}
+
+	keys, err = LoadKeySet(KeyConfig{Secret: currentSecret})
+	if err != nil || keys.active.ID != HMACKey(currentSecret).ID {
+		t.Errorf("secret only = %v, %v, want the secret to sign", keys, err)
+	}
+
+	for name, cfg := range map[string]KeyConfig{
+		"no signing key":     {PreviousSecrets: []string{"old secret"}},
+		"missing key file":   {PrivateKeyFile: rsaPrivate + ".missing"},
+		"missing public key": {Secret: currentSecret, PublicKeyFiles: []string{edPublic + ".missing"}},
+		"short secret":       {Secret: currentSecret[1:]},
+		"short old secret":   {Secret: currentSecret[1:], PrivateKeyFile: rsaPrivate},
+	} {
+		if _, err := LoadKeySet(cfg); err == nil {
+			t.Errorf("%s: LoadKeySet succeeded", name)
+		}
+	}
+}
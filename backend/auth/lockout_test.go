@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(LockoutConfig{MaxFailures: 3, Window: 15 * time.Minute, Duration: time.Hour}, repository.NewMemoryLoginAttemptRepository())
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if locked, err := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now); err != nil || locked != 0 {
+			t.Fatalf("failure %d locked for %v, %v", i+1, locked, err)
+		}
+	}
+	if locked, err := lockout.Check(ctx, "ada@example.com", "192.0.2.1", now); err != nil || locked != 0 {
+		t.Fatalf("locked for %v, %v below MaxFailures", locked, err)
+	}
+	if locked, err := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now); err != nil || locked != time.Hour {
+		t.Fatalf("third failure locked for %v, %v, want 1h", locked, err)
+	}
+	if locked, _ := lockout.Check(ctx, "ada@example.com", "192.0.2.1", now.Add(20*time.Minute)); locked != 40*time.Minute {
+		t.Errorf("20m into the lock %v remain, want 40m", locked)
+	}
+	if locked, _ := lockout.Check(ctx, "bob@example.com", "192.0.2.1", now); locked != 0 {
+		t.Errorf("another account is locked for %v", locked)
+	}
+	if locked, _ := lockout.Check(ctx, "ada@example.com", "192.0.2.1", now.Add(time.Hour)); locked != 0 {
+		t.Errorf("still locked for %v once the lock ran out", locked)
+	}
+}
+
+func TestLockoutIsPerClient(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(LockoutConfig{MaxFailures: 2, Window: 15 * time.Minute, Duration: time.Hour}, repository.NewMemoryLoginAttemptRepository())
+	now := time.Now()
+
+	// Someone else failing on purpose does not lock the user out
+	lockout.Fail(ctx, "ada@example.com", "198.51.100.7", now)
+	if locked, _ := lockout.Fail(ctx, "ada@example.com", "198.51.100.7", now); locked != time.Hour {
+		t.Fatalf("second failure locked for %v, want 1h", locked)
+	}
+	if locked, _ := lockout.Check(ctx, "ada@example.com", "192.0.2.1", now); locked != 0 {
+		t.Errorf("another client is locked for %v", locked)
+	}
+	if locked, _ := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now); locked != 0 {
+		t.Errorf("another client's first failure locked for %v", locked)
+	}
+
+	// and a success from another client does not unlock the first
+	if err := lockout.Reset(ctx, "ada@example.com", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if locked, _ := lockout.Check(ctx, "ada@example.com", "198.51.100.7", now); locked != time.Hour {
+		t.Errorf("the failing client is locked for %v after another client's login, want 1h", locked)
+	}
+}
+
+func TestLockoutWindowAndReset(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(LockoutConfig{MaxFailures: 2, Window: 15 * time.Minute, Duration: time.Hour}, repository.NewMemoryLoginAttemptRepository())
+	now := time.Now()
+
+	// Failures outside the window are forgotten
+	lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now)
+	if locked, _ := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now.Add(16*time.Minute)); locked != 0 {
+		t.Errorf("failures 16m apart locked for %v", locked)
+	}
+
+	// A successful login starts the count again
+	if err := lockout.Reset(ctx, "ada@example.com", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if locked, _ := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now.Add(17*time.Minute)); locked != 0 {
+		t.Errorf("the first failure after a reset locked for %v", locked)
+	}
+	if locked, _ := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now.Add(18*time.Minute)); locked != time.Hour {
+		t.Errorf("the second failure after a reset locked for %v, want 1h", locked)
+	}
+}
+
+func TestLockoutDisabled(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(LockoutConfig{}, repository.NewMemoryLoginAttemptRepository())
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		if locked, err := lockout.Fail(ctx, "ada@example.com", "192.0.2.1", now); err != nil || locked != 0 {
+			t.Fatalf("a disabled lockout locked for %v, %v", locked, err)
+		}
+	}
+	if locked, _ := lockout.Check(ctx, "ada@example.com", "192.0.2.1", now); locked != 0 {
+		t.Errorf("a disabled lockout reports %v", locked)
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptCost is the work factor for new password hashes
+const bcryptCost = 14
+
+// HashPassword returns the bcrypt hash of a password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches a hash from HashPassword
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// dummyHash is hashed at bcryptCost on first use, so comparing against it
+// takes as long as comparing against a real hash
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("taskmorph-no-such-user"), bcryptCost)
+	return hash
+})
+
+// RejectPassword spends the time CheckPassword would and always fails. Login
+// uses it for unknown emails so response times do not reveal which exist.
+func RejectPassword(password string) bool {
+	bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+	return false
+}
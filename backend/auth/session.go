@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TokenPair is what a client receives on login and refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// SessionService issues short-lived access tokens and rotating refresh
+// tokens. Refresh tokens are opaque "<session id>.<secret>" strings; only a
+// hash of the secret is stored. Presenting a refresh token that was already
+// rotated out revokes the whole session, since it means the token leaked.
+type SessionService struct {
+	cfg      TokenConfig
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	revoked  repository.RevokedTokenRepository
+}
+
+func NewSessionService(cfg TokenConfig, users repository.UserRepository, sessions repository.SessionRepository, revoked repository.RevokedTokenRepository) *SessionService {
+	return &SessionService{cfg: cfg, users: users, sessions: sessions, revoked: revoked}
+}
+
+// Start opens a new session for a user who just proved their credentials
+func (s *SessionService) Start(ctx context.Context, user *models.User) (*TokenPair, error) {
+	now := time.Now()
+	secret, hash, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:      user.ID.Hex(),
+		RefreshHash: hash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.RefreshTTL),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.issue(user, session.ID, secret, now)
+}
+
+// Refresh redeems a refresh token for a new token pair
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	session, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesRefreshHash(refreshToken, session.RefreshHash) {
+		if err := s.revokeSession(ctx, session.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := primitive.ObjectIDFromHex(session.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, hash, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	// Losing this race to a concurrent refresh of the same token is not
+	// treated as reuse; the other request got the new pair
+	err = s.sessions.Rotate(ctx, session.ID, session.RefreshHash, hash, now.Add(s.cfg.RefreshTTL))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user, session.ID, secret, now)
+}
+
+// Logout revokes the session behind a refresh token and, if the caller also
+// sent a valid access token, that token and its session
+func (s *SessionService) Logout(ctx context.Context, refreshToken string, claims *Claims) error {
+	now := time.Now()
+	if claims != nil {
+		if err := s.revoked.Revoke(ctx, claims.ID, claims.Expiry()); err != nil {
+			return err
+		}
+		if sessionID, err := primitive.ObjectIDFromHex(claims.SessionID); err == nil {
+			if err := s.revokeSession(ctx, sessionID, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	session, err := s.lookup(ctx, refreshToken)
+	if errors.Is(err, ErrInvalidToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !matchesRefreshHash(refreshToken, session.RefreshHash) {
+		return nil
+	}
+	return s.revokeSession(ctx, session.ID, now)
+}
+
+// RevokeAll ends every session of a user, for example after a password
+// change
+func (s *SessionService) RevokeAll(ctx context.Context, userID string) error {
+	now := time.Now()
+	ids, err := s.sessions.RevokeAllForUser(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.revoked.Revoke(ctx, id.Hex(), now.Add(s.cfg.AccessTTL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Authenticate verifies an access token and checks it against the deny list
+func (s *SessionService) Authenticate(ctx context.Context, accessToken string) (*Claims, error) {
+	claims, err := parseAccessToken(s.cfg, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.revoked.IsRevoked(ctx, claims.ID, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (s *SessionService) issue(user *models.User, sessionID primitive.ObjectID, secret string, now time.Time) (*TokenPair, error) {
+	accessToken, err := generateAccessToken(s.cfg, user, sessionID.Hex(), now)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: sessionID.Hex() + "." + secret,
+		ExpiresIn:    s.cfg.AccessTTL,
+	}, nil
+}
+
+// lookup finds the live session a refresh token points at, without checking
+// the secret
+func (s *SessionService) lookup(ctx context.Context, refreshToken string) (*models.Session, error) {
+	rawID, _, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	sessionID, err := primitive.ObjectIDFromHex(rawID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := s.sessions.FindByID(ctx, sessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	return session, nil
+}
+
+// revokeSession ends a session and blocks its outstanding access tokens,
+// which can live at most AccessTTL longer
+func (s *SessionService) revokeSession(ctx context.Context, id primitive.ObjectID, now time.Time) error {
+	if err := s.sessions.Revoke(ctx, id, now); err != nil {
+		return err
+	}
+	return s.revoked.Revoke(ctx, id.Hex(), now.Add(s.cfg.AccessTTL))
+}
+
+func newRefreshSecret() (secret, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	secret = base64.RawURLEncoding.EncodeToString(buf)
+	return secret, hashRefreshSecret(secret), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func matchesRefreshHash(refreshToken, hash string) bool {
+	_, secret, _ := strings.Cut(refreshToken, ".")
+	return subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(hash)) == 1
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+func testTokenConfig(t *testing.T) TokenConfig {
+	t.Helper()
+	keys, err := NewKeySet(HMACKey("test secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return TokenConfig{Keys: keys, AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour}
+}
+
+func newTestSessions(t *testing.T, cfg TokenConfig) (*SessionService, *models.User) {
+	t.Helper()
+	users := repository.NewMemoryUserRepository()
+	user := &models.User{Name: "Ada", Email: "ada@example.com"}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return NewSessionService(cfg, users, repository.NewMemorySessionRepository(), repository.NewMemoryRevokedTokenRepository()), user
+}
+
+func mustStart(t *testing.T, s *SessionService, user *models.User) *TokenPair {
+	t.Helper()
+	pair, err := s.Start(context.Background(), user)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return pair
+}
+
+func TestSessionStartAndAuthenticate(t *testing.T) {
+	s, user := newTestSessions(t, testTokenConfig(t))
+	ctx := context.Background()
+	pair := mustStart(t, s, user)
+
+	claims, err := s.Authenticate(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.UserID != user.ID.Hex() || claims.Email != user.Email || claims.SessionID == "" || claims.ID == "" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if !strings.HasPrefix(pair.RefreshToken, claims.SessionID+".") {
+		t.Errorf("refresh token %q does not name session %s", pair.RefreshToken, claims.SessionID)
+	}
+	if pair.ExpiresIn != 15*time.Minute {
+		t.Errorf("expires in %v, want 15m", pair.ExpiresIn)
+	}
+
+	for _, token := range []string{"", "garbage", pair.AccessToken + "x", pair.RefreshToken} {
+		if _, err := s.Authenticate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Authenticate(%q) = %v, want ErrInvalidToken", token, err)
+		}
+	}
+
+	other, err := NewKeySet(HMACKey("another secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, _ := newTestSessions(t, TokenConfig{Keys: other, AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if _, err := s.Authenticate(ctx, mustStart(t, forged, user).AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with another key: %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	cfg := testTokenConfig(t)
+	cfg.AccessTTL = -time.Minute
+	cfg.RefreshTTL = -time.Minute
+	s, user := newTestSessions(t, cfg)
+	pair := mustStart(t, s, user)
+
+	if _, err := s.Authenticate(context.Background(), pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired access token: %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Refresh(context.Background(), pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired refresh token: %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestSessionRefreshRotates(t *testing.T) {
+	s, user := newTestSessions(t, testTokenConfig(t))
+	ctx := context.Background()
+	first := mustStart(t, s, user)
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("Refresh returned the same tokens")
+	}
+	if _, err := s.Authenticate(ctx, second.AccessToken); err != nil {
+		t.Fatalf("refreshed access token: %v", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("second Refresh: %v", err)
+	}
+
+	for _, token := range []string{"", "no-dot", "not-an-id.secret", "65f000000000000000000000.secret"} {
+		if _, err := s.Refresh(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Refresh(%q) = %v, want ErrInvalidToken", token, err)
+		}
+	}
+}
+
+func TestSessionRefreshReuseRevokesSession(t *testing.T) {
+	s, user := newTestSessions(t, testTokenConfig(t))
+	ctx := context.Background()
+	first := mustStart(t, s, user)
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	untouched := mustStart(t, s, user)
+
+	// Redeeming a rotated-out token means it leaked: the whole session ends
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("reused refresh token: %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("current refresh token after reuse: %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Authenticate(ctx, second.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token after reuse: %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := s.Authenticate(ctx, untouched.AccessToken); err != nil {
+		t.Errorf("another session was revoked: %v", err)
+	}
+	if _, err := s.Refresh(ctx, untouched.RefreshToken); err != nil {
+		t.Errorf("another session cannot refresh: %v", err)
+	}
+}
+
+func TestSessionLogout(t *testing.T) {
+	s, user := newTestSessions(t, testTokenConfig(t))
+	ctx := context.Background()
+
+	byRefresh := mustStart(t, s, user)
+	if err := s.Logout(ctx, byRefresh.RefreshToken, nil); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := s.Refresh(ctx, byRefresh.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh after logout: %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Authenticate(ctx, byRefresh.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token after logout: %v, want ErrInvalidToken", err)
+	}
+
+	byAccess := mustStart(t, s, user)
+	claims, err := s.Authenticate(ctx, byAccess.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Logout(ctx, "", claims); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := s.Refresh(ctx, byAccess.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh after logout by access token: %v, want ErrInvalidToken", err)
+	}
+
+	// Logging out twice, or with junk, is not an error
+	if err := s.Logout(ctx, byRefresh.RefreshToken, nil); err != nil {
+		t.Errorf("second Logout: %v", err)
+	}
+	if err := s.Logout(ctx, "junk", nil); err != nil {
+		t.Errorf("Logout with junk: %v", err)
+	}
+}
+
+func TestSessionRevokeAll(t *testing.T) {
+	s, user := newTestSessions(t, testTokenConfig(t))
+	ctx := context.Background()
+	pairs := []*TokenPair{mustStart(t, s, user), mustStart(t, s, user)}
+
+	if err := s.RevokeAll(ctx, user.ID.Hex()); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	for i, pair := range pairs {
+		if _, err := s.Authenticate(ctx, pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("session %d access token: %v, want ErrInvalidToken", i, err)
+		}
+		if _, err := s.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("session %d refresh token: %v, want ErrInvalidToken", i, err)
+		}
+	}
+	if _, err := s.Authenticate(ctx, mustStart(t, s, user).AccessToken); err != nil {
+		t.Errorf("new session after RevokeAll: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Package auth issues and verifies the tokens that authenticate API requests
+// and exposes the authenticated user to handlers.
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidToken covers every rejected access or refresh token: malformed,
+// badly signed, expired, revoked or already redeemed
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// TokenConfig holds the signing keys and token lifetimes
+type TokenConfig struct {
+	Keys       *KeySet
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Claims are the claims of an access token. UserID is the hex ObjectID of
+// the user, ID is the jti, unique per token, and SessionID ties the token to
+// the login that issued it.
+type Claims struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// Expiry returns the time the token expires
+func (c *Claims) Expiry() time.Time {
+	return c.ExpiresAt.Time
+}
+
+func generateAccessToken(cfg TokenConfig, user *models.User, sessionID string, now time.Time) (string, error) {
+	return cfg.Keys.sign(Claims{
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.AccessTTL)),
+		},
+	})
+}
+
+func parseAccessToken(cfg TokenConfig, tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, cfg.Keys.keyFunc,
+		jwt.WithValidMethods(cfg.Keys.methods()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.ID == "" || claims.SessionID == "" {
+		return nil, ErrInvalidToken
+	}
+	if _, err := primitive.ObjectIDFromHex(claims.UserID); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// accountClaims are the claims of an emailed verification or reset token.
+// Subject is the user ID and ID is the AccountToken record, which makes the
+// token single-use. Email is the address the token was sent to.
+type accountClaims struct {
+	Purpose string `json:"purpose"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+func generateAccountToken(cfg TokenConfig, record *models.AccountToken) (string, error) {
+	return cfg.Keys.sign(accountClaims{
+		Purpose: record.Purpose,
+		Email:   record.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        record.ID.Hex(),
+			Subject:   record.UserID,
+			IssuedAt:  jwt.NewNumericDate(record.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+		},
+	})
+}
+
+func parseAccountToken(cfg TokenConfig, tokenString, purpose string) (*accountClaims, error) {
+	var claims accountClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, cfg.Keys.keyFunc,
+		jwt.WithValidMethods(cfg.Keys.methods()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	cfg := testTokenConfig(t)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com"}
+	sessionID := primitive.NewObjectID().Hex()
+
+	token, err := generateAccessToken(cfg, user, sessionID, time.Now())
+	if err != nil {
+		t.Fatalf("generateAccessToken: %v", err)
+	}
+	claims, err := parseAccessToken(cfg, token)
+	if err != nil {
+		t.Fatalf("parseAccessToken: %v", err)
+	}
+	if claims.UserID != user.ID.Hex() || claims.Email != user.Email || claims.SessionID != sessionID || claims.ID == "" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if got := time.Until(claims.Expiry()); got <= 14*time.Minute || got > 15*time.Minute {
+		t.Errorf("token expires in %v, want the 15m access TTL", got)
+	}
+}
+
+func TestParseAccessTokenRejects(t *testing.T) {
+	cfg := testTokenConfig(t)
+	key := HMACKey("test secret")
+	valid := jwt.MapClaims{
+		"userId": primitive.NewObjectID().Hex(),
+		"email":  "ada@example.com",
+		"sid":    primitive.NewObjectID().Hex(),
+		"jti":    primitive.NewObjectID().Hex(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	}
+	sign := func(method jwt.SigningMethod, secret any, edit func(jwt.MapClaims)) string {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		if edit != nil {
+			edit(claims)
+		}
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	if _, err := parseAccessToken(cfg, sign(jwt.SigningMethodHS256, key.Sign, nil)); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	tests := map[string]string{
+		"other secret":      sign(jwt.SigningMethodHS256, []byte("other secret"), nil),
+		"alg none":          sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, nil),
+		"expired":           sign(jwt.SigningMethodHS256, key.Sign, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }),
+		"no expiry":         sign(jwt.SigningMethodHS256, key.Sign, func(c jwt.MapClaims) { delete(c, "exp") }),
+		"legacy email-only": sign(jwt.SigningMethodHS256, key.Sign, func(c jwt.MapClaims) { delete(c, "userId") }),
+		"invalid userId":    sign(jwt.SigningMethodHS256, key.Sign, func(c jwt.MapClaims) { c["userId"] = "ada" }),
+		"no jti":            sign(jwt.SigningMethodHS256, key.Sign, func(c jwt.MapClaims) { delete(c, "jti") }),
+		"no session":        sign(jwt.SigningMethodHS256, key.Sign, func(c jwt.MapClaims) { delete(c, "sid") }),
+	}
+	for name, token := range tests {
+		if _, err := parseAccessToken(cfg, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
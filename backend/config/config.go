@@ -0,0 +1,427 @@
+// Package config loads every server setting once at startup.
+//
+// Settings are named like environment variables (JWT_SECRET, SMTP_PORT, ...)
+// and are looked up, first match wins, in:
+//
+//  1. the process environment
+//  2. the .env file, or the file named by ENV_FILE, if present
+//  3. the YAML file named by CONFIG_FILE, if set
+//  4. the built-in defaults
+//
+// In the YAML file, nested keys are joined with underscores, so
+//
+//	smtp:
+//	  host: mail.example.com
+//	  port: 2525
+//
+// sets SMTP_HOST and SMTP_PORT. Lists may be YAML sequences or
+// comma-separated strings.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/auth"
+	"github.com/Vanaraj10/taskmorph-backend/logging"
+	"github.com/Vanaraj10/taskmorph-backend/mailer"
+	"github.com/Vanaraj10/taskmorph-backend/middleware"
+	"github.com/Vanaraj10/taskmorph-backend/notifications"
+	"github.com/Vanaraj10/taskmorph-backend/ratelimit"
+	"github.com/Vanaraj10/taskmorph-backend/scheduler"
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/Vanaraj10/taskmorph-backend/store"
+	"github.com/Vanaraj10/taskmorph-backend/utils"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Storage backends
+const (
+	StorageMongo  = "mongo"
+	StorageMemory = "memory"
+)
+
+// Config is every setting of the server
+type Config struct {
+	Port string
+	// ShutdownTimeout bounds how long a shutdown waits for requests and jobs
+	// in progress
+	ShutdownTimeout time.Duration
+	// Storage is StorageMongo or StorageMemory
+	Storage string
+	Mongo   store.Config
+	// MigrateOnStart applies pending migrations before serving
+	MigrateOnStart bool
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For is believed.
+	// Empty trusts none, so the client IP is the connection's address.
+	TrustedProxies []string
+
+	Log           logging.Config
+	Token         auth.TokenConfig
+	Account       auth.AccountConfig
+	Lockout       auth.LockoutConfig
+	Mail          mailer.Config
+	Notifications notifications.Config
+	Generator     services.GeneratorConfig
+	Quota         services.QuotaConfig
+	Scheduler     scheduler.Config
+	RateLimit     ratelimit.Config
+	CORS          middleware.CORSConfig
+}
+
+// Load reads the configuration and validates all of it, returning every
+// problem found joined into one error rather than stopping at the first
+func Load() (*Config, error) {
+	src, err := newSource()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Port:            src.str("PORT", "8080"),
+		ShutdownTimeout: src.duration("SHUTDOWN_TIMEOUT", 15*time.Second, time.Nanosecond),
+		Storage:         src.oneOf("STORAGE", StorageMongo, StorageMongo, StorageMemory),
+	}
+	cfg.TrustedProxies = src.list("TRUSTED_PROXIES", nil)
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			src.fail("TRUSTED_PROXIES: %q is not an IP address or CIDR", proxy)
+		}
+	}
+
+	cfg.Mongo = store.Config{
+		URI:                    src.str("MONGO_URI", ""),
+		Database:               src.str("MONGO_DATABASE", "taskmorph"),
+		MaxPoolSize:            uint64(src.integer("MONGO_MAX_POOL_SIZE", 100, 0)),
+		MinPoolSize:            uint64(src.integer("MONGO_MIN_POOL_SIZE", 0, 0)),
+		ConnectTimeout:         src.duration("MONGO_CONNECT_TIMEOUT", 10*time.Second, time.Nanosecond),
+		ServerSelectionTimeout: src.duration("MONGO_SERVER_SELECTION_TIMEOUT", 10*time.Second, time.Nanosecond),
+		ConnectRetries:         src.integer("MONGO_CONNECT_RETRIES", 5, 0),
+		RetryBackoff:           src.duration("MONGO_RETRY_BACKOFF", time.Second, time.Nanosecond),
+	}
+	cfg.MigrateOnStart = src.boolean("MIGRATE_ON_START", true)
+	if cfg.Storage == StorageMongo && cfg.Mongo.URI == "" {
+		src.fail("MONGO_URI is required when STORAGE is mongo")
+	}
+	if cfg.Mongo.MaxPoolSize != 0 && cfg.Mongo.MinPoolSize > cfg.Mongo.MaxPoolSize {
+		src.fail("MONGO_MIN_POOL_SIZE cannot exceed MONGO_MAX_POOL_SIZE")
+	}
+
+	cfg.Log = logging.Config{
+		Level:  src.level("LOG_LEVEL", slog.LevelInfo),
+		Format: src.oneOf("LOG_FORMAT", "json", "json", "text"),
+	}
+
+	cfg.Token = auth.TokenConfig{
+		AccessTTL:  src.duration("ACCESS_TOKEN_TTL", 15*time.Minute, time.Nanosecond),
+		RefreshTTL: src.duration("REFRESH_TOKEN_TTL", 30*24*time.Hour, time.Nanosecond),
+	}
+	keys := auth.KeyConfig{
+		Secret:          src.str("JWT_SECRET", ""),
+		PreviousSecrets: src.list("JWT_PREVIOUS_SECRETS", nil),
+		PrivateKeyFile:  src.str("JWT_PRIVATE_KEY_FILE", ""),
+		PublicKeyFiles:  src.list("JWT_PUBLIC_KEY_FILES", nil),
+	}
+	if cfg.Token.Keys, err = auth.LoadKeySet(keys); err != nil {
+		src.fail("%v", err)
+	}
+
+	appURL := strings.TrimRight(src.str("APP_URL", "http://localhost:5173"), "/")
+	cfg.Account = auth.AccountConfig{
+		AppURL:               appURL,
+		RequireVerifiedEmail: src.boolean("REQUIRE_VERIFIED_EMAIL", false),
+		VerifyTTL:            48 * time.Hour,
+		ResetTTL:             time.Hour,
+	}
+	cfg.Lockout = auth.LockoutConfig{
+		MaxFailures: src.integer("LOGIN_MAX_FAILURES", 5, 0),
+		Window:      src.duration("LOGIN_FAILURE_WINDOW", 15*time.Minute, time.Nanosecond),
+		Duration:    src.duration("LOGIN_LOCKOUT_DURATION", 15*time.Minute, time.Nanosecond),
+	}
+
+	cfg.Mail = mailer.Config{
+		Host:     src.str("SMTP_HOST", ""),
+		Port:     src.integer("SMTP_PORT", 587, 1),
+		Username: src.str("SMTP_USERNAME", ""),
+		Password: src.str("SMTP_PASSWORD", ""),
+		From:     src.str("SMTP_FROM", "TaskMorph <no-reply@taskmorph.local>"),
+		Timeout:  10 * time.Second,
+	}
+	cfg.Notifications = notifications.Config{
+		WebhookSecret:  src.str("WEBHOOK_SECRET", ""),
+		WebhookTimeout: 10 * time.Second,
+	}
+
+	cfg.Generator = services.GeneratorConfig{
+		Provider: src.oneOf("AI_PROVIDER", services.ProviderGemini,
+			services.ProviderGemini, services.ProviderOpenAI, services.ProviderOffline),
+		Model:   src.str("AI_MODEL", ""),
+		BaseURL: src.str("AI_BASE_URL", ""),
+		Timeout: src.duration("AI_TIMEOUT", 30*time.Second, time.Nanosecond),
+	}
+	geminiKey := src.str("GEMINI_API_KEY", "")
+	openAIKey := src.str("OPENAI_API_KEY", "")
+	switch cfg.Generator.Provider {
+	case services.ProviderGemini:
+		cfg.Generator.APIKey = geminiKey
+	case services.ProviderOpenAI:
+		cfg.Generator.APIKey = openAIKey
+	}
+	cfg.Quota = services.QuotaConfig{
+		DailyRequests:   int64(src.integer("AI_DAILY_REQUEST_QUOTA", 50, 0)),
+		MonthlyRequests: int64(src.integer("AI_MONTHLY_REQUEST_QUOTA", 500, 0)),
+		DailyTokens:     int64(src.integer("AI_DAILY_TOKEN_QUOTA", 0, 0)),
+		MonthlyTokens:   int64(src.integer("AI_MONTHLY_TOKEN_QUOTA", 0, 0)),
+	}
+
+	cfg.Scheduler = scheduler.DefaultConfig()
+	cfg.Scheduler.ReminderSpec = src.str("REMINDER_CRON", cfg.Scheduler.ReminderSpec)
+	cfg.Scheduler.OverdueSpec = src.str("OVERDUE_CRON", cfg.Scheduler.OverdueSpec)
+	if raw := src.str("REMINDER_LEAD_TIMES", ""); raw != "" {
+		leads, err := scheduler.ParseLeadTimes(raw)
+		if err != nil {
+			src.fail("invalid REMINDER_LEAD_TIMES: %v", err)
+		}
+		cfg.Scheduler.LeadTimes = leads
+	}
+	cfg.Scheduler.Lookback = src.duration("REMINDER_LOOKBACK", cfg.Scheduler.Lookback, 0)
+	if err := cfg.Scheduler.Validate(); err != nil {
+		src.fail("%v", err)
+	}
+
+	cfg.RateLimit = ratelimit.Config{
+		Auth:  ratelimit.Policy{PerIP: src.rule("RATE_LIMIT_AUTH_IP", "30/m"), PerUser: src.rule("RATE_LIMIT_AUTH_USER", "off")},
+		AI:    ratelimit.Policy{PerIP: src.rule("RATE_LIMIT_AI_IP", "30/m"), PerUser: src.rule("RATE_LIMIT_AI_USER", "10/m")},
+		API:   ratelimit.Policy{PerIP: src.rule("RATE_LIMIT_API_IP", "600/m"), PerUser: src.rule("RATE_LIMIT_API_USER", "300/m")},
+		Store: src.oneOf("RATE_LIMIT_STORE", StorageMemory, StorageMemory, StorageMongo),
+	}
+	if cfg.RateLimit.Store == StorageMongo && cfg.Storage != StorageMongo {
+		src.fail("RATE_LIMIT_STORE=mongo needs STORAGE=mongo")
+	}
+
+	cfg.CORS = middleware.CORSConfig{
+		AllowedOrigins:   src.list("CORS_ALLOWED_ORIGINS", []string{appURL}),
+		AllowedMethods:   src.list("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		AllowedHeaders:   src.list("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", middleware.RequestIDHeader}),
+		ExposedHeaders:   src.list("CORS_EXPOSED_HEADERS", []string{middleware.RequestIDHeader, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"}),
+		AllowCredentials: src.boolean("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           src.duration("CORS_MAX_AGE", 2*time.Hour, 0),
+	}
+	for i, method := range cfg.CORS.AllowedMethods {
+		cfg.CORS.AllowedMethods[i] = strings.ToUpper(method)
+	}
+	if err := cfg.CORS.Validate(); err != nil {
+		src.fail("%v", err)
+	}
+
+	src.checkUnknown()
+	if err := errors.Join(src.errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Warnings lists settings that are valid but probably not what was meant
+func (cfg *Config) Warnings() []string {
+	var warnings []string
+	if cfg.Generator.Provider == services.ProviderGemini && cfg.Generator.APIKey == "" {
+		warnings = append(warnings, "GEMINI_API_KEY is not set, AI step generation will fail")
+	}
+	if cfg.Mail.Host == "" {
+		warnings = append(warnings, "SMTP_HOST is not set, emails are only logged")
+	}
+	return warnings
+}
+
+// source looks settings up in the environment, .env and YAML layers and
+// collects the problems it finds along the way
+type source struct {
+	dotenv map[string]string
+	file   map[string]string
+	// fileName is the YAML file, for error messages
+	fileName string
+	// known is every setting Load asked for, to spot typos in the YAML file
+	known map[string]bool
+	errs  []error
+}
+
+func newSource() (*source, error) {
+	src := &source{known: make(map[string]bool)}
+
+	envFile, explicit := os.LookupEnv("ENV_FILE")
+	if !explicit {
+		envFile = ".env"
+	}
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+		return nil, fmt.Errorf("reading %s: %w", envFile, err)
+	}
+	src.dotenv = dotenv
+
+	src.fileName, _ = src.lookup("CONFIG_FILE")
+	if src.fileName != "" {
+		data, err := os.ReadFile(src.fileName)
+		if err != nil {
+			return nil, fmt.Errorf("reading CONFIG_FILE: %w", err)
+		}
+		var tree map[string]any
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", src.fileName, err)
+		}
+		src.file = make(map[string]string)
+		flatten("", tree, src.file)
+	}
+	return src, nil
+}
+
+// flatten turns nested YAML maps into SECTION_KEY settings
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for key, value := range tree {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(name, v, out)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[name] = strings.Join(items, ",")
+		case nil:
+			out[name] = ""
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+func (s *source) lookup(name string) (string, bool) {
+	s.known[name] = true
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	if v, ok := s.dotenv[name]; ok {
+		return v, true
+	}
+	v, ok := s.file[name]
+	return v, ok
+}
+
+func (s *source) fail(format string, args ...any) {
+	s.errs = append(s.errs, fmt.Errorf(format, args...))
+}
+
+// str returns the setting, or def when it is unset or empty
+func (s *source) str(name, def string) string {
+	if v, ok := s.lookup(name); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	return def
+}
+
+func (s *source) oneOf(name, def string, allowed ...string) string {
+	v := strings.ToLower(s.str(name, def))
+	if !slices.Contains(allowed, v) {
+		s.fail("invalid %s %q, want one of %s", name, v, strings.Join(allowed, ", "))
+		return def
+	}
+	return v
+}
+
+func (s *source) integer(name string, def, min int) int {
+	raw := s.str(name, "")
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min {
+		s.fail("invalid %s %q, want an integer of at least %d", name, raw, min)
+		return def
+	}
+	return n
+}
+
+func (s *source) duration(name string, def, min time.Duration) time.Duration {
+	raw := s.str(name, "")
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < min {
+		s.fail("invalid %s %q, want a duration such as 15m", name, raw)
+		return def
+	}
+	return d
+}
+
+func (s *source) boolean(name string, def bool) bool {
+	raw := s.str(name, "")
+	if raw == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		s.fail("invalid %s %q, want true or false", name, raw)
+		return def
+	}
+	return b
+}
+
+func (s *source) list(name string, def []string) []string {
+	if items := utils.SplitList(s.str(name, "")); len(items) > 0 {
+		return items
+	}
+	return def
+}
+
+func (s *source) level(name string, def slog.Level) slog.Level {
+	raw := s.str(name, "")
+	if raw == "" {
+		return def
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		s.fail("invalid %s %q, want debug, info, warn or error", name, raw)
+		return def
+	}
+	return level
+}
+
+// rule parses a rate limit. Unlike other settings an empty value is not
+// the default but "off".
+func (s *source) rule(name, def string) ratelimit.Rule {
+	raw, ok := s.lookup(name)
+	if !ok {
+		raw = def
+	}
+	rule, err := ratelimit.ParseRule(raw)
+	if err != nil {
+		s.fail("invalid %s: %v", name, err)
+	}
+	return rule
+}
+
+// checkUnknown reports YAML settings that Load never asked for
+func (s *source) checkUnknown() {
+	var unknown []string
+	for name := range s.file {
+		if !s.known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+	for _, name := range unknown {
+		s.fail("unknown setting %s in %s", name, s.fileName)
+	}
+}
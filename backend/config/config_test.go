@@ -0,0 +1,231 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/ratelimit"
+	"github.com/Vanaraj10/taskmorph-backend/services"
+)
+
+// testSecret is exactly auth.MinSecretBytes long
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// clearEnv empties the environment for the test so settings from the shell
+// running it cannot leak in, then applies env
+func clearEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	saved := os.Environ()
+	os.Clearenv()
+	t.Cleanup(func() {
+		os.Clearenv()
+		for _, kv := range saved {
+			name, value, _ := strings.Cut(kv, "=")
+			os.Setenv(name, value)
+		}
+	})
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+}
+
+// writeFile writes content to name in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t, map[string]string{
+		"ENV_FILE":   writeFile(t, ".env", ""),
+		"STORAGE":    "memory",
+		"JWT_SECRET": testSecret,
+	})
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Port != "8080" || cfg.ShutdownTimeout != 15*time.Second || cfg.Storage != StorageMemory || cfg.TrustedProxies != nil {
+		t.Errorf("server = %q %v %q %v", cfg.Port, cfg.ShutdownTimeout, cfg.Storage, cfg.TrustedProxies)
+	}
+	if cfg.Mongo.Database != "taskmorph" || cfg.Mongo.MaxPoolSize != 100 || cfg.Mongo.ConnectRetries != 5 || cfg.Mongo.RetryBackoff != time.Second {
+		t.Errorf("mongo = %+v", cfg.Mongo)
+	}
+	if cfg.Token.AccessTTL != 15*time.Minute || cfg.Token.RefreshTTL != 30*24*time.Hour || cfg.Token.Keys == nil {
+		t.Errorf("token = %+v", cfg.Token)
+	}
+	if cfg.Log.Format != "json" || cfg.Mail.Port != 587 || cfg.Generator.Provider != services.ProviderGemini || cfg.Quota.DailyRequests != 50 {
+		t.Errorf("log %+v, mail port %d, provider %s, quota %+v", cfg.Log, cfg.Mail.Port, cfg.Generator.Provider, cfg.Quota)
+	}
+	if cfg.RateLimit.AI.PerUser != (ratelimit.Rule{Limit: 10, Period: time.Minute}) || cfg.RateLimit.Auth.PerUser.Limit != 0 {
+		t.Errorf("rate limits = %+v", cfg.RateLimit)
+	}
+	if !slices.Equal(cfg.CORS.AllowedOrigins, []string{"http://localhost:5173"}) || cfg.Account.AppURL != "http://localhost:5173" {
+		t.Errorf("CORS origins %v, app URL %q", cfg.CORS.AllowedOrigins, cfg.Account.AppURL)
+	}
+	if got := cfg.Warnings(); len(got) != 2 {
+		t.Errorf("warnings = %q, want the missing Gemini key and SMTP host", got)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+port: 7000
+storage: memory
+app-url: https://yaml.example.com/
+log:
+  level: debug
+  format: text
+smtp:
+  host: yaml.example.com
+  port: 2525
+cors:
+  allowed_origins:
+    - https://a.example.com
+    - https://*.b.example.com
+reminder:
+  lead_times: [48h, 2h]
+`)
+	envFile := writeFile(t, ".env", strings.Join([]string{
+		"PORT=8000",
+		"SMTP_HOST=dotenv.example.com",
+		"CONFIG_FILE=" + yamlFile,
+		"JWT_SECRET=" + testSecret,
+	}, "\n"))
+	clearEnv(t, map[string]string{
+		"ENV_FILE":        envFile,
+		"PORT":            "9000",
+		"TRUSTED_PROXIES": "10.0.0.1, 10.1.0.0/16",
+	})
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// PORT is in all three layers, SMTP_HOST in .env and YAML
+	if cfg.Port != "9000" || cfg.Mail.Host != "dotenv.example.com" || cfg.Mail.Port != 2525 {
+		t.Errorf("port %q, SMTP %s:%d", cfg.Port, cfg.Mail.Host, cfg.Mail.Port)
+	}
+	if cfg.Log.Level.String() != "DEBUG" || cfg.Log.Format != "text" || cfg.Account.AppURL != "https://yaml.example.com" {
+		t.Errorf("log %+v, app URL %q", cfg.Log, cfg.Account.AppURL)
+	}
+	if !slices.Equal(cfg.CORS.AllowedOrigins, []string{"https://a.example.com", "https://*.b.example.com"}) {
+		t.Errorf("CORS origins = %v", cfg.CORS.AllowedOrigins)
+	}
+	if !slices.Equal(cfg.Scheduler.LeadTimes, []time.Duration{48 * time.Hour, 2 * time.Hour}) {
+		t.Errorf("lead times = %v", cfg.Scheduler.LeadTimes)
+	}
+	if !slices.Equal(cfg.TrustedProxies, []string{"10.0.0.1", "10.1.0.0/16"}) {
+		t.Errorf("trusted proxies = %v", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadCollectsErrors(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+storage: mongo
+smtp:
+  hots: typo.example.com
+retries: 3
+`)
+	clearEnv(t, map[string]string{
+		"ENV_FILE":               writeFile(t, ".env", "LOG_LEVEL=loud"),
+		"CONFIG_FILE":            yamlFile,
+		"SMTP_PORT":              "abc",
+		"ACCESS_TOKEN_TTL":       "soon",
+		"RATE_LIMIT_AI_USER":     "lots",
+		"CORS_ALLOW_CREDENTIALS": "true",
+		"CORS_ALLOWED_ORIGINS":   "*",
+		"MONGO_MIN_POOL_SIZE":    "20",
+		"MONGO_MAX_POOL_SIZE":    "10",
+		"TRUSTED_PROXIES":        "10.0.0.1, proxy.internal",
+	})
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load accepted an invalid configuration")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"MONGO_URI is required",
+		"MONGO_MIN_POOL_SIZE cannot exceed MONGO_MAX_POOL_SIZE",
+		`invalid LOG_LEVEL "loud"`,
+		`invalid SMTP_PORT "abc"`,
+		`invalid ACCESS_TOKEN_TTL "soon"`,
+		"invalid RATE_LIMIT_AI_USER",
+		"JWT_SECRET or JWT_PRIVATE_KEY_FILE must be set",
+		"cannot be used with credentials",
+		`TRUSTED_PROXIES: "proxy.internal" is not an IP address or CIDR`,
+		"unknown setting RETRIES in " + yamlFile,
+		"unknown setting SMTP_HOTS in " + yamlFile,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error does not mention %q:\n%s", want, msg)
+		}
+	}
+	if strings.Index(msg, "RETRIES") > strings.Index(msg, "SMTP_HOTS") {
+		t.Errorf("unknown settings are not sorted:\n%s", msg)
+	}
+}
+
+func TestLoadRejectsShortSecret(t *testing.T) {
+	clearEnv(t, map[string]string{
+		"ENV_FILE":   writeFile(t, ".env", ""),
+		"STORAGE":    "memory",
+		"JWT_SECRET": testSecret[1:],
+	})
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET must be at least 32 bytes, got 31") {
+		t.Errorf("Load with a 31-byte secret = %v", err)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, env := range map[string]map[string]string{
+		"missing ENV_FILE":    {"ENV_FILE": filepath.Join(dir, "missing.env")},
+		"missing CONFIG_FILE": {"ENV_FILE": writeFile(t, ".env", ""), "CONFIG_FILE": filepath.Join(dir, "missing.yaml")},
+		"malformed YAML":      {"ENV_FILE": writeFile(t, ".env", ""), "CONFIG_FILE": writeFile(t, "bad.yaml", "port: [8080")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t, env)
+			if _, err := Load(); err == nil {
+				t.Error("Load succeeded")
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	got := make(map[string]string)
+	flatten("", map[string]any{
+		"port": 8080,
+		"smtp": map[string]any{
+			"host":      "mail.example.com",
+			"from-name": "TaskMorph",
+			"tls":       map[string]any{"enabled": true},
+		},
+		"cors_allowed_origins": []any{"https://a.test", "https://b.test"},
+		"jwt_secret":           nil,
+	}, got)
+
+	want := map[string]string{
+		"PORT":                 "8080",
+		"SMTP_HOST":            "mail.example.com",
+		"SMTP_FROM_NAME":       "TaskMorph",
+		"SMTP_TLS_ENABLED":     "true",
+		"CORS_ALLOWED_ORIGINS": "https://a.test,https://b.test",
+		"JWT_SECRET":           "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flatten = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Vanaraj10/taskmorph-backend/auth"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail redeems the token from a verification email
+func (ac *AuthController) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	err := ac.accounts.VerifyEmail(c.Request.Context(), req.Token)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification emails a new verification link. The response is the
+// same whether or not the address is registered.
+func (ac *AuthController) ResendVerification(c *gin.Context) {
+	var req emailRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := ac.accounts.ResendVerification(c.Request.Context(), models.NormalizeEmail(req.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If the address needs verifying, a new link is on its way"})
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not the address is registered.
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var req emailRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := ac.accounts.SendPasswordReset(c.Request.Context(), models.NormalizeEmail(req.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send reset email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If the address is registered, a reset link is on its way"})
+}
+
+// ResetPassword sets a new password using the token from a reset email. All
+// existing sessions are logged out.
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	err := ac.accounts.ResetPassword(c.Request.Context(), req.Token, req.Password)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset, please log in again"})
+}
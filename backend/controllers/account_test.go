@@ -0,0 +1,94 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// linkToken returns the token from the link in the latest email to the
+// address, checking that the link points at path on the app
+func (m *captureMailer) linkToken(t *testing.T, to, path string) string {
+	t.Helper()
+	body := m.last(t, to).body
+	start := strings.Index(body, "http://app.test"+path+"?token=")
+	if start < 0 {
+		t.Fatalf("no %s link in:\n%s", path, body)
+	}
+	link, err := url.Parse(strings.Fields(body[start:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestRegisterAndVerifyEmail(t *testing.T) {
+	api := newTestAPI(t, func(o *testOptions) { o.account.RequireVerifiedEmail = true })
+	credentials := gin.H{"email": "ada@example.com", "password": "analytical engine 1"}
+
+	expect(t, api.do(http.MethodPost, "/auth/register", "", gin.H{"name": "Ada", "email": "Ada@Example.com", "password": "analytical engine 1"}), http.StatusOK, nil)
+	token := api.mail.linkToken(t, "ada@example.com", "/verify-email")
+
+	expect(t, api.do(http.MethodPost, "/auth/login", "", credentials), http.StatusForbidden, nil)
+	expect(t, api.do(http.MethodPost, "/auth/verify", "", gin.H{"token": token}), http.StatusOK, nil)
+	expect(t, api.do(http.MethodPost, "/auth/verify", "", gin.H{"token": token}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPost, "/auth/login", "", credentials), http.StatusOK, nil)
+
+	expect(t, api.do(http.MethodPost, "/auth/register", "", gin.H{"name": "Ada", "email": "ada@example.com", "password": "analytical engine 2"}), http.StatusBadRequest, nil)
+}
+
+func TestForgotAndResetPassword(t *testing.T) {
+	api := newTestAPI(t)
+	api.addUser("ada@example.com")
+	before := api.login("ada@example.com")
+
+	// Unknown and known addresses get the same answer
+	var unknown, known struct {
+		Message string `json:"message"`
+	}
+	expect(t, api.do(http.MethodPost, "/auth/forgot-password", "", gin.H{"email": "nobody@example.com"}), http.StatusOK, &unknown)
+	expect(t, api.do(http.MethodPost, "/auth/forgot-password", "", gin.H{"email": "ada@example.com"}), http.StatusOK, &known)
+	if unknown != known {
+		t.Errorf("responses differ: %q and %q", unknown.Message, known.Message)
+	}
+	token := api.mail.linkToken(t, "ada@example.com", "/reset-password")
+
+	expect(t, api.do(http.MethodPost, "/auth/reset-password", "", gin.H{"token": token, "password": "short1"}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPost, "/auth/reset-password", "", gin.H{"token": "junk", "password": "brand new secret 9"}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPost, "/auth/reset-password", "", gin.H{"token": token, "password": "brand new secret 9"}), http.StatusOK, nil)
+	expect(t, api.do(http.MethodPost, "/auth/reset-password", "", gin.H{"token": token, "password": "brand new secret 8"}), http.StatusBadRequest, nil)
+
+	expect(t, api.do(http.MethodGet, "/auth/me", before.Token, nil), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada@example.com", "password": "brand new secret 9"}), http.StatusOK, nil)
+}
+
+func TestAccountTokensDieWithTheirEmail(t *testing.T) {
+	api := newTestAPI(t, func(o *testOptions) { o.account.RequireVerifiedEmail = true })
+	password := "analytical engine 1"
+
+	expect(t, api.do(http.MethodPost, "/auth/register", "", gin.H{"name": "Ada", "email": "ada@example.com", "password": password}), http.StatusOK, nil)
+	first := api.mail.linkToken(t, "ada@example.com", "/verify-email")
+	expect(t, api.do(http.MethodPost, "/auth/resend-verification", "", gin.H{"email": "ada@example.com"}), http.StatusOK, nil)
+	second := api.mail.linkToken(t, "ada@example.com", "/verify-email")
+	expect(t, api.do(http.MethodPost, "/auth/forgot-password", "", gin.H{"email": "ada@example.com"}), http.StatusOK, nil)
+	reset := api.mail.linkToken(t, "ada@example.com", "/reset-password")
+	expect(t, api.do(http.MethodPost, "/auth/verify", "", gin.H{"token": first}), http.StatusOK, nil)
+
+	var session loginResponse
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada@example.com", "password": password}), http.StatusOK, &session)
+	expect(t, api.do(http.MethodPatch, "/auth/me", session.Token, gin.H{"email": "someone@example.com", "current_password": password}), http.StatusOK, nil)
+
+	// Links sent to the old address can neither verify the new one nor
+	// reset the password
+	expect(t, api.do(http.MethodPost, "/auth/verify", "", gin.H{"token": second}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPost, "/auth/reset-password", "", gin.H{"token": reset, "password": "brand new secret 9"}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "someone@example.com", "password": password}), http.StatusForbidden, nil)
+
+	// The link sent to the new address still works
+	current := api.mail.linkToken(t, "someone@example.com", "/verify-email")
+	expect(t, api.do(http.MethodPost, "/auth/verify", "", gin.H{"token": current}), http.StatusOK, nil)
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "someone@example.com", "password": password}), http.StatusOK, nil)
+}
@@ -0,0 +1,218 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/auth"
+	"github.com/Vanaraj10/taskmorph-backend/controllers"
+	"github.com/Vanaraj10/taskmorph-backend/middleware"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/ratelimit"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/Vanaraj10/taskmorph-backend/routes"
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_SECRET", "test secret")
+	os.Exit(m.Run())
+}
+
+// testPassword is the password of every user created by testAPI.signUp
+const testPassword = "correct horse 1!"
+
+// testOptions are the settings newTestAPI wires the server with
+type testOptions struct {
+	account   auth.AccountConfig
+	lockout   auth.LockoutConfig
+	quota     services.QuotaConfig
+	rateLimit ratelimit.Config
+	// tasks wraps the in-memory task repository, if set
+	tasks func(repository.TaskRepository) repository.TaskRepository
+}
+
+// testAPI is the full router, wired like main.go but on the in-memory
+// repositories and the offline step generator
+type testAPI struct {
+	t        *testing.T
+	router   *gin.Engine
+	users    repository.UserRepository
+	tasks    repository.TaskRepository
+	inbox    repository.NotificationRepository
+	sessions *auth.SessionService
+	mail     *captureMailer
+}
+
+func newTestAPI(t *testing.T, configure ...func(*testOptions)) *testAPI {
+	t.Helper()
+	keys, err := auth.NewKeySet(auth.HMACKey("test secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenCfg := auth.TokenConfig{Keys: keys, AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour}
+	opts := testOptions{
+		account: auth.AccountConfig{AppURL: "http://app.test", VerifyTTL: 48 * time.Hour, ResetTTL: time.Hour},
+	}
+	for _, fn := range configure {
+		fn(&opts)
+	}
+
+	api := &testAPI{
+		t:      t,
+		router: gin.New(),
+		users:  repository.NewMemoryUserRepository(),
+		tasks:  repository.NewMemoryTaskRepository(),
+		inbox:  repository.NewMemoryNotificationRepository(),
+		mail:   &captureMailer{},
+	}
+	if opts.tasks != nil {
+		api.tasks = opts.tasks(api.tasks)
+	}
+	api.sessions = auth.NewSessionService(tokenCfg, api.users, repository.NewMemorySessionRepository(), repository.NewMemoryRevokedTokenRepository())
+	accounts := auth.NewAccountService(opts.account, tokenCfg, api.users, repository.NewMemoryAccountTokenRepository(), api.sessions, api.mail)
+	meter := services.NewUsageMeter(opts.quota, services.ProviderOffline, services.NewOfflineGenerator(), repository.NewMemoryUsageRepository())
+	limits := middleware.NewRateLimits(ratelimit.NewLimiter(repository.NewMemoryRateLimitRepository()), opts.rateLimit)
+
+	// Like main.go with TRUSTED_PROXIES unset
+	if err := api.router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	routes.SetupRoutes(api.router, middleware.AuthMiddleware(api.sessions), limits,
+		controllers.NewAuthController(api.users, api.sessions, accounts, auth.NewLockout(opts.lockout, repository.NewMemoryLoginAttemptRepository())),
+		controllers.NewTaskController(api.tasks, meter),
+		controllers.NewNotificationController(api.inbox, api.users),
+		controllers.NewUsageController(meter),
+		controllers.NewHealthController(nil, services.GeneratorConfig{Provider: services.ProviderOffline}),
+	)
+	return api
+}
+
+// do sends a request with body encoded as JSON and the token, if any, as a
+// bearer token
+func (api *testAPI) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	api.t.Helper()
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	api.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails the test unless the response has the wanted status, then
+// decodes the body into v if it is not nil
+func expect(t *testing.T, rec *httptest.ResponseRecorder, status int, v any) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d, body %s", rec.Code, status, rec.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body, err)
+		}
+	}
+}
+
+// addUser stores a verified user with testPassword. The hash uses the
+// lowest bcrypt cost to keep the tests fast.
+func (api *testAPI) addUser(email string) *models.User {
+	api.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	verified := time.Now()
+	user := &models.User{Name: "Test User", Email: email, PasswordHash: string(hash), EmailVerifiedAt: &verified}
+	if err := api.users.Create(context.Background(), user); err != nil {
+		api.t.Fatal(err)
+	}
+	return user
+}
+
+// loginResponse is the body of a successful login
+type loginResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// signUp adds a user and logs in, returning the access token
+func (api *testAPI) signUp(email string) string {
+	api.t.Helper()
+	api.addUser(email)
+	return api.login(email).Token
+}
+
+// login logs in with testPassword
+func (api *testAPI) login(email string) loginResponse {
+	api.t.Helper()
+	var resp loginResponse
+	expect(api.t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": email, "password": testPassword}), http.StatusOK, &resp)
+	return resp
+}
+
+// taskResponse is the body of the endpoints that return one task
+type taskResponse struct {
+	Task models.Task `json:"task"`
+}
+
+// createTask creates a task with the offline generator's five steps
+func (api *testAPI) createTask(token, title string) models.Task {
+	api.t.Helper()
+	var resp taskResponse
+	expect(api.t, api.do(http.MethodPost, "/tasks/create", token, gin.H{"title": title}), http.StatusOK, &resp)
+	return resp.Task
+}
+
+// captureMailer records the emails sent instead of delivering them
+type captureMailer struct {
+	mu   sync.Mutex
+	sent []sentMail
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+func (m *captureMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// last returns the latest email sent to the address
+func (m *captureMailer) last(t *testing.T, to string) sentMail {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].to == to {
+			return m.sent[i]
+		}
+	}
+	t.Fatalf("no email sent to %s", to)
+	return sentMail{}
+}
@@ -1,78 +1,233 @@
 package controllers
 
 import (
-	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/Vanaraj10/taskmorph-backend/config"
+	"github.com/Vanaraj10/taskmorph-backend/auth"
 	"github.com/Vanaraj10/taskmorph-backend/models"
-	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"golang.org/x/crypto/bcrypt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func Register(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+// AuthController serves the /auth routes
+type AuthController struct {
+	users    repository.UserRepository
+	sessions *auth.SessionService
+	accounts *auth.AccountService
+	lockout  *auth.Lockout
+}
+
+func NewAuthController(users repository.UserRepository, sessions *auth.SessionService, accounts *auth.AccountService, lockout *auth.Lockout) *AuthController {
+	return &AuthController{users: users, sessions: sessions, accounts: accounts, lockout: lockout}
+}
+
+func (ac *AuthController) Register(c *gin.Context) {
+	var req registerRequest
+	if !bindJSON(c, &req) {
 		return
 	}
-	userCollection := config.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	req.normalize()
+	ctx := c.Request.Context()
 
-	var existing models.User
-	err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&existing)
+	_, err := ac.users.FindByEmail(ctx, req.Email)
 	if err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
+		respondEmailTaken(c)
+		return
+	}
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
-	// Hash the password here
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(user.Password), 14)
-	user.Password = string(hashedPassword)
 
-	_, err = userCollection.InsertOne(ctx, user)
+	user := models.User{
+		Name:         req.Name,
+		Email:        req.Email,
+		PasswordHash: hashedPassword,
+	}
+	err = ac.users.Create(ctx, &user)
+	if errors.Is(err, repository.ErrDuplicate) {
+		respondEmailTaken(c)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	// The account exists either way; the user can ask for another email
+	if err := ac.accounts.SendVerification(ctx, &user); err != nil {
+		slog.ErrorContext(ctx, "Failed to send verification email", "user_id", user.ID.Hex(), "error", err)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "User registered successfully"})
 }
 
-func Login(c *gin.Context) {
-	var reqUser models.User
+func respondEmailTaken(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "User already exists",
+		"fields": gin.H{"email": "is already registered"},
+	})
+}
+
+// Login exchanges credentials for a token pair. Repeated failures for one
+// email from one client lock that client out of it for a while, during which
+// even the right password gets 429. Other clients can still log in.
+func (ac *AuthController) Login(c *gin.Context) {
+	var req loginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	email := models.NormalizeEmail(req.Email)
+	ctx := c.Request.Context()
+
+	locked, err := ac.lockout.Check(ctx, email, c.ClientIP(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+	if locked > 0 {
+		respondLockedOut(c, locked)
+		return
+	}
+
+	// Unknown emails and wrong passwords get the same answer in the same time
+	dbUser, err := ac.users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		auth.RejectPassword(req.Password)
+		ac.loginFailed(c, email)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+	if !auth.CheckPassword(dbUser.PasswordHash, req.Password) {
+		ac.loginFailed(c, email)
+		return
+	}
+	if errors.Is(ac.accounts.CheckLogin(dbUser), auth.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email address before logging in"})
+		return
+	}
+	if err := ac.lockout.Reset(ctx, email, c.ClientIP()); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset login failures", "user_id", dbUser.ID.Hex(), "error", err)
+	}
 
-	c.BindJSON(&reqUser)
-	userCollection := config.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	var dbUser models.User
-	err := userCollection.FindOne(ctx, bson.M{"email": reqUser.Email}).Decode(&dbUser)
+	tokens, err := ac.sessions.Start(ctx, dbUser)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
 		return
 	}
-	// Compare the password
-	err = bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(reqUser.Password))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
+		"user":          newUserResponse(dbUser),
+	})
+}
+
+// loginFailed counts a failed login towards the lockout and responds with
+// 401, or with 429 if this failure locked the account for the client
+func (ac *AuthController) loginFailed(c *gin.Context, email string) {
+	locked, err := ac.lockout.Fail(c.Request.Context(), email, c.ClientIP(), time.Now())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		slog.ErrorContext(c.Request.Context(), "Failed to record login failure", "error", err)
+	}
+	if locked > 0 {
+		slog.WarnContext(c.Request.Context(), "Login locked after repeated failures", "client_ip", c.ClientIP())
+		respondLockedOut(c, locked)
 		return
 	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+}
+
+func respondLockedOut(c *gin.Context, locked time.Duration) {
+	retryAfter := int(math.Ceil(locked.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts, try again later",
+		"retry_after": retryAfter,
+	})
+}
 
-	token, _ := services.GenerateToken(dbUser.Email)
+// Refresh trades a refresh token for a new access and refresh token. The old
+// refresh token stops working.
+func (ac *AuthController) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
 
-	// Remove password from response
-	dbUser.Password = ""
+	tokens, err := ac.sessions.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user": gin.H{
-			"id":    dbUser.ID,
-			"name":  dbUser.Name,
-			"email": dbUser.Email,
-		},
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
 	})
 }
+
+// Logout revokes the session identified by the refresh token in the body
+// and/or the bearer access token. It succeeds even if they already expired.
+func (ac *AuthController) Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	accessToken := auth.BearerToken(c)
+	if req.RefreshToken == "" && accessToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide a refresh token or access token"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	var claims *auth.Claims
+	if accessToken != "" {
+		var err error
+		claims, err = ac.sessions.Authenticate(ctx, accessToken)
+		if err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+
+	if err := ac.sessions.Logout(ctx, req.RefreshToken, claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// currentUserID returns the user ID that AuthMiddleware took from the access
+// token. It writes the error response itself and returns ok=false on failure.
+func currentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, ok := auth.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	}
+	return userID, ok
+}
@@ -0,0 +1,200 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/auth"
+	"github.com/Vanaraj10/taskmorph-backend/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	api := newTestAPI(t)
+	register := gin.H{"name": "Ada", "email": "ada@example.com", "password": testPassword}
+	expect(t, api.do(http.MethodPost, "/auth/register", "", register), http.StatusOK, nil)
+	expect(t, api.do(http.MethodPost, "/auth/register", "", register), http.StatusBadRequest, nil)
+
+	var resp struct {
+		Token string `json:"token"`
+		User  struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		} `json:"user"`
+	}
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada@example.com", "password": testPassword}), http.StatusOK, &resp)
+	if resp.Token == "" || resp.User.Email != "ada@example.com" || resp.User.Password != "" {
+		t.Errorf("login = %+v, want a token and the user without the password", resp)
+	}
+	expect(t, api.do(http.MethodGet, "/tasks/", resp.Token, nil), http.StatusOK, nil)
+}
+
+func TestLoginFailuresLookAlike(t *testing.T) {
+	api := newTestAPI(t)
+	api.addUser("ada@example.com")
+
+	unknown := api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "bob@example.com", "password": testPassword})
+	wrong := api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada@example.com", "password": "wrong password"})
+	if unknown.Code != http.StatusUnauthorized || wrong.Code != http.StatusUnauthorized || unknown.Body.String() != wrong.Body.String() {
+		t.Errorf("unknown email gives %d %s, wrong password %d %s, want the same 401", unknown.Code, unknown.Body, wrong.Code, wrong.Body)
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	api := newTestAPI(t)
+	api.addUser("ada@example.com")
+	first := api.login("ada@example.com")
+
+	var second loginResponse
+	expect(t, api.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": first.RefreshToken}), http.StatusOK, &second)
+	if second.Token == "" || second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh = %+v, want a new pair", second)
+	}
+	expect(t, api.do(http.MethodGet, "/tasks/", second.Token, nil), http.StatusOK, nil)
+
+	expect(t, api.do(http.MethodPost, "/auth/refresh", "", gin.H{}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPost, "/auth/logout", "", nil), http.StatusBadRequest, nil)
+
+	// Replaying the first refresh token ends the session
+	expect(t, api.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": first.RefreshToken}), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodGet, "/tasks/", second.Token, nil), http.StatusUnauthorized, nil)
+
+	third := api.login("ada@example.com")
+	expect(t, api.do(http.MethodPost, "/auth/logout", third.Token, gin.H{"refresh_token": third.RefreshToken}), http.StatusOK, nil)
+	expect(t, api.do(http.MethodGet, "/tasks/", third.Token, nil), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": third.RefreshToken}), http.StatusUnauthorized, nil)
+	// Logging out again still succeeds
+	expect(t, api.do(http.MethodPost, "/auth/logout", third.Token, nil), http.StatusOK, nil)
+}
+
+func TestLoginLockout(t *testing.T) {
+	api := newTestAPI(t, func(opts *testOptions) {
+		opts.lockout = auth.LockoutConfig{MaxFailures: 3, Window: 15 * time.Minute, Duration: time.Hour}
+	})
+	api.addUser("ada@example.com")
+	api.addUser("bob@example.com")
+	wrong := gin.H{"email": "ada@example.com", "password": "wrong password"}
+
+	for i := 0; i < 2; i++ {
+		expect(t, api.do(http.MethodPost, "/auth/login", "", wrong), http.StatusUnauthorized, nil)
+	}
+	rec := api.do(http.MethodPost, "/auth/login", "", wrong)
+	expect(t, rec, http.StatusTooManyRequests, nil)
+	if got := rec.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After = %q, want 3600", got)
+	}
+
+	// The right password does not get past the lock, in any letter case
+	rec = api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ADA@example.com", "password": testPassword})
+	expect(t, rec, http.StatusTooManyRequests, nil)
+	if got, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || got <= 0 || got > 3600 {
+		t.Errorf("Retry-After = %q while locked", rec.Header().Get("Retry-After"))
+	}
+	api.login("bob@example.com")
+
+	// The lock is on the failing client only, so failing on purpose cannot
+	// lock the real user out
+	body, err := json.Marshal(gin.H{"email": "ada@example.com", "password": testPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "198.51.100.7:4000"
+	rec = httptest.NewRecorder()
+	api.router.ServeHTTP(rec, req)
+	expect(t, rec, http.StatusOK, nil)
+}
+
+func TestLoginResetsLockout(t *testing.T) {
+	api := newTestAPI(t, func(opts *testOptions) {
+		opts.lockout = auth.LockoutConfig{MaxFailures: 2, Window: 15 * time.Minute, Duration: time.Hour}
+	})
+	api.addUser("ada@example.com")
+	wrong := gin.H{"email": "ada@example.com", "password": "wrong password"}
+
+	expect(t, api.do(http.MethodPost, "/auth/login", "", wrong), http.StatusUnauthorized, nil)
+	api.login("ada@example.com")
+	expect(t, api.do(http.MethodPost, "/auth/login", "", wrong), http.StatusUnauthorized, nil)
+	api.login("ada@example.com")
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	api := newTestAPI(t, func(opts *testOptions) {
+		opts.rateLimit.Auth.PerIP = ratelimit.Rule{Limit: 2, Period: time.Minute}
+	})
+
+	// The empty body fails validation, which is enough to reach the limiter
+	login := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		api.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := login("198.51.100.7:4000", "")
+		expect(t, rec, http.StatusBadRequest, nil)
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(1-i) {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %d", i+1, got, 1-i)
+		}
+	}
+	rec := login("198.51.100.7:4000", "")
+	expect(t, rec, http.StatusTooManyRequests, nil)
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+
+	// Without trusted proxies a forged X-Forwarded-For does not buy a new
+	// bucket, while another client address does
+	expect(t, login("198.51.100.7:4000", "203.0.113.9"), http.StatusTooManyRequests, nil)
+	expect(t, login("198.51.100.8:4000", ""), http.StatusBadRequest, nil)
+}
+
+func TestAPIRateLimitPerUser(t *testing.T) {
+	api := newTestAPI(t, func(opts *testOptions) {
+		opts.rateLimit.API.PerUser = ratelimit.Rule{Limit: 2, Period: time.Minute}
+	})
+	ada := api.signUp("ada@example.com")
+	bob := api.signUp("bob@example.com")
+
+	expect(t, api.do(http.MethodGet, "/tasks/", ada, nil), http.StatusOK, nil)
+	expect(t, api.do(http.MethodGet, "/tasks/", ada, nil), http.StatusOK, nil)
+	expect(t, api.do(http.MethodGet, "/tasks/", ada, nil), http.StatusTooManyRequests, nil)
+	// Both users share the test client address, but only the user rule is set
+	expect(t, api.do(http.MethodGet, "/tasks/", bob, nil), http.StatusOK, nil)
+}
+
+func TestRateLimitRefusalTakesNothing(t *testing.T) {
+	api := newTestAPI(t, func(opts *testOptions) {
+		opts.rateLimit.API.PerIP = ratelimit.Rule{Limit: 3, Period: time.Minute}
+		opts.rateLimit.API.PerUser = ratelimit.Rule{Limit: 2, Period: time.Minute}
+	})
+	ada := api.signUp("ada@example.com")
+	bob := api.signUp("bob@example.com")
+
+	expect(t, api.do(http.MethodGet, "/tasks/", ada, nil), http.StatusOK, nil)
+	expect(t, api.do(http.MethodGet, "/tasks/", ada, nil), http.StatusOK, nil)
+	// Refused by ada's bucket, so the shared address keeps its last token
+	for i := 0; i < 3; i++ {
+		expect(t, api.do(http.MethodGet, "/tasks/", ada, nil), http.StatusTooManyRequests, nil)
+	}
+	rec := api.do(http.MethodGet, "/tasks/", bob, nil)
+	expect(t, rec, http.StatusOK, nil)
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 0 left for the address", got)
+	}
+	expect(t, api.do(http.MethodGet, "/tasks/", bob, nil), http.StatusTooManyRequests, nil)
+}
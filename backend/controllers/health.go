@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthController serves the liveness and readiness probes
+type HealthController struct {
+	// pingDB is nil when the server runs without a database
+	pingDB    func(ctx context.Context) error
+	generator services.GeneratorConfig
+}
+
+func NewHealthController(pingDB func(ctx context.Context) error, generator services.GeneratorConfig) *HealthController {
+	return &HealthController{pingDB: pingDB, generator: generator}
+}
+
+// Livez reports that the process is up and serving. It checks nothing else,
+// so a database outage does not get the server restarted.
+func (hc *HealthController) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the server can take traffic, which is when the
+// database answers a ping. The AI provider is reported too, but a missing API
+// key only breaks step generation, so it degrades the status rather than
+// failing the check.
+func (hc *HealthController) Readyz(c *gin.Context) {
+	configured := hc.generator.Provider == services.ProviderOffline || hc.generator.APIKey != ""
+	code, status := http.StatusOK, "ok"
+	if !configured {
+		status = "degraded"
+	}
+
+	database := "disabled"
+	if hc.pingDB != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		if err := hc.pingDB(ctx); err != nil {
+			slog.WarnContext(ctx, "Database ping failed", "error", err)
+			database = "unreachable"
+			code, status = http.StatusServiceUnavailable, "unavailable"
+		} else {
+			database = "ok"
+		}
+	}
+
+	c.JSON(code, gin.H{
+		"status":   status,
+		"database": database,
+		"ai": gin.H{
+			"provider":   hc.generator.Provider,
+			"configured": configured,
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vanaraj10/taskmorph-backend/controllers"
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// healthBody is the body of the readiness probe
+type healthBody struct {
+	Status   string `json:"status"`
+	Database string `json:"database"`
+	AI       struct {
+		Provider   string `json:"provider"`
+		Configured bool   `json:"configured"`
+	} `json:"ai"`
+}
+
+func TestProbes(t *testing.T) {
+	api := newTestAPI(t)
+	expect(t, api.do(http.MethodGet, "/livez", "", nil), http.StatusOK, nil)
+
+	for _, path := range []string{"/readyz", "/health"} {
+		var body healthBody
+		expect(t, api.do(http.MethodGet, path, "", nil), http.StatusOK, &body)
+		if body.Status != "ok" || body.Database != "disabled" || body.AI.Provider != services.ProviderOffline || !body.AI.Configured {
+			t.Errorf("%s = %+v", path, body)
+		}
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	down := func(ctx context.Context) error { return errors.New("no primary") }
+	up := func(ctx context.Context) error { return nil }
+	gemini := services.GeneratorConfig{Provider: services.ProviderGemini}
+
+	for name, tc := range map[string]struct {
+		ping      func(ctx context.Context) error
+		generator services.GeneratorConfig
+		code      int
+		want      healthBody
+	}{
+		"ready": {up, services.GeneratorConfig{Provider: services.ProviderGemini, APIKey: "key"}, http.StatusOK, healthBody{Status: "ok", Database: "ok"}},
+		// A missing API key only breaks step generation
+		"no AI key":     {up, gemini, http.StatusOK, healthBody{Status: "degraded", Database: "ok"}},
+		"database down": {down, gemini, http.StatusServiceUnavailable, healthBody{Status: "unavailable", Database: "unreachable"}},
+	} {
+		router := gin.New()
+		health := controllers.NewHealthController(tc.ping, tc.generator)
+		router.GET("/livez", health.Livez)
+		router.GET("/readyz", health.Readyz)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body healthBody
+		expect(t, rec, tc.code, &body)
+		if body.Status != tc.want.Status || body.Database != tc.want.Database || body.AI.Configured != (tc.generator.APIKey != "") {
+			t.Errorf("%s: readyz = %+v", name, body)
+		}
+
+		// Liveness ignores the database
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+		expect(t, rec, http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/notifications"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+// NotificationController serves the /notifications routes
+type NotificationController struct {
+	notifications repository.NotificationRepository
+	users         repository.UserRepository
+}
+
+func NewNotificationController(notifications repository.NotificationRepository, users repository.UserRepository) *NotificationController {
+	return &NotificationController{notifications: notifications, users: users}
+}
+
+// GetNotifications lists the inbox, newest first. ?unread=true hides read
+// entries and ?limit caps the number returned.
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	limit := defaultNotificationLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(n, maxNotificationLimit)
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	notifications, err := nc.notifications.ListByUser(ctx, userID.Hex(), unreadOnly, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+	unread, err := nc.notifications.CountUnread(ctx, userID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications, "unread": unread})
+}
+
+// MarkRead marks a single notification as read
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	err = nc.notifications.MarkRead(c.Request.Context(), userID.Hex(), id, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllRead clears the unread badge
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := nc.notifications.MarkAllRead(c.Request.Context(), userID.Hex(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read", "updated": count})
+}
+
+// GetPreferences returns the user's channel settings, defaults included
+func (nc *NotificationController) GetPreferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := nc.users.FindByID(c.Request.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, user.Preferences())
+}
+
+// UpdatePreferences replaces the user's channel settings
+func (nc *NotificationController) UpdatePreferences(c *gin.Context) {
+	var prefs models.NotificationPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if prefs.WebhookURL != "" {
+		if err := notifications.ValidateWebhookURL(c.Request.Context(), prefs.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if prefs.Webhook && prefs.WebhookURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "webhook_url is required to enable webhooks"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	err := nc.users.UpdatePreferences(c.Request.Context(), userID, prefs)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Preferences updated", "preferences": prefs})
+}
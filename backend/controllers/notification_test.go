@@ -0,0 +1,96 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type inboxResponse struct {
+	Notifications []models.Notification `json:"notifications"`
+	Unread        int64                 `json:"unread"`
+}
+
+func TestNotificationInbox(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.addUser("ada@example.com")
+	token := api.login("ada@example.com").Token
+	other := api.signUp("bob@example.com")
+
+	var ids []primitive.ObjectID
+	for i, title := range []string{"First", "Second", "Third"} {
+		n := &models.Notification{UserID: user.ID.Hex(), Kind: "reminder", Title: title, CreatedAt: time.Now().Add(time.Duration(i) * time.Minute)}
+		if err := api.inbox.Create(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	var inbox inboxResponse
+	expect(t, api.do(http.MethodGet, "/notifications/?limit=2", token, nil), http.StatusOK, &inbox)
+	if len(inbox.Notifications) != 2 || inbox.Notifications[0].Title != "Third" || inbox.Unread != 3 {
+		t.Fatalf("inbox = %+v, want the newest two of three unread", inbox)
+	}
+
+	expect(t, api.do(http.MethodPatch, "/notifications/"+ids[2].Hex()+"/read", token, nil), http.StatusOK, nil)
+	expect(t, api.do(http.MethodPatch, "/notifications/"+ids[1].Hex()+"/read", other, nil), http.StatusNotFound, nil)
+	expect(t, api.do(http.MethodPatch, "/notifications/not-an-id/read", token, nil), http.StatusBadRequest, nil)
+
+	inbox = inboxResponse{}
+	expect(t, api.do(http.MethodGet, "/notifications/?unread=true", token, nil), http.StatusOK, &inbox)
+	if len(inbox.Notifications) != 2 || inbox.Unread != 2 {
+		t.Errorf("unread inbox = %+v, want two", inbox)
+	}
+
+	var marked struct {
+		Updated int64 `json:"updated"`
+	}
+	expect(t, api.do(http.MethodPost, "/notifications/read-all", token, nil), http.StatusOK, &marked)
+	if marked.Updated != 2 {
+		t.Errorf("read-all updated %d, want 2", marked.Updated)
+	}
+
+	inbox = inboxResponse{}
+	expect(t, api.do(http.MethodGet, "/notifications/", other, nil), http.StatusOK, &inbox)
+	if inbox.Notifications == nil || len(inbox.Notifications) != 0 || inbox.Unread != 0 {
+		t.Errorf("another user's inbox = %+v, want empty", inbox)
+	}
+	expect(t, api.do(http.MethodGet, "/notifications/?limit=0", token, nil), http.StatusBadRequest, nil)
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+
+	var prefs models.NotificationPreferences
+	expect(t, api.do(http.MethodGet, "/notifications/preferences", token, nil), http.StatusOK, &prefs)
+	if prefs != models.DefaultNotificationPreferences() {
+		t.Errorf("preferences = %+v, want the defaults", prefs)
+	}
+
+	for _, body := range []gin.H{
+		{"webhook": true},
+		{"webhook": true, "webhook_url": "ftp://93.184.216.34/hook"},
+		{"webhook": true, "webhook_url": "https:///hook"},
+		{"webhook": true, "webhook_url": "http://127.0.0.1:8080/hook"},
+		{"webhook": true, "webhook_url": "http://169.254.169.254/latest/meta-data"},
+		{"webhook": true, "webhook_url": "https://10.0.0.5/hook"},
+	} {
+		if rec := api.do(http.MethodPut, "/notifications/preferences", token, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT preferences %v = %d, want 400", body, rec.Code)
+		}
+	}
+
+	want := models.NotificationPreferences{InApp: true, Webhook: true, WebhookURL: "https://93.184.216.34/hook"}
+	expect(t, api.do(http.MethodPut, "/notifications/preferences", token, want), http.StatusOK, nil)
+	prefs = models.NotificationPreferences{}
+	expect(t, api.do(http.MethodGet, "/notifications/preferences", token, nil), http.StatusOK, &prefs)
+	if prefs != want {
+		t.Errorf("saved preferences = %+v, want %+v", prefs, want)
+	}
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Vanaraj10/taskmorph-backend/auth"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Me returns the authenticated user's profile
+func (ac *AuthController) Me(c *gin.Context) {
+	user := ac.loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// UpdateMe changes the name and/or email. Changing the email takes the
+// current password, since the address is what password resets go to. A new
+// email has to be verified again, so a verification email is sent to it, and
+// like a password change it logs out every session and the caller gets a
+// fresh token pair.
+func (ac *AuthController) UpdateMe(c *gin.Context) {
+	var req updateProfileRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user := ac.loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var update repository.UserUpdate
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		update.Name = &name
+	}
+	if req.Email != nil {
+		if email := models.NormalizeEmail(*req.Email); email != user.Email {
+			update.Email = &email
+		}
+	}
+	if update.Email != nil && !checkCurrentPassword(c, user, req.CurrentPassword) {
+		return
+	}
+
+	updated, err := ac.users.UpdateProfile(ctx, user.ID, update)
+	if errors.Is(err, repository.ErrDuplicate) {
+		respondEmailTaken(c)
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	if update.Email == nil {
+		c.JSON(http.StatusOK, newUserResponse(updated))
+		return
+	}
+
+	if err := ac.accounts.SendVerification(ctx, updated); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to send verification email", "user_id", updated.ID.Hex(), "error", err)
+	}
+	if err := ac.sessions.RevokeAll(ctx, updated.ID.Hex()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end other sessions"})
+		return
+	}
+	tokens, err := ac.sessions.Start(ctx, updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":          newUserResponse(updated),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
+	})
+}
+
+// ChangePassword replaces the password after checking the current one. Every
+// session is logged out and the caller gets a fresh token pair.
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	var req changePasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user := ac.loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if !checkCurrentPassword(c, user, req.CurrentPassword) {
+		return
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if err := ac.users.SetPassword(ctx, user.ID, hash); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	if err := ac.sessions.RevokeAll(ctx, user.ID.Hex()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end other sessions"})
+		return
+	}
+	tokens, err := ac.sessions.Start(ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
+	})
+}
+
+// checkCurrentPassword confirms a sensitive change with the user's password.
+// It writes the 400 itself and returns false if the password is missing or
+// wrong.
+func checkCurrentPassword(c *gin.Context, user *models.User, password string) bool {
+	msg := "is incorrect"
+	if password == "" {
+		msg = "is required"
+	} else if auth.CheckPassword(user.PasswordHash, password) {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "Validation failed",
+		"fields": gin.H{"current_password": msg},
+	})
+	return false
+}
+
+// loadCurrentUser fetches the authenticated user's record. It writes the
+// error response itself and returns nil on failure.
+func (ac *AuthController) loadCurrentUser(c *gin.Context) *models.User {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil
+	}
+
+	user, err := ac.users.FindByID(c.Request.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return nil
+	}
+	return user
+}
@@ -0,0 +1,124 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userBody is the public view of a user returned by /auth/me
+type userBody struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func TestMe(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.addUser("ada@example.com")
+	token := api.login("ada@example.com").Token
+
+	rec := api.do(http.MethodGet, "/auth/me", token, nil)
+	var me userBody
+	expect(t, rec, http.StatusOK, &me)
+	if me.ID != user.ID.Hex() || me.Email != "ada@example.com" || !me.EmailVerified {
+		t.Errorf("me = %+v", me)
+	}
+	var raw map[string]any
+	expect(t, rec, http.StatusOK, &raw)
+	for _, field := range []string{"password", "password_hash", "PasswordHash"} {
+		if _, ok := raw[field]; ok {
+			t.Errorf("/auth/me exposes %s: %s", field, rec.Body)
+		}
+	}
+
+	expect(t, api.do(http.MethodGet, "/auth/me", "", nil), http.StatusUnauthorized, nil)
+}
+
+func TestUpdateMe(t *testing.T) {
+	api := newTestAPI(t)
+	api.addUser("taken@example.com")
+	api.addUser("ada@example.com")
+	other := api.login("ada@example.com")
+	token := api.login("ada@example.com").Token
+
+	var me userBody
+	expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"name": " Ada L. "}), http.StatusOK, &me)
+	if me.Name != "Ada L." || me.Email != "ada@example.com" || !me.EmailVerified {
+		t.Errorf("after renaming: %+v", me)
+	}
+
+	// The same address in another case is not a change
+	expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"email": "ADA@example.com"}), http.StatusOK, &me)
+	if !me.EmailVerified {
+		t.Errorf("re-submitting the same email cleared verification")
+	}
+
+	// Changing the email takes the current password
+	for password, msg := range map[string]string{"": "is required", "not it": "is incorrect"} {
+		var resp validationError
+		expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"email": "lovelace@example.com", "current_password": password}), http.StatusBadRequest, &resp)
+		if resp.Fields["current_password"] != msg {
+			t.Errorf("current_password %q = %+v, want %q", password, resp, msg)
+		}
+	}
+
+	var changed struct {
+		User userBody `json:"user"`
+		loginResponse
+	}
+	expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"email": "Lovelace@Example.com", "current_password": testPassword}), http.StatusOK, &changed)
+	if changed.User.Email != "lovelace@example.com" || changed.User.EmailVerified {
+		t.Errorf("after changing the email: %+v, want it lowercase and unverified", changed.User)
+	}
+	if changed.Token == "" || changed.RefreshToken == "" {
+		t.Fatalf("email change = %+v, want a new token pair", changed)
+	}
+	api.mail.last(t, "lovelace@example.com")
+
+	// Like a password change, every earlier session is logged out
+	expect(t, api.do(http.MethodGet, "/auth/me", token, nil), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodGet, "/auth/me", other.Token, nil), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": other.RefreshToken}), http.StatusUnauthorized, nil)
+	token = changed.Token
+	expect(t, api.do(http.MethodGet, "/auth/me", token, nil), http.StatusOK, nil)
+
+	var taken validationError
+	expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"email": "taken@example.com", "current_password": testPassword}), http.StatusBadRequest, &taken)
+	if taken.Fields["email"] != "is already registered" {
+		t.Errorf("taken email = %+v", taken)
+	}
+	expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"name": " "}), http.StatusBadRequest, nil)
+	expect(t, api.do(http.MethodPatch, "/auth/me", token, gin.H{"email": "nope"}), http.StatusBadRequest, nil)
+}
+
+func TestChangePassword(t *testing.T) {
+	api := newTestAPI(t)
+	api.addUser("ada@example.com")
+	other := api.login("ada@example.com")
+	current := api.login("ada@example.com")
+
+	var wrong validationError
+	expect(t, api.do(http.MethodPost, "/auth/change-password", current.Token, gin.H{"current_password": "not it", "new_password": "brand new secret 9"}), http.StatusBadRequest, &wrong)
+	if wrong.Fields["current_password"] != "is incorrect" {
+		t.Errorf("wrong current password = %+v", wrong)
+	}
+	expect(t, api.do(http.MethodPost, "/auth/change-password", current.Token, gin.H{"current_password": testPassword, "new_password": "short"}), http.StatusBadRequest, nil)
+
+	var changed loginResponse
+	expect(t, api.do(http.MethodPost, "/auth/change-password", current.Token, gin.H{"current_password": testPassword, "new_password": "brand new secret 9"}), http.StatusOK, &changed)
+	if changed.Token == "" || changed.RefreshToken == "" {
+		t.Fatalf("change-password = %+v, want a new token pair", changed)
+	}
+
+	// Every earlier session is logged out; the new pair works
+	expect(t, api.do(http.MethodGet, "/auth/me", other.Token, nil), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodGet, "/auth/me", current.Token, nil), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": other.RefreshToken}), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodGet, "/auth/me", changed.Token, nil), http.StatusOK, nil)
+
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada@example.com", "password": testPassword}), http.StatusUnauthorized, nil)
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada@example.com", "password": "brand new secret 9"}), http.StatusOK, nil)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// registerRequest is the body of POST /auth/register. Passwords are capped
+// at 72 bytes, not characters, because bcrypt refuses anything longer.
+type registerRequest struct {
+	Name     string `json:"name" binding:"required,notblank,max=100"`
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,min=8,maxbytes=72,password"`
+}
+
+// normalize trims the name and canonicalizes the email
+func (r *registerRequest) normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Email = models.NormalizeEmail(r.Email)
+}
+
+// loginRequest is the body of POST /auth/login. The password policy is not
+// applied, so accounts created before it can still log in.
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,maxbytes=72"`
+}
+
+// emailRequest is the body of endpoints that only take an address
+type emailRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+}
+
+// resetPasswordRequest is the body of POST /auth/reset-password
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,maxbytes=72,password"`
+}
+
+// updateProfileRequest is the body of PATCH /auth/me; omitted fields are
+// left unchanged. CurrentPassword is only needed to change the email.
+type updateProfileRequest struct {
+	Name            *string `json:"name" binding:"omitempty,notblank,max=100"`
+	Email           *string `json:"email" binding:"omitempty,email,max=254"`
+	CurrentPassword string  `json:"current_password" binding:"omitempty,maxbytes=72"`
+}
+
+// changePasswordRequest is the body of POST /auth/change-password
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,maxbytes=72"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,maxbytes=72,password"`
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// userResponse is the public view of a user. It is the only shape users are
+// serialized in, so storage-only fields such as the password hash never
+// reach clients.
+type userResponse struct {
+	ID            primitive.ObjectID `json:"id"`
+	Name          string             `json:"name"`
+	Email         string             `json:"email"`
+	EmailVerified bool               `json:"email_verified"`
+}
+
+func newUserResponse(user *models.User) userResponse {
+	return userResponse{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified(),
+	}
+}
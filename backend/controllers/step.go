@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stepParams parses the :id and :stepID route parameters. It writes the
+// error response itself and returns ok=false on failure.
+func stepParams(c *gin.Context) (taskID, stepID primitive.ObjectID, ok bool) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return taskID, stepID, false
+	}
+
+	stepID, err = primitive.ObjectIDFromHex(c.Param("stepID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step ID"})
+		return taskID, stepID, false
+	}
+	return taskID, stepID, true
+}
+
+// respondStepResult writes the outcome of a step mutation
+func respondStepResult(c *gin.Context, task *models.Task, err error, message string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task or step not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update step"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "task": task})
+}
+
+// AddStep inserts a manual step, at the end unless a position is given
+func (tc *TaskController) AddStep(c *gin.Context) {
+	var req struct {
+		Title       string `json:"title" binding:"required"`
+		Description string `json:"description"`
+		Position    *int   `json:"position"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Step title is required"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	position := repository.AppendPosition
+	if req.Position != nil {
+		if *req.Position < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Position cannot be negative"})
+			return
+		}
+		position = *req.Position
+	}
+
+	step := models.Step{
+		ID:          primitive.NewObjectID(),
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+	}
+
+	task, err := tc.tasks.AddStep(c.Request.Context(), userID.Hex(), taskID, step, position)
+	respondStepResult(c, task, err, "Step added successfully")
+}
+
+// UpdateStep edits a step's title and description
+func (tc *TaskController) UpdateStep(c *gin.Context) {
+	var req struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Title == nil && req.Description == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide a title or description"})
+		return
+	}
+
+	var update repository.StepUpdate
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title cannot be empty"})
+			return
+		}
+		update.Title = &title
+	}
+	update.Description = req.Description
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	taskID, stepID, ok := stepParams(c)
+	if !ok {
+		return
+	}
+
+	task, err := tc.tasks.UpdateStep(c.Request.Context(), userID.Hex(), taskID, stepID, update)
+	respondStepResult(c, task, err, "Step updated successfully")
+}
+
+// MoveStep moves a step to a new zero-based position
+func (tc *TaskController) MoveStep(c *gin.Context) {
+	var req struct {
+		Position *int `json:"position" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || *req.Position < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A non-negative position is required"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	taskID, stepID, ok := stepParams(c)
+	if !ok {
+		return
+	}
+
+	task, err := tc.tasks.MoveStep(c.Request.Context(), userID.Hex(), taskID, stepID, *req.Position)
+	respondStepResult(c, task, err, "Step moved successfully")
+}
+
+// DeleteStep removes a single step from a task
+func (tc *TaskController) DeleteStep(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	taskID, stepID, ok := stepParams(c)
+	if !ok {
+		return
+	}
+
+	task, err := tc.tasks.DeleteStep(c.Request.Context(), userID.Hex(), taskID, stepID)
+	respondStepResult(c, task, err, "Step deleted successfully")
+}
@@ -0,0 +1,153 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stepTitles lists the step titles in order
+func stepTitles(task models.Task) []string {
+	titles := make([]string, len(task.Steps))
+	for i, step := range task.Steps {
+		titles[i] = step.Title
+	}
+	return titles
+}
+
+func TestStepEndpoints(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	task := api.createTask(token, "Launch")
+	path := "/tasks/" + task.ID.Hex()
+	first, last := task.Steps[0], task.Steps[4]
+
+	var resp taskResponse
+	expect(t, api.do(http.MethodPost, path+"/step", token, gin.H{"title": " Tell the team ", "position": 1}), http.StatusOK, &resp)
+	added := resp.Task.Steps[1]
+	if len(resp.Task.Steps) != 6 || added.Title != "Tell the team" || added.ID.IsZero() {
+		t.Fatalf("steps after adding at 1 = %v", stepTitles(resp.Task))
+	}
+
+	expect(t, api.do(http.MethodPost, path+"/step", token, gin.H{"title": "Celebrate", "position": 99}), http.StatusOK, &resp)
+	if got := resp.Task.Steps[6].Title; got != "Celebrate" {
+		t.Errorf("a position past the end appended %q last, want Celebrate", got)
+	}
+	expect(t, api.do(http.MethodPost, path+"/step", token, gin.H{"title": "Tidy up"}), http.StatusOK, &resp)
+	if got := resp.Task.Steps[7].Title; got != "Tidy up" {
+		t.Errorf("no position appended %q last, want Tidy up", got)
+	}
+
+	expect(t, api.do(http.MethodPatch, path+"/step/"+added.ID.Hex(), token, gin.H{"title": "Tell everyone", "description": "Post in the channel"}), http.StatusOK, &resp)
+	if step := resp.Task.Steps[1]; step.Title != "Tell everyone" || step.Description != "Post in the channel" {
+		t.Errorf("edited step = %+v", step)
+	}
+	expect(t, api.do(http.MethodPatch, path+"/step/"+added.ID.Hex(), token, gin.H{"description": ""}), http.StatusOK, &resp)
+	if step := resp.Task.Steps[1]; step.Title != "Tell everyone" || step.Description != "" {
+		t.Errorf("step after clearing the description = %+v", step)
+	}
+
+	expect(t, api.do(http.MethodPatch, path+"/step/"+last.ID.Hex()+"/move", token, gin.H{"position": 0}), http.StatusOK, &resp)
+	if resp.Task.Steps[0].ID != last.ID || resp.Task.Steps[1].ID != first.ID || len(resp.Task.Steps) != 8 {
+		t.Errorf("steps after moving the last one first = %v", stepTitles(resp.Task))
+	}
+	expect(t, api.do(http.MethodPatch, path+"/step/"+last.ID.Hex()+"/move", token, gin.H{"position": 50}), http.StatusOK, &resp)
+	if resp.Task.Steps[7].ID != last.ID {
+		t.Errorf("steps after moving past the end = %v", stepTitles(resp.Task))
+	}
+
+	expect(t, api.do(http.MethodPatch, path+"/step/"+first.ID.Hex()+"/complete", token, nil), http.StatusOK, &resp)
+	expect(t, api.do(http.MethodDelete, path+"/step/"+added.ID.Hex(), token, nil), http.StatusOK, &resp)
+	if len(resp.Task.Steps) != 7 || resp.Task.Progress != 14 {
+		t.Errorf("after deleting a step: %d steps, progress %d, want 7 and 14", len(resp.Task.Steps), resp.Task.Progress)
+	}
+	for _, step := range resp.Task.Steps {
+		if step.ID == added.ID {
+			t.Errorf("deleted step %s is still listed", added.ID.Hex())
+		}
+	}
+
+	// Deleting every open step finishes the task
+	remaining := append([]models.Step(nil), resp.Task.Steps...)
+	for _, step := range remaining {
+		if step.ID != first.ID {
+			expect(t, api.do(http.MethodDelete, path+"/step/"+step.ID.Hex(), token, nil), http.StatusOK, &resp)
+		}
+	}
+	if resp.Task.Status != models.StatusDone || resp.Task.Progress != 100 {
+		t.Errorf("task with only completed steps: status %s, progress %d", resp.Task.Status, resp.Task.Progress)
+	}
+}
+
+func TestStepEndpointErrors(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	task := api.createTask(token, "Launch")
+	path := "/tasks/" + task.ID.Hex()
+	step := path + "/step/" + task.Steps[0].ID.Hex()
+	unknown := path + "/step/" + primitive.NewObjectID().Hex()
+
+	for _, req := range []struct {
+		method, path string
+		body         any
+		want         int
+	}{
+		{http.MethodPost, path + "/step", gin.H{}, http.StatusBadRequest},
+		{http.MethodPost, path + "/step", gin.H{"title": "  "}, http.StatusBadRequest},
+		{http.MethodPost, path + "/step", gin.H{"title": "Late", "position": -1}, http.StatusBadRequest},
+		{http.MethodPatch, step, gin.H{}, http.StatusBadRequest},
+		{http.MethodPatch, step, gin.H{"title": " "}, http.StatusBadRequest},
+		{http.MethodPatch, step + "/move", gin.H{}, http.StatusBadRequest},
+		{http.MethodPatch, step + "/move", gin.H{"position": -2}, http.StatusBadRequest},
+		{http.MethodPatch, path + "/step/not-an-id", gin.H{"title": "x"}, http.StatusBadRequest},
+		{http.MethodPost, "/tasks/" + primitive.NewObjectID().Hex() + "/step", gin.H{"title": "x"}, http.StatusNotFound},
+		{http.MethodPatch, unknown, gin.H{"title": "x"}, http.StatusNotFound},
+		{http.MethodPatch, unknown + "/move", gin.H{"position": 0}, http.StatusNotFound},
+		{http.MethodPatch, unknown + "/complete", nil, http.StatusNotFound},
+		{http.MethodPatch, unknown + "/uncomplete", nil, http.StatusNotFound},
+		{http.MethodDelete, unknown, nil, http.StatusNotFound},
+	} {
+		if rec := api.do(req.method, req.path, token, req.body); rec.Code != req.want {
+			t.Errorf("%s %s %v = %d, want %d", req.method, req.path, req.body, rec.Code, req.want)
+		}
+	}
+
+	var got models.Task
+	expect(t, api.do(http.MethodGet, path, token, nil), http.StatusOK, &got)
+	if len(got.Steps) != 5 || got.Steps[0].Title != task.Steps[0].Title {
+		t.Errorf("rejected requests changed the task: %v", stepTitles(got))
+	}
+}
+
+func TestCompleteStep(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.addUser("ada@example.com")
+	token := api.login("ada@example.com").Token
+	task := api.createTask(token, "Launch")
+	path := "/tasks/" + task.ID.Hex() + "/step/" + task.Steps[0].ID.Hex()
+
+	var resp taskResponse
+	expect(t, api.do(http.MethodPatch, path+"/complete", token, nil), http.StatusOK, &resp)
+	if step := resp.Task.Steps[0]; !step.IsCompleted || step.CompletedAt == nil || step.CompletedBy != user.ID.Hex() {
+		t.Errorf("completed step = %+v, want it completed by the user", step)
+	}
+
+	// A fresh response each time, since decoding leaves absent fields as is
+	var undone taskResponse
+	expect(t, api.do(http.MethodPatch, path+"/complete", token, gin.H{"completed": false}), http.StatusOK, &undone)
+	if step := undone.Task.Steps[0]; step.IsCompleted || step.CompletedAt != nil || step.CompletedBy != "" {
+		t.Errorf("step after completed=false = %+v", step)
+	}
+
+	expect(t, api.do(http.MethodPatch, path+"/complete", token, gin.H{"completed": true}), http.StatusOK, nil)
+	var reopened taskResponse
+	expect(t, api.do(http.MethodPatch, path+"/uncomplete", token, nil), http.StatusOK, &reopened)
+	if step := reopened.Task.Steps[0]; step.IsCompleted || step.CompletedAt != nil {
+		t.Errorf("step after uncomplete = %+v", step)
+	}
+
+	expect(t, api.do(http.MethodPatch, path+"/complete", token, gin.H{"completed": "yes"}), http.StatusBadRequest, nil)
+}
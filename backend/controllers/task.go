@@ -1,20 +1,38 @@
 package controllers
 
 import (
-	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/Vanaraj10/taskmorph-backend/config"
 	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
 	"github.com/Vanaraj10/taskmorph-backend/services"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// TaskController serves the /ai and /tasks routes. Steps are generated
+// through the usage meter, so every AI call counts against the user's quota.
+type TaskController struct {
+	tasks repository.TaskRepository
+	ai    *services.UsageMeter
+}
+
+func NewTaskController(tasks repository.TaskRepository, ai *services.UsageMeter) *TaskController {
+	return &TaskController{
+		tasks: tasks,
+		ai:    ai,
+	}
+}
+
 // BreakdownTask handles AI task breakdown requests
-func BreakdownTask(c *gin.Context) {
+func (tc *TaskController) BreakdownTask(c *gin.Context) {
 	var req struct {
 		Task string `json:"task" binding:"required"`
 	}
@@ -24,9 +42,14 @@ func BreakdownTask(c *gin.Context) {
 		return
 	}
 
-	steps, err := services.AskGemini(req.Task)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	steps, err := tc.ai.GenerateSteps(c.Request.Context(), userID.Hex(), models.OperationBreakdown, req.Task)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate task breakdown"})
+		respondGenerateError(c, err, "Failed to generate task breakdown")
 		return
 	}
 
@@ -34,7 +57,7 @@ func BreakdownTask(c *gin.Context) {
 }
 
 // CreateTask creates a new task with AI-generated steps
-func CreateTask(c *gin.Context) {
+func (tc *TaskController) CreateTask(c *gin.Context) {
 	var req struct {
 		Title    string `json:"title" binding:"required"`
 		Deadline string `json:"deadline"`
@@ -45,9 +68,8 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
-	email, exists := c.Get("email")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -65,9 +87,9 @@ func CreateTask(c *gin.Context) {
 	}
 
 	// Generate steps using AI
-	steps, err := services.AskGemini(req.Title)
+	steps, err := tc.ai.GenerateSteps(c.Request.Context(), userID.Hex(), models.OperationCreateTask, req.Title)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate steps"})
+		respondGenerateError(c, err, "Failed to generate steps")
 		return
 	}
 
@@ -76,26 +98,15 @@ func CreateTask(c *gin.Context) {
 		steps[i].ID = primitive.NewObjectID()
 	}
 
-	// Get user ID from email
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
-		return
-	}
-
 	task := models.Task{
 		ID:       primitive.NewObjectID(),
 		Title:    req.Title,
 		Deadline: deadline,
 		Steps:    steps,
-		UserID:   user.ID.Hex(),
+		UserID:   userID.Hex(),
 	}
 
-	collection := config.GetCollection("tasks")
-	_, err = collection.InsertOne(context.TODO(), task)
-	if err != nil {
+	if err := tc.tasks.Create(c.Request.Context(), &task); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
 		return
 	}
@@ -103,198 +114,407 @@ func CreateTask(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Task created successfully", "task": task})
 }
 
-// GetTasks retrieves all tasks for the authenticated user
-func GetTasks(c *gin.Context) {
-	email, exists := c.Get("email")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// respondGenerateError answers a failed step generation: 429 with the quota
+// and its reset time if the user ran out, 500 with message otherwise
+func respondGenerateError(c *gin.Context, err error, message string) {
+	var quotaErr *services.QuotaError
+	if errors.As(err, &quotaErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(quotaErr.ResetsAt).Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":     fmt.Sprintf("You have used your %s AI %s quota", quotaErr.Period, quotaErr.Resource),
+			"period":    quotaErr.Period,
+			"resource":  quotaErr.Resource,
+			"limit":     quotaErr.Limit,
+			"resets_at": quotaErr.ResetsAt,
+		})
 		return
 	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
 
-	// Get user ID from email
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err := userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
+// stepInput is one entry of the steps list in UpdateTask. Entries with an ID
+// refer to an existing step, entries without one become new steps.
+type stepInput struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+}
+
+// UpdateTask partially updates a task's title, deadline and steps
+func (tc *TaskController) UpdateTask(c *gin.Context) {
+	var req struct {
+		Title    *string      `json:"title"`
+		Deadline *string      `json:"deadline"`
+		Steps    *[]stepInput `json:"steps"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
 
-	collection := config.GetCollection("tasks")
-	cursor, err := collection.Find(context.TODO(), bson.M{"user_id": user.ID.Hex()})
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
 		return
 	}
-	defer cursor.Close(context.TODO())
 
-	var tasks []models.Task
-	if err = cursor.All(context.TODO(), &tasks); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode tasks"})
+	var update repository.TaskUpdate
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title cannot be empty"})
+			return
+		}
+		update.Title = &title
+	}
+	if req.Deadline != nil {
+		deadline, err := time.Parse("2006-01-02", *req.Deadline)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deadline format. Use YYYY-MM-DD"})
+			return
+		}
+		update.Deadline = &deadline
+	}
+	if req.Steps != nil {
+		existing, err := tc.tasks.FindByID(c.Request.Context(), userID.Hex(), objectID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		update.Steps, err = mergeSteps(existing.Steps, *req.Steps, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update.StepsReadAt = existing.UpdatedAt
+	}
+
+	task, err := tc.tasks.Update(c.Request.Context(), userID.Hex(), objectID, update)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task was changed by another request, reload it and try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
 
-	// Calculate progress for each task
-	tasksWithProgress := make([]gin.H, len(tasks))
-	for i, task := range tasks {
-		completedSteps := 0
-		for _, step := range task.Steps {
-			if step.IsCompleted {
-				completedSteps++
+	c.JSON(http.StatusOK, gin.H{"message": "Task updated successfully", "task": task})
+}
+
+// mergeSteps builds the new step list in the requested order. Existing steps
+// keep their ID and completion state; an empty title or an absent description
+// keeps the stored value, and an edited step is marked updated at now. New steps are
+// timestamped by the repository. Steps left out of the request are removed.
+func mergeSteps(existing []models.Step, inputs []stepInput, now time.Time) ([]models.Step, error) {
+	byID := make(map[primitive.ObjectID]models.Step, len(existing))
+	for _, step := range existing {
+		byID[step.ID] = step
+	}
+
+	steps := make([]models.Step, 0, len(inputs))
+	seen := make(map[primitive.ObjectID]bool, len(inputs))
+	for _, input := range inputs {
+		if input.ID == "" {
+			if strings.TrimSpace(input.Title) == "" {
+				return nil, fmt.Errorf("New steps need a title")
 			}
+			step := models.Step{ID: primitive.NewObjectID(), Title: strings.TrimSpace(input.Title)}
+			if input.Description != nil {
+				step.Description = *input.Description
+			}
+			steps = append(steps, step)
+			continue
 		}
 
-		progress := 0
-		if len(task.Steps) > 0 {
-			progress = (completedSteps * 100) / len(task.Steps)
+		stepID, err := primitive.ObjectIDFromHex(input.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid step ID %q", input.ID)
+		}
+		step, ok := byID[stepID]
+		if !ok {
+			return nil, fmt.Errorf("Step %s does not belong to this task", input.ID)
+		}
+		if seen[stepID] {
+			return nil, fmt.Errorf("Step %s is listed more than once", input.ID)
 		}
+		seen[stepID] = true
 
-		tasksWithProgress[i] = gin.H{
-			"id":       task.ID,
-			"title":    task.Title,
-			"deadline": task.Deadline,
-			"progress": progress,
-			"steps":    task.Steps,
+		changed := false
+		if title := strings.TrimSpace(input.Title); title != "" && title != step.Title {
+			step.Title = title
+			changed = true
 		}
+		if input.Description != nil && *input.Description != step.Description {
+			step.Description = *input.Description
+			changed = true
+		}
+		if changed {
+			step.UpdatedAt = now
+		}
+		steps = append(steps, step)
 	}
-
-	c.JSON(http.StatusOK, tasksWithProgress)
+	return steps, nil
 }
 
-// GetTask retrieves a specific task by ID
-func GetTask(c *gin.Context) {
-	taskID := c.Param("id")
-	email, exists := c.Get("email")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// GetTasks retrieves a page of the authenticated user's tasks. See
+// parseTaskQuery for the supported query parameters.
+func (tc *TaskController) GetTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get user ID from email
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err := userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	objectID, err := primitive.ObjectIDFromHex(taskID)
+	page, err := tc.tasks.ListByUser(c.Request.Context(), userID.Hex(), query)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
 		return
 	}
 
-	collection := config.GetCollection("tasks")
-	var task models.Task
-	err = collection.FindOne(context.TODO(), bson.M{
-		"_id":     objectID,
-		"user_id": user.ID.Hex(),
-	}).Decode(&task)
+	now := time.Now()
+	for i := range page.Tasks {
+		ensureStatus(&page.Tasks[i], now)
+	}
+	if page.Tasks == nil {
+		page.Tasks = []models.Task{}
+	}
 
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":       page.Tasks,
+		"next_cursor": page.NextCursor,
+		"total":       page.Total,
+	})
+}
+
+// parseTaskQuery reads the GetTasks query parameters:
+//
+//	status         comma-separated todo, in_progress, done, overdue
+//	deadline_from  YYYY-MM-DD, inclusive
+//	deadline_to    YYYY-MM-DD, inclusive
+//	progress_min   0-100, inclusive
+//	progress_max   0-100, inclusive
+//	q              case-insensitive title match
+//	sort           deadline, created (default), updated or progress
+//	order          asc (default) or desc
+//	limit          page size, up to 100
+//	cursor         next_cursor from the previous page
+func parseTaskQuery(c *gin.Context) (repository.TaskQuery, error) {
+	var query repository.TaskQuery
+
+	if raw := c.Query("status"); raw != "" {
+		for _, value := range strings.Split(raw, ",") {
+			status := models.TaskStatus(strings.TrimSpace(value))
+			if !status.Valid() {
+				return query, fmt.Errorf("Invalid status. Use todo, in_progress, done or overdue")
+			}
+			query.Filter.Statuses = append(query.Filter.Statuses, status)
+		}
+	}
+
+	if raw := c.Query("deadline_from"); raw != "" {
+		from, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return query, fmt.Errorf("Invalid deadline_from format. Use YYYY-MM-DD")
+		}
+		query.Filter.DeadlineFrom = &from
+	}
+	if raw := c.Query("deadline_to"); raw != "" {
+		to, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return query, fmt.Errorf("Invalid deadline_to format. Use YYYY-MM-DD")
+		}
+		// The filter bound is exclusive, so include the whole final day
+		to = to.AddDate(0, 0, 1)
+		query.Filter.DeadlineTo = &to
+	}
+
+	for param, target := range map[string]**int{
+		"progress_min": &query.Filter.ProgressMin,
+		"progress_max": &query.Filter.ProgressMax,
+	} {
+		if raw := c.Query(param); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 0 || value > 100 {
+				return query, fmt.Errorf("Invalid %s. Use a number from 0 to 100", param)
+			}
+			*target = &value
+		}
 	}
 
-	// Calculate progress
-	completedSteps := 0
-	for _, step := range task.Steps {
-		if step.IsCompleted {
-			completedSteps++
+	query.Filter.Title = strings.TrimSpace(c.Query("q"))
+
+	if raw := c.Query("sort"); raw != "" {
+		query.Sort = repository.TaskSort(raw)
+		if !query.Sort.Valid() {
+			return query, fmt.Errorf("Invalid sort. Use deadline, created, updated or progress")
 		}
 	}
 
-	progress := 0
-	if len(task.Steps) > 0 {
-		progress = (completedSteps * 100) / len(task.Steps)
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("Invalid order. Use asc or desc")
 	}
 
-	response := gin.H{
-		"id":       task.ID,
-		"title":    task.Title,
-		"deadline": task.Deadline,
-		"progress": progress,
-		"steps":    task.Steps,
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > repository.MaxPageSize {
+			return query, fmt.Errorf("Invalid limit. Use a number from 1 to %d", repository.MaxPageSize)
+		}
+		query.Limit = limit
 	}
 
-	c.JSON(http.StatusOK, response)
+	query.Cursor = c.Query("cursor")
+	return query, nil
 }
 
-// CompleteStep marks a step as completed or uncompleted
-func CompleteStep(c *gin.Context) {
-	taskID := c.Param("taskID")
-	stepID := c.Param("stepID")
-	email, exists := c.Get("email")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// SearchTasks runs a full-text search over task titles and step titles and
+// descriptions, returning the best matches first
+func (tc *TaskController) SearchTasks(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query q is required"})
 		return
 	}
 
-	// Get user ID from email
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err := userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	limit := repository.DefaultPageSize
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > repository.MaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit. Use a number from 1 to %d", repository.MaxPageSize)})
+			return
+		}
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	hits, err := tc.tasks.Search(c.Request.Context(), userID.Hex(), text, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search tasks"})
 		return
 	}
 
-	taskObjectID, err := primitive.ObjectIDFromHex(taskID)
+	now := time.Now()
+	results := make([]gin.H, len(hits))
+	for i, hit := range hits {
+		ensureStatus(&hit.Task, now)
+		matched := hit.MatchedStepIDs
+		if matched == nil {
+			matched = []primitive.ObjectID{}
+		}
+		results[i] = gin.H{
+			"task":             hit.Task,
+			"score":            hit.Score,
+			"matched_step_ids": matched,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// GetTask retrieves a specific task by ID
+func (tc *TaskController) GetTask(c *gin.Context) {
+	taskID := c.Param("id")
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
 		return
 	}
 
-	stepObjectID, err := primitive.ObjectIDFromHex(stepID)
+	task, err := tc.tasks.FindByID(c.Request.Context(), userID.Hex(), objectID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step ID"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
+	ensureStatus(task, time.Now())
 
-	collection := config.GetCollection("tasks")
+	c.JSON(http.StatusOK, task)
+}
 
-	// Update the specific step's completion status
-	filter := bson.M{
-		"_id":       taskObjectID,
-		"user_id":   user.ID.Hex(),
-		"steps._id": stepObjectID,
+// ensureStatus fills in progress and status for tasks stored before those
+// fields were persisted
+func ensureStatus(task *models.Task, now time.Time) {
+	if task.Status == "" {
+		task.Refresh(now)
 	}
-	update := bson.M{
-		"$set": bson.M{
-			"steps.$.is_completed": true,
-		},
+}
+
+// CompleteStep marks a step as completed or uncompleted. The optional body
+// {"completed": false} undoes a completion; without a body the step is completed.
+func (tc *TaskController) CompleteStep(c *gin.Context) {
+	req := struct {
+		Completed *bool `json:"completed"`
+	}{}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
 	}
 
-	result, err := collection.UpdateOne(context.TODO(), filter, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update step"})
+	completed := req.Completed == nil || *req.Completed
+	tc.setStepCompleted(c, completed)
+}
+
+// UncompleteStep marks a step as not completed
+func (tc *TaskController) UncompleteStep(c *gin.Context) {
+	tc.setStepCompleted(c, false)
+}
+
+func (tc *TaskController) setStepCompleted(c *gin.Context, completed bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task or step not found"})
+	taskID, stepID, ok := stepParams(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Step completed successfully"})
+	// Update the specific step's completion status
+	task, err := tc.tasks.SetStepCompleted(c.Request.Context(), userID.Hex(), taskID, stepID, completed, time.Now())
+	message := "Step completed successfully"
+	if !completed {
+		message = "Step marked as not completed"
+	}
+	respondStepResult(c, task, err, message)
 }
 
 // DeleteTask deletes a task
-func DeleteTask(c *gin.Context) {
+func (tc *TaskController) DeleteTask(c *gin.Context) {
 	taskID := c.Param("id")
-	email, exists := c.Get("email")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	// Get user ID from email
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err := userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -304,19 +524,13 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	collection := config.GetCollection("tasks")
-	result, err := collection.DeleteOne(context.TODO(), bson.M{
-		"_id":     objectID,
-		"user_id": user.ID.Hex(),
-	})
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+	err = tc.tasks.Delete(c.Request.Context(), userID.Hex(), objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
 		return
 	}
 
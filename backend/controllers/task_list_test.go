@@ -0,0 +1,172 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// taskPage is the body of GET /tasks/
+type taskPage struct {
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor"`
+	Total      int64         `json:"total"`
+}
+
+func taskTitles(tasks []models.Task) []string {
+	titles := make([]string, len(tasks))
+	for i, task := range tasks {
+		titles[i] = task.Title
+	}
+	return titles
+}
+
+func TestGetTasksFilters(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+
+	create := func(title, deadline string, completed int) {
+		t.Helper()
+		var resp taskResponse
+		expect(t, api.do(http.MethodPost, "/tasks/create", token, gin.H{"title": title, "deadline": deadline}), http.StatusOK, &resp)
+		for _, step := range resp.Task.Steps[:completed] {
+			expect(t, api.do(http.MethodPatch, "/tasks/"+resp.Task.ID.Hex()+"/step/"+step.ID.Hex()+"/complete", token, nil), http.StatusOK, nil)
+		}
+	}
+	create("Pay rent", "2099-03-01", 0)
+	create("Pay taxes", "2099-04-15", 2)
+	create("Renew passport", "2099-06-30", 5)
+	create("Book dentist", "2000-01-01", 1)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "[Pay rent Pay taxes Renew passport Book dentist]"},
+		{"status=todo", "[Pay rent]"},
+		{"status=in_progress,done", "[Pay taxes Renew passport]"},
+		{"status=overdue", "[Book dentist]"},
+		{"q=PAY", "[Pay rent Pay taxes]"},
+		{"deadline_from=2099-03-02&deadline_to=2099-06-30", "[Pay taxes Renew passport]"},
+		{"deadline_to=2099-03-01", "[Pay rent Book dentist]"},
+		{"progress_min=30&progress_max=40", "[Pay taxes]"},
+		{"progress_min=100", "[Renew passport]"},
+		{"sort=deadline", "[Book dentist Pay rent Pay taxes Renew passport]"},
+		{"sort=deadline&order=desc", "[Renew passport Pay taxes Pay rent Book dentist]"},
+		{"sort=progress&order=desc", "[Renew passport Pay taxes Book dentist Pay rent]"},
+		{"status=done&q=rent", "[]"},
+	}
+	for _, tt := range tests {
+		var page taskPage
+		expect(t, api.do(http.MethodGet, "/tasks/?"+tt.query, token, nil), http.StatusOK, &page)
+		if got := fmt.Sprint(taskTitles(page.Tasks)); got != tt.want {
+			t.Errorf("GET /tasks/?%s = %s, want %s", tt.query, got, tt.want)
+		}
+		if page.Total != int64(len(page.Tasks)) || page.NextCursor != "" {
+			t.Errorf("GET /tasks/?%s: total %d, cursor %q for a single page of %d", tt.query, page.Total, page.NextCursor, len(page.Tasks))
+		}
+	}
+}
+
+func TestGetTasksPages(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	var want []string
+	for i := 0; i < 7; i++ {
+		title := fmt.Sprintf("Task %d", i)
+		api.createTask(token, title)
+		want = append([]string{title}, want...)
+	}
+
+	var got []string
+	query := url.Values{"limit": {"3"}, "order": {"desc"}}
+	for pages := 1; ; pages++ {
+		var page taskPage
+		expect(t, api.do(http.MethodGet, "/tasks/?"+query.Encode(), token, nil), http.StatusOK, &page)
+		if page.Total != 7 {
+			t.Errorf("page %d total = %d, want 7", pages, page.Total)
+		}
+		got = append(got, taskTitles(page.Tasks)...)
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		if pages == 3 {
+			t.Fatal("the last page has a next cursor")
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+
+	// A cursor only continues the order it was issued for
+	query.Set("order", "asc")
+	expect(t, api.do(http.MethodGet, "/tasks/?"+query.Encode(), token, nil), http.StatusBadRequest, nil)
+}
+
+func TestGetTasksRejectsInvalidQueries(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	for _, query := range []string{
+		"status=finished",
+		"status=todo,,done",
+		"deadline_from=tomorrow",
+		"deadline_to=2099-13-01",
+		"progress_min=-1",
+		"progress_max=101",
+		"progress_min=half",
+		"sort=title",
+		"order=up",
+		"limit=0",
+		"limit=101",
+		"cursor=not-a-cursor",
+		"cursor=e30",
+	} {
+		if rec := api.do(http.MethodGet, "/tasks/?"+query, token, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /tasks/?%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	other := api.signUp("bob@example.com")
+	api.createTask(token, "Renovate the kitchen")
+	api.createTask(token, "File taxes")
+	api.createTask(other, "Kitchen party")
+
+	var resp struct {
+		Results []struct {
+			Task           models.Task `json:"task"`
+			Score          float64     `json:"score"`
+			MatchedStepIDs []string    `json:"matched_step_ids"`
+		} `json:"results"`
+	}
+	expect(t, api.do(http.MethodGet, "/tasks/search?q=kitchen", token, nil), http.StatusOK, &resp)
+	if len(resp.Results) != 1 || resp.Results[0].Task.Title != "Renovate the kitchen" {
+		t.Fatalf("results = %+v, want only the user's kitchen task", resp.Results)
+	}
+	// The offline generator mentions the title in every step description
+	if got := len(resp.Results[0].MatchedStepIDs); got != 5 || resp.Results[0].Score <= 0 {
+		t.Errorf("score %v with %d matched steps, want a positive score and 5", resp.Results[0].Score, got)
+	}
+
+	expect(t, api.do(http.MethodGet, "/tasks/search?q=holiday", token, nil), http.StatusOK, &resp)
+	if resp.Results == nil || len(resp.Results) != 0 {
+		t.Errorf("no match gives %v, want an empty list", resp.Results)
+	}
+
+	for _, query := range []string{"", "q=+", "q=kitchen&limit=0", "q=kitchen&limit=many"} {
+		if rec := api.do(http.MethodGet, "/tasks/search?"+query, token, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /tasks/search?%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
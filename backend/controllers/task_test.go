@@ -0,0 +1,282 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTaskRoutesRequireAuth(t *testing.T) {
+	api := newTestAPI(t)
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/tasks/"},
+		{http.MethodPost, "/tasks/create"},
+		{http.MethodPost, "/ai/breakdown"},
+	} {
+		if rec := api.do(route.method, route.path, "", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a token = %d, want 401", route.method, route.path, rec.Code)
+		}
+		if rec := api.do(route.method, route.path, "not-a-token", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s with a bad token = %d, want 401", route.method, route.path, rec.Code)
+		}
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+
+	task := api.createTask(token, "Write the report")
+	if task.Title != "Write the report" || len(task.Steps) != 5 {
+		t.Fatalf("created task = %+v, want the title and five steps", task)
+	}
+	if task.Status != models.StatusTodo || task.Progress != 0 {
+		t.Errorf("new task status, progress = %s, %d", task.Status, task.Progress)
+	}
+	path := "/tasks/" + task.ID.Hex()
+
+	var got models.Task
+	expect(t, api.do(http.MethodGet, path, token, nil), http.StatusOK, &got)
+	if got.ID != task.ID || len(got.Steps) != 5 || got.Progress != 0 {
+		t.Fatalf("GET %s = %+v", path, got)
+	}
+
+	var completed taskResponse
+	expect(t, api.do(http.MethodPatch, path+"/step/"+task.Steps[0].ID.Hex()+"/complete", token, nil), http.StatusOK, &completed)
+	if completed.Task.Progress != 20 || completed.Task.Status != models.StatusInProgress {
+		t.Errorf("after completing a step: progress %d, status %s", completed.Task.Progress, completed.Task.Status)
+	}
+	expect(t, api.do(http.MethodGet, path, token, nil), http.StatusOK, &got)
+	if got.Progress != 20 || !got.Steps[0].IsCompleted {
+		t.Errorf("stored after completing a step: progress %d, step %+v", got.Progress, got.Steps[0])
+	}
+
+	var list taskPage
+	expect(t, api.do(http.MethodGet, "/tasks/", token, nil), http.StatusOK, &list)
+	if len(list.Tasks) != 1 || list.Tasks[0].ID != task.ID || list.Tasks[0].Progress != 20 || list.Total != 1 {
+		t.Errorf("task list = %+v", list)
+	}
+
+	expect(t, api.do(http.MethodDelete, path, token, nil), http.StatusOK, nil)
+	expect(t, api.do(http.MethodGet, path, token, nil), http.StatusNotFound, nil)
+}
+
+func TestBreakdownTask(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	var steps []models.Step
+	expect(t, api.do(http.MethodPost, "/ai/breakdown", token, gin.H{"task": "Plan the trip"}), http.StatusOK, &steps)
+	if len(steps) != 5 {
+		t.Errorf("got %d steps, want 5", len(steps))
+	}
+	expect(t, api.do(http.MethodPost, "/ai/breakdown", token, gin.H{}), http.StatusBadRequest, nil)
+}
+
+func TestTasksAreIsolatedPerUser(t *testing.T) {
+	api := newTestAPI(t)
+	owner := api.signUp("owner@example.com")
+	other := api.signUp("other@example.com")
+	task := api.createTask(owner, "Private plans")
+	path := "/tasks/" + task.ID.Hex()
+	step := path + "/step/" + task.Steps[0].ID.Hex()
+
+	for _, req := range []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodGet, path, nil},
+		{http.MethodPatch, path, gin.H{"title": "Mine now"}},
+		{http.MethodPatch, path, gin.H{"steps": []gin.H{}}},
+		{http.MethodPatch, step + "/complete", nil},
+		{http.MethodPost, path + "/step", gin.H{"title": "Extra"}},
+		{http.MethodPatch, step, gin.H{"title": "Renamed"}},
+		{http.MethodDelete, step, nil},
+		{http.MethodDelete, path, nil},
+	} {
+		if rec := api.do(req.method, req.path, other, req.body); rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s by another user = %d, want 404", req.method, req.path, rec.Code)
+		}
+	}
+
+	var list taskPage
+	expect(t, api.do(http.MethodGet, "/tasks/", other, nil), http.StatusOK, &list)
+	if len(list.Tasks) != 0 || list.Total != 0 {
+		t.Errorf("another user lists %d tasks, want 0", len(list.Tasks))
+	}
+
+	var got models.Task
+	expect(t, api.do(http.MethodGet, path, owner, nil), http.StatusOK, &got)
+	if got.Title != "Private plans" || len(got.Steps) != 5 || got.Progress != 0 {
+		t.Errorf("owner's task was changed: %+v", got)
+	}
+}
+
+func TestTaskRequestValidation(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	task := api.createTask(token, "Plan")
+	path := "/tasks/" + task.ID.Hex()
+
+	for _, req := range []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodPost, "/tasks/create", gin.H{}},
+		{http.MethodPost, "/tasks/create", gin.H{"title": "Plan", "deadline": "next week"}},
+		{http.MethodGet, "/tasks/not-an-id", nil},
+		{http.MethodPatch, path, gin.H{"title": "  "}},
+		{http.MethodPatch, path, gin.H{"deadline": "31/01/2099"}},
+		{http.MethodPatch, path + "/step/not-an-id/complete", nil},
+	} {
+		if rec := api.do(req.method, req.path, token, req.body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %v = %d, want 400", req.method, req.path, req.body, rec.Code)
+		}
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	task := api.createTask(token, "Move house")
+	path := "/tasks/" + task.ID.Hex()
+	expect(t, api.do(http.MethodPatch, path+"/step/"+task.Steps[1].ID.Hex()+"/complete", token, nil), http.StatusOK, nil)
+
+	var resp taskResponse
+	expect(t, api.do(http.MethodPatch, path, token, gin.H{"title": " Move flat ", "deadline": "2099-01-31"}), http.StatusOK, &resp)
+	if resp.Task.Title != "Move flat" || resp.Task.Deadline.Format("2006-01-02") != "2099-01-31" || len(resp.Task.Steps) != 5 {
+		t.Errorf("updated task = %q due %v with %d steps", resp.Task.Title, resp.Task.Deadline, len(resp.Task.Steps))
+	}
+
+	// Reverse two steps, rename one, add one and drop the rest
+	expect(t, api.do(http.MethodPatch, path, token, gin.H{"steps": []gin.H{
+		{"id": task.Steps[1].ID.Hex()},
+		{"id": task.Steps[0].ID.Hex(), "title": "Book the van"},
+		{"title": "Return the keys", "description": "To the old landlord"},
+	}}), http.StatusOK, &resp)
+
+	steps := resp.Task.Steps
+	if len(steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(steps))
+	}
+	if steps[0].ID != task.Steps[1].ID || !steps[0].IsCompleted || steps[0].Title != task.Steps[1].Title {
+		t.Errorf("first step = %+v, want step 2 kept completed", steps[0])
+	}
+	if steps[1].ID != task.Steps[0].ID || steps[1].Title != "Book the van" || steps[1].Description != task.Steps[0].Description {
+		t.Errorf("second step = %+v, want step 1 renamed with its description kept", steps[1])
+	}
+	if steps[2].ID.IsZero() || steps[2].Title != "Return the keys" || steps[2].Description != "To the old landlord" {
+		t.Errorf("third step = %+v, want a new step", steps[2])
+	}
+	if resp.Task.Title != "Move flat" {
+		t.Errorf("a steps update changed the title to %q", resp.Task.Title)
+	}
+
+	for _, steps := range [][]gin.H{
+		{{"id": "not-an-id"}},
+		{{"id": primitive.NewObjectID().Hex()}},
+		{{"id": task.Steps[0].ID.Hex()}, {"id": task.Steps[0].ID.Hex()}},
+		{{"title": " "}},
+	} {
+		if rec := api.do(http.MethodPatch, path, token, gin.H{"steps": steps}); rec.Code != http.StatusBadRequest {
+			t.Errorf("steps %v = %d, want 400", steps, rec.Code)
+		}
+	}
+
+	var got models.Task
+	expect(t, api.do(http.MethodGet, path, token, nil), http.StatusOK, &got)
+	if len(got.Steps) != 3 || got.Progress != 33 {
+		t.Errorf("stored task has %d steps and progress %d, want 3 and 33", len(got.Steps), got.Progress)
+	}
+}
+
+func TestTaskStatusFollowsSteps(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+
+	var created taskResponse
+	expect(t, api.do(http.MethodPost, "/tasks/create", token, gin.H{"title": "Late", "deadline": "2000-01-01"}), http.StatusOK, &created)
+	task := created.Task
+	if task.Status != models.StatusOverdue {
+		t.Fatalf("task past its deadline has status %s, want overdue", task.Status)
+	}
+	path := "/tasks/" + task.ID.Hex() + "/step/"
+
+	var resp taskResponse
+	for _, step := range task.Steps {
+		expect(t, api.do(http.MethodPatch, path+step.ID.Hex()+"/complete", token, nil), http.StatusOK, &resp)
+	}
+	if resp.Task.Status != models.StatusDone || resp.Task.Progress != 100 {
+		t.Fatalf("all steps completed: status %s, progress %d", resp.Task.Status, resp.Task.Progress)
+	}
+
+	expect(t, api.do(http.MethodPatch, path+task.Steps[2].ID.Hex()+"/uncomplete", token, nil), http.StatusOK, &resp)
+	if resp.Task.Status != models.StatusOverdue || resp.Task.Progress != 80 {
+		t.Errorf("one step reopened: status %s, progress %d", resp.Task.Status, resp.Task.Progress)
+	}
+
+	expect(t, api.do(http.MethodPatch, "/tasks/"+task.ID.Hex(), token, gin.H{"deadline": "2099-01-01"}), http.StatusOK, &resp)
+	if resp.Task.Status != models.StatusInProgress {
+		t.Errorf("after moving the deadline: status %s, want in_progress", resp.Task.Status)
+	}
+}
+
+func TestUpdateTaskClearsDescription(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.signUp("ada@example.com")
+	task := api.createTask(token, "Move house")
+	path := "/tasks/" + task.ID.Hex()
+
+	var resp taskResponse
+	expect(t, api.do(http.MethodPatch, path, token, gin.H{"steps": []gin.H{
+		{"id": task.Steps[0].ID.Hex(), "description": ""},
+		{"id": task.Steps[1].ID.Hex()},
+	}}), http.StatusOK, &resp)
+	if steps := resp.Task.Steps; len(steps) != 2 || steps[0].Description != "" || steps[1].Description != task.Steps[1].Description {
+		t.Errorf("steps = %+v, want the first description cleared and the second kept", steps)
+	}
+}
+
+// racingTasks completes the first step of a task behind the controller's back
+// whenever it is read, like a request that lands between read and write
+type racingTasks struct {
+	repository.TaskRepository
+}
+
+func (r racingTasks) FindByID(ctx context.Context, userID string, taskID primitive.ObjectID) (*models.Task, error) {
+	task, err := r.TaskRepository.FindByID(ctx, userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.TaskRepository.SetStepCompleted(ctx, userID, taskID, task.Steps[0].ID, true, time.Now()); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func TestUpdateTaskConflict(t *testing.T) {
+	api := newTestAPI(t, func(o *testOptions) {
+		o.tasks = func(tasks repository.TaskRepository) repository.TaskRepository { return racingTasks{tasks} }
+	})
+	token := api.signUp("ada@example.com")
+	task := api.createTask(token, "Move house")
+	path := "/tasks/" + task.ID.Hex()
+
+	expect(t, api.do(http.MethodPatch, path, token, gin.H{"steps": []gin.H{{"id": task.Steps[1].ID.Hex()}}}), http.StatusConflict, nil)
+
+	stored, err := api.tasks.FindByID(context.Background(), task.UserID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Steps) != 5 || !stored.Steps[0].IsCompleted {
+		t.Errorf("stored task has %d steps, first completed %v; want the racing write kept", len(stored.Steps), stored.Steps[0].IsCompleted)
+	}
+
+	// Title and deadline changes do not depend on the step list
+	expect(t, api.do(http.MethodPatch, path, token, gin.H{"title": "Move flat"}), http.StatusOK, nil)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageController serves the /usage route
+type UsageController struct {
+	ai *services.UsageMeter
+}
+
+func NewUsageController(ai *services.UsageMeter) *UsageController {
+	return &UsageController{ai: ai}
+}
+
+// GetUsage reports the user's AI usage and what is left of their quotas for
+// the current UTC day and month
+func (uc *UsageController) GetUsage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	report, err := uc.ai.Report(c.Request.Context(), userID.Hex(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usage"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
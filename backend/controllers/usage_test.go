@@ -0,0 +1,51 @@
+package controllers_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAIQuota(t *testing.T) {
+	api := newTestAPI(t, func(opts *testOptions) {
+		opts.quota = services.QuotaConfig{DailyRequests: 2}
+	})
+	token := api.signUp("ada@example.com")
+	api.createTask(token, "Launch")
+	api.createTask(token, "Plan the trip")
+
+	var refused struct {
+		Period   string    `json:"period"`
+		Resource string    `json:"resource"`
+		Limit    int64     `json:"limit"`
+		ResetsAt time.Time `json:"resets_at"`
+	}
+	rec := api.do(http.MethodPost, "/tasks/create", token, gin.H{"title": "One too many"})
+	expect(t, rec, http.StatusTooManyRequests, &refused)
+	if refused.Period != "daily" || refused.Resource != "requests" || refused.Limit != 2 {
+		t.Errorf("429 body = %+v", refused)
+	}
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil || retryAfter <= 0 || retryAfter > 24*60*60 {
+		t.Errorf("Retry-After = %q, want seconds until the next UTC day", rec.Header().Get("Retry-After"))
+	}
+
+	var tasks taskPage
+	expect(t, api.do(http.MethodGet, "/tasks/", token, nil), http.StatusOK, &tasks)
+	if tasks.Total != 2 {
+		t.Errorf("%d tasks after a refused create, want 2", tasks.Total)
+	}
+
+	var report services.UsageReport
+	expect(t, api.do(http.MethodGet, "/usage/", token, nil), http.StatusOK, &report)
+	if report.Daily.Requests != 2 || report.Daily.RequestsLeft == nil || *report.Daily.RequestsLeft != 0 || report.Monthly.RequestsLeft != nil {
+		t.Errorf("usage = %+v", report)
+	}
+
+	other := api.signUp("bob@example.com")
+	api.createTask(other, "Launch")
+}
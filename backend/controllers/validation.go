@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Report fields by their JSON names so clients can match them to inputs
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("password", validatePassword)
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	// max counts characters, but bcrypt's limit is in bytes
+	v.RegisterValidation("maxbytes", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.Atoi(fl.Param())
+		return err == nil && len(fl.Field().String()) <= limit
+	})
+}
+
+// validatePassword requires at least one letter and one digit. Length is
+// checked separately with min and max.
+func validatePassword(fl validator.FieldLevel) bool {
+	var letter, digit bool
+	for _, r := range fl.Field().String() {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return letter && digit
+}
+
+// bindJSON binds and validates the request body. On failure it writes a 400
+// with a message per invalid field and returns false:
+//
+//	{"error": "Validation failed", "fields": {"email": "must be a valid email address"}}
+func bindJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return false
+	}
+
+	fields := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		if _, seen := fields[fe.Field()]; !seen {
+			fields[fe.Field()] = fieldMessage(fe)
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": fields})
+	return false
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "maxbytes":
+		return fmt.Sprintf("must be at most %s bytes", fe.Param())
+	case "password":
+		return "must contain at least one letter and one number"
+	}
+	return "is invalid"
+}
@@ -0,0 +1,74 @@
+package controllers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validationError is the body of a 400 from bindJSON
+type validationError struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields"`
+}
+
+func TestRegisterValidation(t *testing.T) {
+	api := newTestAPI(t)
+	for _, tc := range []struct {
+		body   gin.H
+		fields map[string]string
+	}{
+		{gin.H{}, map[string]string{"name": "is required", "email": "is required", "password": "is required"}},
+		{gin.H{"name": "  ", "email": "ada@example.com", "password": testPassword}, map[string]string{"name": "is required"}},
+		{gin.H{"name": "Ada", "email": "not an address", "password": testPassword}, map[string]string{"email": "must be a valid email address"}},
+		{gin.H{"name": "Ada", "email": "ada@example.com", "password": "short1"}, map[string]string{"password": "must be at least 8 characters"}},
+		{gin.H{"name": "Ada", "email": "ada@example.com", "password": "lettersonly"}, map[string]string{"password": "must contain at least one letter and one number"}},
+		{gin.H{"name": "Ada", "email": "ada@example.com", "password": "1234567890"}, map[string]string{"password": "must contain at least one letter and one number"}},
+		// 38 characters but 74 bytes, past what bcrypt accepts
+		{gin.H{"name": "Ada", "email": "ada@example.com", "password": strings.Repeat("é", 36) + "a1"}, map[string]string{"password": "must be at most 72 bytes"}},
+	} {
+		var resp validationError
+		expect(t, api.do(http.MethodPost, "/auth/register", "", tc.body), http.StatusBadRequest, &resp)
+		if resp.Error != "Validation failed" || len(resp.Fields) != len(tc.fields) {
+			t.Errorf("register %v = %+v, want fields %v", tc.body, resp, tc.fields)
+			continue
+		}
+		for field, msg := range tc.fields {
+			if resp.Fields[field] != msg {
+				t.Errorf("register %v: %s = %q, want %q", tc.body, field, resp.Fields[field], msg)
+			}
+		}
+	}
+
+	expect(t, api.do(http.MethodPost, "/auth/register", "", gin.H{"name": "Ada", "email": "ada@example.com", "password": strings.Repeat("é", 35) + "a1"}), http.StatusOK, nil)
+
+	var resp validationError
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ada"}), http.StatusBadRequest, &resp)
+	if resp.Fields["email"] == "" || resp.Fields["password"] != "is required" {
+		t.Errorf("login fields = %v", resp.Fields)
+	}
+}
+
+func TestRegisterNormalizesEmail(t *testing.T) {
+	api := newTestAPI(t)
+	expect(t, api.do(http.MethodPost, "/auth/register", "", gin.H{"name": " Ada ", "email": "Ada@Example.COM", "password": testPassword}), http.StatusOK, nil)
+
+	var taken validationError
+	expect(t, api.do(http.MethodPost, "/auth/register", "", gin.H{"name": "Ada", "email": "ada@example.com", "password": testPassword}), http.StatusBadRequest, &taken)
+	if taken.Fields["email"] != "is already registered" {
+		t.Errorf("duplicate register = %+v", taken)
+	}
+
+	var resp struct {
+		User struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	expect(t, api.do(http.MethodPost, "/auth/login", "", gin.H{"email": "ADA@example.com", "password": testPassword}), http.StatusOK, &resp)
+	if resp.User.Name != "Ada" || resp.User.Email != "ada@example.com" {
+		t.Errorf("stored user = %+v, want the trimmed name and lowercase email", resp.User)
+	}
+}
@@ -0,0 +1,141 @@
+// Package logging builds the application's structured logger. Every record
+// carries the request ID from its context, and attributes that look like
+// credentials are redacted before they are written.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Config selects the minimum level and output format
+type Config struct {
+	Level slog.Level
+	// Format is "json" (default) or "text"
+	Format string
+}
+
+// New returns a logger writing to w. Install it with slog.SetDefault so
+// every package logs through it.
+func New(cfg Config, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	var next slog.Handler
+	if cfg.Format == "text" {
+		next = slog.NewTextHandler(w, opts)
+	} else {
+		next = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&handler{next: next})
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored by WithRequestID, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Unredacted marks a value that must be logged as is even if it looks like
+// a credential, such as the links printed by the development mailer
+type Unredacted string
+
+func (u Unredacted) LogValue() slog.Value {
+	return slog.StringValue(string(u))
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are redacted wherever they appear as an attribute key suffix
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "api_key", "apikey", "cookie"}
+
+// Credentials inside free text: bearer tokens, JWTs and secrets in URLs
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	queryPattern  = regexp.MustCompile(`(?i)([?&](?:key|api_key|apikey|token|access_token|refresh_token|password)=)[^&\s"']+`)
+)
+
+// handler adds the request ID and redacts records before passing them on
+type handler struct {
+	next slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, scrub(r.Message), r.PC)
+	if id := RequestID(ctx); id != "" {
+		clean.AddAttrs(slog.String("request_id", id))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redact(a)
+	}
+	return &handler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name)}
+}
+
+func redact(a slog.Attr) slog.Attr {
+	if u, ok := a.Value.Any().(Unredacted); ok {
+		return slog.String(a.Key, string(u))
+	}
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, scrub(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		clean := make([]any, len(group))
+		for i, ga := range group {
+			clean[i] = redact(ga)
+		}
+		return slog.Group(a.Key, clean...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, scrub(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func isSensitive(key string) bool {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, s := range sensitiveKeys {
+		if strings.HasSuffix(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	return queryPattern.ReplaceAllString(s, "${1}"+redacted)
+}
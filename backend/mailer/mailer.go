@@ -0,0 +1,46 @@
+// Package mailer sends plain-text email, over SMTP or, for local
+// development, to the log.
+package mailer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/logging"
+)
+
+// Mailer sends one plain-text email
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// Config points at an SMTP relay. Username may be empty for relays that do
+// not authenticate, such as a local MailHog or smtp4dev stub.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// New returns an SMTPMailer, or a LogMailer when no SMTP host is configured
+func New(cfg Config) Mailer {
+	if cfg.Host == "" {
+		return LogMailer{}
+	}
+	return NewSMTPMailer(cfg)
+}
+
+// LogMailer logs emails instead of sending them. It is meant for local
+// development: with LOG_LEVEL=debug the body is logged too, so the links in
+// verification and reset emails can be copied from the server output.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	slog.InfoContext(ctx, "Email not sent, SMTP_HOST is unset", "subject", subject)
+	slog.DebugContext(ctx, "Email", "to", to, "subject", subject, "body", logging.Unredacted(body))
+	return nil
+}
@@ -1,55 +1,264 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/Vanaraj10/taskmorph-backend/auth"
 	"github.com/Vanaraj10/taskmorph-backend/config"
+	"github.com/Vanaraj10/taskmorph-backend/controllers"
+	"github.com/Vanaraj10/taskmorph-backend/logging"
+	"github.com/Vanaraj10/taskmorph-backend/mailer"
 	"github.com/Vanaraj10/taskmorph-backend/middleware"
+	"github.com/Vanaraj10/taskmorph-backend/migrations"
+	"github.com/Vanaraj10/taskmorph-backend/notifications"
+	"github.com/Vanaraj10/taskmorph-backend/ratelimit"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
 	"github.com/Vanaraj10/taskmorph-backend/routes"
+	"github.com/Vanaraj10/taskmorph-backend/scheduler"
+	"github.com/Vanaraj10/taskmorph-backend/services"
+	"github.com/Vanaraj10/taskmorph-backend/store"
 	"github.com/gin-gonic/gin"
-	"github.com/robfig/cron/v3"
 )
 
 func main() {
-	c := cron.New()
+	cfg, err := config.Load()
+	if err != nil {
+		// The logger is not configured yet, so report with the default one
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			slog.Error("Invalid configuration", "error", problem)
+		}
+		os.Exit(1)
+	}
 
-	c.AddFunc("*/10 * * * *", func() {
-		fmt.Println("Running every 5 minutes at", time.Now())
-	})
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(cfg, os.Args[2:]))
+		default:
+			slog.Error("Unknown command, want migrate or none", "command", os.Args[1])
+			os.Exit(2)
+		}
+	}
+	startServer(cfg)
+}
 
-	c.Start()
-	startServer()
+// setupLogging installs the configured logger as the default one
+func setupLogging(cfg *config.Config, w io.Writer) {
+	slog.SetDefault(logging.New(cfg.Log, w))
+	// Route gin's debug-mode output through the logger as well
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("Route", "method", method, "path", path, "handler", handler)
+	}
 }
 
-func startServer () {
+func startServer(cfg *config.Config) {
+	setupLogging(cfg, os.Stdout)
+	for _, warning := range cfg.Warnings() {
+		slog.Warn(warning)
+	}
+
+	// Also cancels connecting to the database when interrupted during startup
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var db *store.Store
+	if cfg.Storage == config.StorageMongo {
+		var err error
+		if db, err = store.Connect(ctx, cfg.Mongo); err != nil {
+			slog.Error("Database unavailable", "error", err)
+			os.Exit(1)
+		}
+		if cfg.MigrateOnStart {
+			if _, err := migrations.NewRunner(db.DB(), migrations.All()).Up(ctx); err != nil {
+				slog.Error("Migrating the database failed", "error", err)
+				os.Exit(1)
+			}
+		}
+	}
+	repos, err := setupRepositories(ctx, cfg, db)
+	if err != nil {
+		slog.Error("Setting up storage failed", "error", err)
+		os.Exit(1)
+	}
+	mail := mailer.New(cfg.Mail)
+
+	generator, err := services.NewStepGenerator(cfg.Generator)
+	if err != nil {
+		slog.Error("Setting up the step generator failed", "error", err)
+		os.Exit(1)
+	}
 
-	// err := godotenv.Load()
-	// if err != nil {
-	// 	panic("Error loading .env file")
-	// }
+	dispatcher := notifications.NewDispatcher(repos.users,
+		notifications.NewNotifiers(cfg.Notifications, repos.notifications, mail)...)
+	jobs, err := scheduler.New(cfg.Scheduler, repos.tasks, repos.reminders, dispatcher)
+	if err != nil {
+		slog.Error("Setting up the scheduler failed", "error", err)
+		os.Exit(1)
+	}
+	jobs.Start()
+
+	router := gin.New()
+	// ClientIP, and so per-IP rate limiting, only honours X-Forwarded-For
+	// from these proxies. gin trusts every proxy unless told otherwise.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
 
-	config.ConnectDB()
-	fmt.Println("TaskMorph Backend is running...")
-	router := gin.Default()
+	router.Use(middleware.RequestLogger(), middleware.Recovery())
 
 	// Add CORS middleware
-	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.CORSMiddleware(cfg.CORS))
+
+	var pingDB func(ctx context.Context) error
+	if db != nil {
+		pingDB = db.Ping
+	}
+	health := controllers.NewHealthController(pingDB, cfg.Generator)
+
+	sessions := auth.NewSessionService(cfg.Token, repos.users, repos.sessions, repos.revokedTokens)
+	accounts := auth.NewAccountService(cfg.Account, cfg.Token, repos.users, repos.accountTokens, sessions, mail)
+	meter := services.NewUsageMeter(cfg.Quota, cfg.Generator.Provider, generator, repos.usage)
+	limits := middleware.NewRateLimits(ratelimit.NewLimiter(repos.rateLimits), cfg.RateLimit)
+	routes.SetupRoutes(router, middleware.AuthMiddleware(sessions), limits,
+		controllers.NewAuthController(repos.users, sessions, accounts, auth.NewLockout(cfg.Lockout, repos.loginAttempts)),
+		controllers.NewTaskController(repos.tasks, meter),
+		controllers.NewNotificationController(repos.notifications, repos.users),
+		controllers.NewUsageController(meter),
+		health,
+	)
+
+	server := &http.Server{
+		Addr:              "0.0.0.0:" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	failed := make(chan error, 1)
+	go func() {
+		slog.Info("TaskMorph Backend is running", "port", cfg.Port, "ai_provider", cfg.Generator.Provider)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down", "timeout", cfg.ShutdownTimeout.String())
+	case err := <-failed:
+		slog.Error("Server stopped", "error", err)
+		exitCode = 1
+	}
+	// A second signal kills the process instead of waiting for the drain
+	stop()
+	shutdown(cfg, server, jobs, db)
+	os.Exit(exitCode)
+}
+
+// shutdown stops accepting connections, lets requests and scheduler jobs in
+// progress finish, then closes the database connection. Everything shares one
+// deadline of cfg.ShutdownTimeout.
+func shutdown(cfg *config.Config, server *http.Server, jobs *scheduler.Scheduler, db *store.Store) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-	routes.SetupRoutes(router)
+	jobsDone := jobs.Stop()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Requests still running at shutdown deadline", "error", err)
+	}
+	select {
+	case <-jobsDone.Done():
+	case <-ctx.Done():
+		slog.Warn("Scheduler jobs still running at shutdown deadline")
+	}
+	if db != nil {
+		if err := db.Close(ctx); err != nil {
+			slog.Warn("Disconnecting from MongoDB failed", "error", err)
+		}
+	}
+	slog.Info("Shutdown complete")
+}
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "OK",
-			"message": "TaskMorph Backend is running",
-		})
-	})
+type repositories struct {
+	tasks         repository.TaskRepository
+	users         repository.UserRepository
+	reminders     repository.ReminderRepository
+	notifications repository.NotificationRepository
+	sessions      repository.SessionRepository
+	revokedTokens repository.RevokedTokenRepository
+	accountTokens repository.AccountTokenRepository
+	usage         repository.UsageRepository
+	rateLimits    repository.RateLimitRepository
+	loginAttempts repository.LoginAttemptRepository
+}
+
+// setupRepositories builds the repositories on db, or in memory when
+// STORAGE=memory and db is nil, and creates the MongoDB indexes. The rate
+// limiter and login lockout follow RATE_LIMIT_STORE instead, since keeping
+// them in memory is fine for a single instance and saves a database write per
+// request.
+func setupRepositories(ctx context.Context, cfg *config.Config, db *store.Store) (repositories, error) {
+	var repos repositories
+	if db == nil {
+		slog.Warn("Using in-memory storage, data will not persist")
+		repos = repositories{
+			tasks:         repository.NewMemoryTaskRepository(),
+			users:         repository.NewMemoryUserRepository(),
+			reminders:     repository.NewMemoryReminderRepository(),
+			notifications: repository.NewMemoryNotificationRepository(),
+			sessions:      repository.NewMemorySessionRepository(),
+			revokedTokens: repository.NewMemoryRevokedTokenRepository(),
+			accountTokens: repository.NewMemoryAccountTokenRepository(),
+			usage:         repository.NewMemoryUsageRepository(),
+			rateLimits:    repository.NewMemoryRateLimitRepository(),
+			loginAttempts: repository.NewMemoryLoginAttemptRepository(),
+		}
+		return repos, nil
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port if not specified
+	tasks := repository.NewMongoTaskRepository(db.DB())
+	users := repository.NewMongoUserRepository(db.DB())
+	reminders := repository.NewMongoReminderRepository(db.DB())
+	inbox := repository.NewMongoNotificationRepository(db.DB())
+	sessions := repository.NewMongoSessionRepository(db.DB())
+	revokedTokens := repository.NewMongoRevokedTokenRepository(db.DB())
+	accountTokens := repository.NewMongoAccountTokenRepository(db.DB())
+	usage := repository.NewMongoUsageRepository(db.DB())
+	indexed := []store.Indexer{tasks, users, reminders, inbox, sessions, revokedTokens, accountTokens, usage}
+	repos = repositories{
+		tasks:         tasks,
+		users:         users,
+		reminders:     reminders,
+		notifications: inbox,
+		sessions:      sessions,
+		revokedTokens: revokedTokens,
+		accountTokens: accountTokens,
+		usage:         usage,
+		rateLimits:    repository.NewMemoryRateLimitRepository(),
+		loginAttempts: repository.NewMemoryLoginAttemptRepository(),
+	}
+	if cfg.RateLimit.Store == config.StorageMongo {
+		rateLimits := repository.NewMongoRateLimitRepository(db.DB())
+		loginAttempts := repository.NewMongoLoginAttemptRepository(db.DB())
+		indexed = append(indexed, rateLimits, loginAttempts)
+		repos.rateLimits = rateLimits
+		repos.loginAttempts = loginAttempts
 	}
 
-	router.Run("0.0.0.0:" + port)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return repos, db.EnsureIndexes(ctx, indexed...)
 }
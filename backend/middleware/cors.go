@@ -1,39 +1,149 @@
 package middleware
 
 import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware handles CORS headers
-func CORSMiddleware() gin.HandlerFunc {
-	return gin.HandlerFunc(func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-
-		// Debug logging
-		println("🔍 CORS Debug - Origin:", origin)
-		println("🔍 CORS Debug - Method:", c.Request.Method)
-		println("🔍 CORS Debug - Path:", c.Request.URL.Path)
+// CORSConfig lists who may call the API from a browser. An allowed origin is
+// either exact, like "https://app.example.com", or matches any subdomain,
+// like "https://*.example.com". "*" allows every origin but cannot be
+// combined with credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
 
-		// Allow specific origins or all origins for development
-		if origin != "" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			println("✅ CORS - Set Allow-Origin to:", origin)
-		} else {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-			println("✅ CORS - Set Allow-Origin to: *")
+// Validate rejects malformed origins and "*" combined with credentials
+func (cfg CORSConfig) Validate() error {
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			if cfg.AllowCredentials {
+				return fmt.Errorf("CORS origin * cannot be used with credentials")
+			}
+			continue
 		}
+		u, err := url.Parse(strings.Replace(origin, "*.", "wildcard.", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || strings.Count(origin, "*") > 1 ||
+			(strings.Contains(origin, "*") && !strings.Contains(origin, "://*.")) {
+			return fmt.Errorf("invalid CORS origin %q, want scheme://host[:port] or scheme://*.domain", origin)
+		}
+	}
+	return nil
+}
+
+// CORSMiddleware answers preflight requests and adds CORS headers for the
+// allowed origins. Requests from other origins get no CORS headers, so
+// browsers refuse to hand them the response; their preflights are logged and
+// rejected with 403.
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	allowAll := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
 
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
 
-		if c.Request.Method == "OPTIONS" {
-			println("✅ CORS - Handling OPTIONS preflight request")
-			c.AbortWithStatus(204)
+		// The response depends on these, so caches must key on them
+		c.Writer.Header().Add("Vary", "Origin")
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		allowed := allowAll || originAllowed(cfg.AllowedOrigins, origin)
+		if preflight {
+			method := c.GetHeader("Access-Control-Request-Method")
+			requested := utils.SplitList(c.GetHeader("Access-Control-Request-Headers"))
+			reason := ""
+			switch {
+			case !allowed:
+				reason = "origin not allowed"
+			case !slices.Contains(cfg.AllowedMethods, strings.ToUpper(method)):
+				reason = "method not allowed"
+			case !headersAllowed(cfg.AllowedHeaders, requested):
+				reason = "header not allowed"
+			}
+			if reason != "" {
+				slog.WarnContext(c.Request.Context(), "Rejected CORS preflight",
+					"origin", origin, "method", method, "headers", strings.Join(requested, ", "), "reason", reason)
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+		if !allowed {
+			c.Next()
 			return
 		}
 
+		if allowAll && !cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
 		c.Next()
-	})
+	}
+}
+
+// originAllowed matches origin against exact entries and "scheme://*.domain"
+// entries, which match any subdomain of domain but not domain itself
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == origin {
+			return true
+		}
+		prefix, suffix, ok := strings.Cut(pattern, "*")
+		if ok && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+			!strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:@?#") {
+			return true
+		}
+	}
+	return false
+}
+
+func headersAllowed(allowed, requested []string) bool {
+	for _, h := range requested {
+		if !slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, h) }) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.example.com", "https://*.example.com", "http://localhost:5173"}
+	for origin, want := range map[string]bool{
+		"https://app.example.com":          true,
+		"HTTPS://APP.EXAMPLE.COM":          true,
+		"https://a.example.com":            true,
+		"https://a.b.example.com":          true,
+		"http://localhost:5173":            true,
+		"https://example.com":              false,
+		"https://.example.com":             false,
+		"http://a.example.com":             false,
+		"https://evil-example.com":         false,
+		"https://evilexample.com":          false,
+		"https://a.example.com.evil.com":   false,
+		"https://a.example.com:8443":       false,
+		"https://evil.com@a.example.com":   false,
+		"https://evil.com:x@a.example.com": false,
+		"https://evil.com/.example.com":    false,
+		"https://evil.com?.example.com":    false,
+		"https://evil.com#.example.com":    false,
+		"https://a.example.com/path":       false,
+		"http://localhost:5174":            false,
+		"http://localhost:5173.evil.com":   false,
+		"null":                             false,
+		"":                                 false,
+	} {
+		if got := originAllowed(allowed, origin); got != want {
+			t.Errorf("originAllowed(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+// corsRouter serves GET /ping behind the CORS middleware
+func corsRouter(cfg CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(cfg))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/ping", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+var testCORSConfig = CORSConfig{
+	AllowedOrigins: []string{"https://*.example.com"},
+	AllowedMethods: []string{"GET", "POST"},
+	AllowedHeaders: []string{"Authorization", "Content-Type"},
+	ExposedHeaders: []string{RequestIDHeader},
+	MaxAge:         time.Hour,
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := corsRouter(testCORSConfig)
+
+	rec := corsRequest(router, http.MethodOptions, "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "authorization, content-type",
+	})
+	h := rec.Header()
+	if rec.Code != http.StatusNoContent || h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Allow-Methods") != "GET, POST" || h.Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("allowed preflight = %d %v", rec.Code, h)
+	}
+	if vary := h.Values("Vary"); !slices.Contains(vary, "Origin") || !slices.Contains(vary, "Access-Control-Request-Method") {
+		t.Errorf("Vary = %v", vary)
+	}
+
+	for name, tc := range map[string]struct {
+		origin, method, headers string
+	}{
+		"origin":      {"https://evil-example.com", "GET", ""},
+		"suffix":      {"https://a.example.com.evil.com", "GET", ""},
+		"userinfo":    {"https://evil.com@a.example.com", "GET", ""},
+		"method":      {"https://app.example.com", "DELETE", ""},
+		"header":      {"https://app.example.com", "GET", "X-Custom"},
+		"null origin": {"null", "GET", ""},
+	} {
+		rec := corsRequest(router, http.MethodOptions, tc.origin, map[string]string{
+			"Access-Control-Request-Method":  tc.method,
+			"Access-Control-Request-Headers": tc.headers,
+		})
+		if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s: rejected preflight = %d with ACAO %q, want 403 and none", name, rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+		if !slices.Contains(rec.Header().Values("Vary"), "Origin") {
+			t.Errorf("%s: Vary = %v", name, rec.Header().Values("Vary"))
+		}
+	}
+}
+
+func TestCORSSimpleRequests(t *testing.T) {
+	router := corsRouter(testCORSConfig)
+
+	rec := corsRequest(router, http.MethodGet, "https://app.example.com", nil)
+	h := rec.Header()
+	if rec.Code != http.StatusOK || h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Expose-Headers") != RequestIDHeader || h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("allowed request = %d %v", rec.Code, h)
+	}
+
+	// Other origins still reach the handler but get no CORS headers
+	for _, origin := range []string{"https://evil-example.com", "https://a.example.com.evil.com", "https://evil.com@a.example.com", ""} {
+		rec := corsRequest(router, http.MethodGet, origin, nil)
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("origin %q = %d with ACAO %q", origin, rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+		if rec.Header().Get("Vary") != "Origin" {
+			t.Errorf("origin %q: Vary = %q, want Origin", origin, rec.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestCORSWildcardAndCredentials(t *testing.T) {
+	rec := corsRequest(corsRouter(CORSConfig{AllowedOrigins: []string{"*"}}), http.MethodGet, "https://anywhere.test", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("ACAO with * = %q", got)
+	}
+
+	cfg := testCORSConfig
+	cfg.AllowCredentials = true
+	rec = corsRequest(corsRouter(cfg), http.MethodGet, "https://app.example.com", nil)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("credentialed response headers = %v", rec.Header())
+	}
+}
+
+func TestCORSConfigValidate(t *testing.T) {
+	for _, cfg := range []CORSConfig{
+		{AllowedOrigins: []string{"https://a.test", "https://*.b.test", "http://localhost:5173"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"*"}},
+	} {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate(%v) = %v", cfg.AllowedOrigins, err)
+		}
+	}
+
+	for _, cfg := range []CORSConfig{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"https://a.test/path"}},
+		{AllowedOrigins: []string{"ftp://a.test"}},
+		{AllowedOrigins: []string{"https://a.*.test"}},
+		{AllowedOrigins: []string{"https://*.*.test"}},
+		{AllowedOrigins: []string{"a.test"}},
+		{AllowedOrigins: []string{"https://a.test?x=1"}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate(%v, credentials %v) accepted it", cfg.AllowedOrigins, cfg.AllowCredentials)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vanaraj10/taskmorph-backend/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(logging.Config{Level: slog.LevelInfo}, &buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	router := gin.New()
+	router.Use(RequestLogger())
+	var seen string
+	router.GET("/ping", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusTeapot)
+	})
+
+	for _, tc := range []struct {
+		header string
+		reused bool
+	}{
+		{"client-id.42", true},
+		{"", false},
+		{"bad id\nwith newline", false},
+		{strings.Repeat("a", 65), false},
+	} {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/ping?token=r3fresh-token-value", nil)
+		if tc.header != "" {
+			req.Header.Set(RequestIDHeader, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(RequestIDHeader)
+		if id == "" || id != seen || (id == tc.header) != tc.reused {
+			t.Errorf("header %q: response ID %q, handler saw %q", tc.header, id, seen)
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("log line %q: %v", buf.String(), err)
+		}
+		if record["request_id"] != id || record["path"] != "/ping" || record["status"] != float64(http.StatusTeapot) || record["level"] != "WARN" {
+			t.Errorf("log record = %v", record)
+		}
+		if strings.Contains(buf.String(), "r3fresh-token-value") {
+			t.Errorf("the query string was logged: %s", buf.String())
+		}
+	}
+}
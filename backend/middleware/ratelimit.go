@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/auth"
+	"github.com/Vanaraj10/taskmorph-backend/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimits holds one middleware per route group
+type RateLimits struct {
+	Auth gin.HandlerFunc
+	AI   gin.HandlerFunc
+	API  gin.HandlerFunc
+}
+
+// NewRateLimits builds the group middlewares from the configured policies
+func NewRateLimits(limiter *ratelimit.Limiter, cfg ratelimit.Config) RateLimits {
+	return RateLimits{
+		Auth: RateLimit(limiter, "auth", cfg.Auth),
+		AI:   RateLimit(limiter, "ai", cfg.AI),
+		API:  RateLimit(limiter, "api", cfg.API),
+	}
+}
+
+// limitCheck is one bucket a request has to draw from
+type limitCheck struct {
+	key  string
+	rule ratelimit.Rule
+}
+
+// RateLimit rejects requests over the policy with 429 Too Many Requests. The
+// per-IP rule always applies; the per-user rule applies when AuthMiddleware
+// ran first. Every bucket is checked before a token is taken from any, so a
+// request refused by one bucket does not use up the others. Buckets are
+// namespaced by scope, so groups do not share limits. If the bucket store
+// fails the request is let through rather than taking the API down with it.
+func RateLimit(limiter *ratelimit.Limiter, scope string, policy ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		now := time.Now()
+
+		checks := []limitCheck{{scope + ":ip:" + c.ClientIP(), policy.PerIP}}
+		if userID, ok := auth.UserID(c); ok {
+			checks = append(checks, limitCheck{scope + ":user:" + userID.Hex(), policy.PerUser})
+		}
+
+		for _, check := range checks {
+			decision, err := limiter.Check(ctx, check.key, check.rule, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "Rate limiter unavailable, allowing request", "bucket", check.key, "error", err)
+				continue
+			}
+			if !decision.Allowed {
+				tooManyRequests(c, decision)
+				return
+			}
+		}
+
+		// A concurrent request can still empty a bucket after the check
+		var tightest *ratelimit.Decision
+		for _, check := range checks {
+			decision, err := limiter.Allow(ctx, check.key, check.rule, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "Rate limiter unavailable, allowing request", "bucket", check.key, "error", err)
+				continue
+			}
+			if !decision.Allowed {
+				tooManyRequests(c, decision)
+				return
+			}
+			if decision.Limit > 0 && (tightest == nil || decision.Remaining < tightest.Remaining) {
+				tightest = &decision
+			}
+		}
+
+		if tightest != nil {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		}
+		c.Next()
+	}
+}
+
+func tooManyRequests(c *gin.Context, decision ratelimit.Decision) {
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Header("X-RateLimit-Remaining", "0")
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many requests, slow down",
+		"retry_after": retryAfter,
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/config"
+	"github.com/Vanaraj10/taskmorph-backend/migrations"
+	"github.com/Vanaraj10/taskmorph-backend/store"
+)
+
+// runMigrate implements the migrate command. It applies the pending
+// migrations or, with -dry-run, lists every migration and how many documents
+// the pending ones would change. Logs go to stderr so stdout holds only the
+// report. It returns the exit code.
+func runMigrate(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list pending migrations without applying them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	setupLogging(cfg, os.Stderr)
+	if cfg.Storage != config.StorageMongo {
+		slog.Error("Nothing to migrate, STORAGE is not mongo")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	db, err := store.Connect(ctx, cfg.Mongo)
+	if err != nil {
+		slog.Error("Database unavailable", "error", err)
+		return 1
+	}
+	defer db.Close(context.Background())
+	runner := migrations.NewRunner(db.DB(), migrations.All())
+
+	if *dryRun {
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			slog.Error("Reading migration status failed", "error", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, s := range statuses {
+			state := fmt.Sprintf("pending, %d documents to change", s.Pending)
+			if s.Applied != nil {
+				state = "applied " + s.Applied.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Migration.ID, state, s.Migration.Description)
+		}
+		w.Flush()
+		return 0
+	}
+
+	records, err := runner.Up(ctx)
+	for _, record := range records {
+		fmt.Printf("%s applied, %d documents changed\n", record.ID, record.Changed)
+	}
+	if err != nil {
+		slog.Error("Migrating the database failed", "error", err)
+		return 1
+	}
+	if len(records) == 0 {
+		fmt.Println("Database is up to date")
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Tasks created before progress and status were stored have neither, so
+// status filters and progress sorting skip them. The pipeline is a frozen
+// copy of the status rules of the time, not repository.StatusPipeline, so
+// later changes to those rules cannot change what this migration does. It
+// leaves completed_at alone for 0004 to estimate.
+var backfillTaskStatus = Migration{
+	ID:          "0001_backfill_task_status",
+	Description: "Compute progress and status for tasks that lack them",
+	Pending: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		return db.Collection("tasks").CountDocuments(ctx, taskStatusMissing)
+	},
+	Up: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		result, err := db.Collection("tasks").UpdateMany(ctx, taskStatusMissing, taskStatusPipeline(time.Now()))
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	},
+}
+
+var taskStatusMissing = bson.M{"$or": bson.A{
+	bson.M{"status": bson.M{"$exists": false}},
+	bson.M{"progress": bson.M{"$exists": false}},
+}}
+
+func taskStatusPipeline(now time.Time) mongo.Pipeline {
+	steps := bson.M{"$ifNull": bson.A{"$steps", bson.A{}}}
+	total := bson.M{"$size": steps}
+	completed := bson.M{"$size": bson.M{"$filter": bson.M{
+		"input": steps,
+		"cond":  bson.M{"$eq": bson.A{"$$this.is_completed", true}},
+	}}}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"progress": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{total, 0}},
+				bson.M{"$toInt": bson.M{"$floor": bson.M{"$divide": bson.A{bson.M{"$multiply": bson.A{completed, 100}}, total}}}},
+				0,
+			}},
+			"status": bson.M{"$switch": bson.M{
+				"branches": bson.A{
+					bson.M{"case": bson.M{"$and": bson.A{bson.M{"$gt": bson.A{total, 0}}, bson.M{"$eq": bson.A{completed, total}}}}, "then": "done"},
+					bson.M{"case": bson.M{"$lt": bson.A{"$deadline", now}}, "then": "overdue"},
+					bson.M{"case": bson.M{"$gt": bson.A{completed, 0}}, "then": "in_progress"},
+				},
+				"default": "todo",
+			}},
+		}}},
+	}
+}
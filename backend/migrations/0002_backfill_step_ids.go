@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Steps generated before steps had IDs were stored without _id, so the step
+// endpoints cannot address them
+var backfillStepIDs = Migration{
+	ID:          "0002_backfill_step_ids",
+	Description: "Give every step without an _id a new ObjectID",
+	Pending: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		return db.Collection("tasks").CountDocuments(ctx, stepIDMissing)
+	},
+	Up: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		tasks := db.Collection("tasks")
+		cursor, err := tasks.Find(ctx, stepIDMissing, options.Find().SetProjection(bson.M{"steps": 1}))
+		if err != nil {
+			return 0, err
+		}
+		defer cursor.Close(ctx)
+
+		var changed int64
+		for cursor.Next(ctx) {
+			// bson.D keeps the step fields in their stored order
+			var task struct {
+				ID    primitive.ObjectID `bson:"_id"`
+				Steps []bson.D           `bson:"steps"`
+			}
+			if err := cursor.Decode(&task); err != nil {
+				return changed, err
+			}
+			for i, step := range task.Steps {
+				if !hasKey(step, "_id") {
+					task.Steps[i] = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, step...)
+				}
+			}
+			result, err := tasks.UpdateOne(ctx, bson.M{"_id": task.ID}, bson.M{"$set": bson.M{"steps": task.Steps}})
+			if err != nil {
+				return changed, err
+			}
+			changed += result.ModifiedCount
+		}
+		return changed, cursor.Err()
+	},
+}
+
+var stepIDMissing = bson.M{"steps": bson.M{"$elemMatch": bson.M{"_id": bson.M{"$exists": false}}}}
+
+func hasKey(doc bson.D, key string) bool {
+	for _, e := range doc {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
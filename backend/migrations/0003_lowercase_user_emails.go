@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Accounts registered before emails were normalized may be stored with
+// capitals or spaces, and can then no longer log in, since logins look up
+// the normalized address. The expression mirrors models.NormalizeEmail.
+var lowercaseUserEmails = Migration{
+	ID:          "0003_lowercase_user_emails",
+	Description: "Store user emails trimmed and lowercase",
+	Pending: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		return db.Collection("users").CountDocuments(ctx, emailNotNormalized)
+	},
+	Up: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		users := db.Collection("users")
+
+		// Two accounts that differ only in case would collide on the unique
+		// email index. Picking the one to keep is for a person to decide.
+		cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"email": bson.M{"$type": "string"}}}},
+			{{Key: "$group", Value: bson.M{"_id": normalizedEmail, "ids": bson.M{"$push": "$_id"}, "count": bson.M{"$sum": 1}}}},
+			{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		})
+		if err != nil {
+			return 0, err
+		}
+		var conflicts []struct {
+			IDs []any `bson:"ids"`
+		}
+		if err := cursor.All(ctx, &conflicts); err != nil {
+			return 0, err
+		}
+		if len(conflicts) > 0 {
+			return 0, fmt.Errorf("%w: %d emails belong to several accounts once lowercased, such as users %v",
+				errConflict, len(conflicts), conflicts[0].IDs)
+		}
+
+		result, err := users.UpdateMany(ctx, emailNotNormalized, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"email": normalizedEmail}}},
+		})
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	},
+}
+
+// errConflict marks data a migration cannot fix on its own
+var errConflict = errors.New("needs manual fixing")
+
+var (
+	normalizedEmail    = bson.M{"$trim": bson.M{"input": bson.M{"$toLower": "$email"}}}
+	emailNotNormalized = bson.M{
+		"email": bson.M{"$type": "string"},
+		"$expr": bson.M{"$ne": bson.A{"$email", normalizedEmail}},
+	}
+)
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Tasks and steps stored before timestamps were recorded have none, so they
+// sort first by created or updated time. The best estimates on record are the
+// creation time in the task's ObjectID and the step completion times.
+var backfillTaskTimestamps = Migration{
+	ID:          "0004_backfill_task_timestamps",
+	Description: "Estimate created, updated and completed times for tasks and steps that lack them",
+	Pending: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		return db.Collection("tasks").CountDocuments(ctx, taskTimestampsMissing)
+	},
+	Up: func(ctx context.Context, db *mongo.Database) (int64, error) {
+		result, err := db.Collection("tasks").UpdateMany(ctx, taskTimestampsMissing, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"created_at": bson.M{"$ifNull": bson.A{"$created_at", bson.M{"$toDate": "$_id"}}},
+			}}},
+			{{Key: "$set", Value: bson.M{
+				"updated_at": bson.M{"$ifNull": bson.A{
+					"$updated_at",
+					bson.M{"$max": bson.A{"$created_at", bson.M{"$max": "$steps.completed_at"}}},
+				}},
+				// Fields already on a step win over the estimates
+				"steps": bson.M{"$map": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$steps", bson.A{}}},
+					"in": bson.M{"$mergeObjects": bson.A{
+						bson.M{
+							"created_at": "$created_at",
+							"updated_at": bson.M{"$ifNull": bson.A{"$$this.completed_at", "$created_at"}},
+						},
+						"$$this",
+					}},
+				}},
+			}}},
+			{{Key: "$set", Value: bson.M{
+				"completed_at": bson.M{"$cond": bson.A{
+					bson.M{"$eq": bson.A{"$status", models.StatusDone}},
+					bson.M{"$ifNull": bson.A{
+						"$completed_at",
+						bson.M{"$ifNull": bson.A{bson.M{"$max": "$steps.completed_at"}, "$updated_at"}},
+					}},
+					"$$REMOVE",
+				}},
+			}}},
+		})
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	},
+}
+
+var taskTimestampsMissing = bson.M{"$or": bson.A{
+	bson.M{"created_at": bson.M{"$exists": false}},
+	bson.M{"steps": bson.M{"$elemMatch": bson.M{"created_at": bson.M{"$exists": false}}}},
+	bson.M{"status": models.StatusDone, "completed_at": bson.M{"$exists": false}},
+}}
@@ -0,0 +1,288 @@
+// Package migrations brings existing MongoDB documents up to date with the
+// models. Migrations run in ID order, at most once each, and every applied
+// migration is recorded in the "schema_migrations" collection.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is one up-migration
+type Migration struct {
+	// ID orders the migrations, such as "0001_backfill_task_status". It must
+	// never change once released, since it is what gets recorded.
+	ID          string
+	Description string
+	// Pending counts the documents Up would change, for dry runs
+	Pending func(ctx context.Context, db *mongo.Database) (int64, error)
+	// Up applies the migration and returns how many documents it changed.
+	// It must be idempotent: if the process dies before the migration is
+	// recorded, it runs again.
+	Up func(ctx context.Context, db *mongo.Database) (int64, error)
+}
+
+// All is every migration, in the order they run. Append new ones at the end.
+func All() []Migration {
+	return []Migration{
+		backfillTaskStatus,
+		backfillStepIDs,
+		lowercaseUserEmails,
+		backfillTaskTimestamps,
+	}
+}
+
+// Record is a migration's entry in schema_migrations
+type Record struct {
+	ID          string    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+	Changed     int64     `bson:"changed"`
+	DurationMS  int64     `bson:"duration_ms"`
+}
+
+// Status is a migration and, if it ran, its record. Pending is only counted
+// for migrations that have not run.
+type Status struct {
+	Migration Migration
+	Applied   *Record
+	Pending   int64
+}
+
+const (
+	// lockID is the schema_migrations document held while migrating, so
+	// instances starting together do not run the same migration twice
+	lockID = "lock"
+	// lockTTL frees the lock of an instance that died while migrating. The
+	// holder renews it every lockRenewal, so long migrations keep it.
+	lockTTL     = 10 * time.Minute
+	lockRenewal = lockTTL / 4
+)
+
+// errLockLost stops migrating when the lock could not be renewed in time and
+// another instance may have taken it
+var errLockLost = errors.New("migration lock lost")
+
+// Runner applies migrations to one database
+type Runner struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+}
+
+func NewRunner(db *mongo.Database, migrations []Migration) *Runner {
+	return &Runner{db: db, collection: db.Collection("schema_migrations"), migrations: migrations}
+}
+
+// Status reports every migration, counting what the pending ones would
+// change without changing anything
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(r.migrations))
+	for i, m := range r.migrations {
+		statuses[i] = Status{Migration: m}
+		if record, ok := applied[m.ID]; ok {
+			statuses[i].Applied = &record
+			continue
+		}
+		if statuses[i].Pending, err = m.Pending(ctx, r.db); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+	}
+	return statuses, nil
+}
+
+// Up applies the migrations that have not run yet, in order, stopping at the
+// first failure. It returns the records of the migrations it applied.
+func (r *Runner) Up(ctx context.Context) ([]Record, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	owner, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.unlock(owner)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		r.renew(ctx, owner, cancel)
+	}()
+	defer func() {
+		cancel(nil)
+		<-renewed
+	}()
+
+	// Read after locking, so migrations another instance just ran are seen
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, m := range r.migrations {
+		if _, ok := applied[m.ID]; ok {
+			continue
+		}
+		slog.InfoContext(ctx, "Applying migration", "migration", m.ID)
+		start := time.Now()
+		changed, err := m.Up(ctx, r.db)
+		if cause := context.Cause(ctx); errors.Is(cause, errLockLost) {
+			err = cause
+		}
+		if err != nil {
+			return records, fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		// Only the holder may record, or another instance could run the
+		// migration again after it took the lock
+		if err := r.holds(ctx, owner); err != nil {
+			return records, fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		record := Record{
+			ID:          m.ID,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC(),
+			Changed:     changed,
+			DurationMS:  time.Since(start).Milliseconds(),
+		}
+		if _, err := r.collection.InsertOne(ctx, record); err != nil {
+			return records, fmt.Errorf("record migration %s: %w", m.ID, err)
+		}
+		slog.InfoContext(ctx, "Applied migration", "migration", m.ID, "changed", changed, "duration_ms", record.DurationMS)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// validate rejects empty, duplicate and out-of-order IDs
+func (r *Runner) validate() error {
+	for i, m := range r.migrations {
+		if m.ID == "" || m.Up == nil || m.Pending == nil {
+			return fmt.Errorf("migration %d is incomplete", i)
+		}
+		if i > 0 && m.ID <= r.migrations[i-1].ID {
+			return fmt.Errorf("migration %s is out of order", m.ID)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[string]Record, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"applied_at": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[string]Record, len(records))
+	for _, record := range records {
+		applied[record.ID] = record
+	}
+	return applied, nil
+}
+
+// lock takes the migration lock, waiting while another instance holds it,
+// and returns the owner token that releases it
+func (r *Runner) lock(ctx context.Context) (string, error) {
+	owner := primitive.NewObjectID().Hex()
+	waiting := false
+	for {
+		now := time.Now()
+		_, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": lockID, "locked_until": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "locked_until": now.Add(lockTTL)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			return owner, nil
+		}
+		// The upsert collides with the lock document while it is held
+		if !mongo.IsDuplicateKeyError(err) {
+			return "", fmt.Errorf("take migration lock: %w", err)
+		}
+		if !waiting {
+			slog.InfoContext(ctx, "Waiting for another instance to finish migrating")
+			waiting = true
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return "", fmt.Errorf("take migration lock: %w", ctx.Err())
+		}
+	}
+}
+
+// renew extends the lock until ctx is done, cancelling ctx with errLockLost
+// if the lock is no longer held
+func (r *Runner) renew(ctx context.Context, owner string, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(lockRenewal)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": lockID, "owner": owner},
+			bson.M{"$set": bson.M{"locked_until": time.Now().Add(lockTTL)}},
+		)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// A later tick may still succeed before the lock runs out
+			slog.WarnContext(ctx, "Renewing migration lock failed", "error", err)
+			continue
+		}
+		if result.MatchedCount == 0 {
+			cancel(errLockLost)
+			return
+		}
+	}
+}
+
+// holds returns errLockLost unless owner still has the lock
+func (r *Runner) holds(ctx context.Context, owner string) error {
+	n, err := r.collection.CountDocuments(ctx, bson.M{"_id": lockID, "owner": owner, "locked_until": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errLockLost) {
+			return cause
+		}
+		return err
+	}
+	if n == 0 {
+		return errLockLost
+	}
+	return nil
+}
+
+func (r *Runner) unlock(owner string) {
+	// Release even when ctx was cancelled, or others wait out the TTL
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner}); err != nil {
+		slog.WarnContext(ctx, "Releasing migration lock failed", "error", err)
+	}
+}
@@ -0,0 +1,449 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoDB returns a fresh database on the server at TEST_MONGO_URI, which
+// is dropped when the test ends. The test is skipped when it is not set.
+func testMongoDB(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	db := client.Database("taskmorph_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+// countingMigration counts its Up calls in runs and changes nothing
+func countingMigration(id string, runs *int, err error) Migration {
+	return Migration{
+		ID:          id,
+		Description: "Test migration " + id,
+		Pending: func(ctx context.Context, db *mongo.Database) (int64, error) {
+			return 1, nil
+		},
+		Up: func(ctx context.Context, db *mongo.Database) (int64, error) {
+			*runs++
+			return 1, err
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var runs int
+	a, b := countingMigration("0001_a", &runs, nil), countingMigration("0002_b", &runs, nil)
+	noUp := b
+	noUp.Up = nil
+
+	tests := []struct {
+		name       string
+		migrations []Migration
+		want       string
+	}{
+		{"none", nil, ""},
+		{"in order", []Migration{a, b}, ""},
+		{"empty ID", []Migration{a, {Up: b.Up, Pending: b.Pending}}, "migration 1 is incomplete"},
+		{"no Up", []Migration{a, noUp}, "migration 1 is incomplete"},
+		{"duplicate", []Migration{a, a}, "migration 0001_a is out of order"},
+		{"out of order", []Migration{b, a}, "migration 0001_a is out of order"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := (&Runner{migrations: tt.migrations}).validate(); err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%s: validate() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAllIsValid(t *testing.T) {
+	if err := (&Runner{migrations: All()}).validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpAppliesEachMigrationOnce(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	var first, second int
+	runner := NewRunner(db, []Migration{countingMigration("0001_a", &first, nil), countingMigration("0002_b", &second, nil)})
+
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied != nil || s.Pending != 1 {
+			t.Errorf("%s before Up: applied %v, pending %d", s.Migration.ID, s.Applied, s.Pending)
+		}
+	}
+
+	records, err := runner.Up(ctx)
+	if err != nil || len(records) != 2 || records[0].ID != "0001_a" || records[1].Changed != 1 {
+		t.Fatalf("Up() = %+v, %v", records, err)
+	}
+	if records, err := runner.Up(ctx); err != nil || len(records) != 0 {
+		t.Errorf("second Up() = %+v, %v, want nothing to do", records, err)
+	}
+	if first != 1 || second != 1 {
+		t.Errorf("migrations ran %d and %d times, want once each", first, second)
+	}
+
+	statuses, err = runner.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied == nil || s.Pending != 0 {
+			t.Errorf("%s after Up: applied %v, pending %d", s.Migration.ID, s.Applied, s.Pending)
+		}
+	}
+	if n, _ := db.Collection("schema_migrations").CountDocuments(ctx, bson.M{"_id": lockID}); n != 0 {
+		t.Error("Up left the lock behind")
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	failure := errors.New("boom")
+	var first, second, third int
+	runner := NewRunner(db, []Migration{
+		countingMigration("0001_a", &first, nil),
+		countingMigration("0002_b", &second, failure),
+		countingMigration("0003_c", &third, nil),
+	})
+
+	records, err := runner.Up(ctx)
+	if !errors.Is(err, failure) || len(records) != 1 {
+		t.Fatalf("Up() = %+v, %v, want the first record and the failure", records, err)
+	}
+	if third != 0 {
+		t.Error("a migration after the failure ran")
+	}
+
+	runner.migrations[1] = countingMigration("0002_b", &second, nil)
+	if records, err := runner.Up(ctx); err != nil || len(records) != 2 || records[0].ID != "0002_b" {
+		t.Errorf("Up() after the fix = %+v, %v", records, err)
+	}
+	if first != 1 || second != 2 || third != 1 {
+		t.Errorf("runs = %d, %d, %d, want 1, 2, 1", first, second, third)
+	}
+}
+
+func TestLockOwnership(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	runner := NewRunner(db, nil)
+
+	owner, err := runner.lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.holds(ctx, owner); err != nil {
+		t.Fatalf("holds() = %v right after locking", err)
+	}
+
+	// A second instance waits while the lock is held
+	waitCtx, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancel()
+	if _, err := runner.lock(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock() while held = %v, want to wait until the deadline", err)
+	}
+
+	// Once the holder stops renewing, the lock runs out and can be taken
+	if _, err := runner.collection.UpdateOne(ctx, bson.M{"_id": lockID}, bson.M{"$set": bson.M{"locked_until": time.Now().Add(-time.Second)}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.holds(ctx, owner); !errors.Is(err, errLockLost) {
+		t.Errorf("holds() on an expired lock = %v, want errLockLost", err)
+	}
+	taker, err := runner.lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.holds(ctx, owner); !errors.Is(err, errLockLost) {
+		t.Errorf("holds() after another took the lock = %v, want errLockLost", err)
+	}
+
+	// The old holder cannot release the new holder's lock
+	runner.unlock(owner)
+	if err := runner.holds(ctx, taker); err != nil {
+		t.Errorf("the old holder released the lock: %v", err)
+	}
+	runner.unlock(taker)
+	if n, _ := runner.collection.CountDocuments(ctx, bson.M{"_id": lockID}); n != 0 {
+		t.Error("unlock() left the lock behind")
+	}
+}
+
+func TestUpDoesNotRecordAfterLosingLock(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	runner := NewRunner(db, []Migration{{
+		ID:          "0001_slow",
+		Description: "Loses the lock while running",
+		Pending: func(ctx context.Context, db *mongo.Database) (int64, error) {
+			return 0, nil
+		},
+		Up: func(ctx context.Context, db *mongo.Database) (int64, error) {
+			_, err := db.Collection("schema_migrations").UpdateOne(ctx, bson.M{"_id": lockID}, bson.M{"$set": bson.M{"owner": "another instance"}})
+			return 0, err
+		},
+	}})
+
+	if _, err := runner.Up(ctx); !errors.Is(err, errLockLost) {
+		t.Fatalf("Up() = %v, want errLockLost", err)
+	}
+	if n, _ := db.Collection("schema_migrations").CountDocuments(ctx, bson.M{"_id": "0001_slow"}); n != 0 {
+		t.Error("the migration was recorded without the lock")
+	}
+	// The lock now belongs to the other instance and is left alone
+	if n, _ := db.Collection("schema_migrations").CountDocuments(ctx, bson.M{"_id": lockID, "owner": "another instance"}); n != 1 {
+		t.Error("Up released a lock it no longer held")
+	}
+}
+
+func TestLowercaseUserEmails(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	users := db.Collection("users")
+	if _, err := users.InsertMany(ctx, []any{
+		bson.M{"email": "Ada@Example.com"},
+		bson.M{"email": " bob@example.com "},
+		bson.M{"email": "carol@example.com"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := lowercaseUserEmails.Pending(ctx, db); err != nil || n != 2 {
+		t.Fatalf("Pending() = %d, %v, want 2", n, err)
+	}
+	if n, err := lowercaseUserEmails.Up(ctx, db); err != nil || n != 2 {
+		t.Fatalf("Up() = %d, %v, want 2", n, err)
+	}
+	if n, err := lowercaseUserEmails.Pending(ctx, db); err != nil || n != 0 {
+		t.Errorf("Pending() after Up = %d, %v, want 0", n, err)
+	}
+	if n, _ := users.CountDocuments(ctx, bson.M{"email": bson.M{"$in": bson.A{"ada@example.com", "bob@example.com"}}}); n != 2 {
+		t.Error("emails were not normalized")
+	}
+
+	// Accounts that would collide are left for a person to merge
+	if _, err := users.InsertOne(ctx, bson.M{"email": "ADA@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := lowercaseUserEmails.Up(ctx, db)
+	if !errors.Is(err, errConflict) || !strings.Contains(err.Error(), "1 emails") {
+		t.Errorf("Up() with a collision = %v, want a conflict", err)
+	}
+}
+
+func TestBackfillTaskStatus(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	tasks := db.Collection("tasks")
+	done := bson.M{"title": "a", "is_completed": true}
+	open := bson.M{"title": "b", "is_completed": false}
+	if _, err := tasks.InsertMany(ctx, []any{
+		bson.M{"_id": "half", "steps": bson.A{done, open}},
+		bson.M{"_id": "all", "steps": bson.A{done, done}},
+		bson.M{"_id": "none", "steps": bson.A{open}, "deadline": time.Now().Add(-time.Hour)},
+		bson.M{"_id": "current", "steps": bson.A{open}, "progress": 0, "status": "todo"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := backfillTaskStatus.Pending(ctx, db); err != nil || n != 3 {
+		t.Fatalf("Pending() = %d, %v, want 3", n, err)
+	}
+	if n, err := backfillTaskStatus.Up(ctx, db); err != nil || n != 3 {
+		t.Fatalf("Up() = %d, %v, want 3", n, err)
+	}
+	if n, err := backfillTaskStatus.Pending(ctx, db); err != nil || n != 0 {
+		t.Errorf("Pending() after Up = %d, %v, want 0", n, err)
+	}
+
+	for id, want := range map[string]struct {
+		progress int32
+		status   string
+	}{
+		"half":    {50, "in_progress"},
+		"all":     {100, "done"},
+		"none":    {0, "overdue"},
+		"current": {0, "todo"},
+	} {
+		var got struct {
+			Progress int32  `bson:"progress"`
+			Status   string `bson:"status"`
+		}
+		if err := tasks.FindOne(ctx, bson.M{"_id": id}).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Progress != want.progress || got.Status != want.status {
+			t.Errorf("task %s = %d%% %s, want %d%% %s", id, got.Progress, got.Status, want.progress, want.status)
+		}
+	}
+
+	// Completion times are left for 0004 to estimate
+	if n, err := tasks.CountDocuments(ctx, bson.M{"completed_at": bson.M{"$exists": true}}); err != nil || n != 0 {
+		t.Errorf("%d tasks got completed_at, %v, want none", n, err)
+	}
+}
+
+// TestLegacyTaskMigrations runs 0001 and then 0004 over tasks from before
+// either, as a fresh upgrade does
+func TestLegacyTaskMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	tasks := db.Collection("tasks")
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	stepDone := created.Add(26 * time.Hour)
+	untimed := primitive.NewObjectIDFromTimestamp(created)
+	timed := primitive.NewObjectIDFromTimestamp(created)
+	if _, err := tasks.InsertMany(ctx, []any{
+		bson.M{"_id": untimed, "steps": bson.A{bson.M{"title": "a", "is_completed": true}}},
+		bson.M{"_id": timed, "steps": bson.A{bson.M{"title": "b", "is_completed": true, "completed_at": stepDone}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []Migration{backfillTaskStatus, backfillTaskTimestamps} {
+		if _, err := m.Up(ctx, db); err != nil {
+			t.Fatalf("%s: %v", m.ID, err)
+		}
+	}
+
+	for id, want := range map[primitive.ObjectID]time.Time{untimed: created, timed: stepDone} {
+		var task struct {
+			Status      string     `bson:"status"`
+			CompletedAt *time.Time `bson:"completed_at"`
+		}
+		if err := tasks.FindOne(ctx, bson.M{"_id": id}).Decode(&task); err != nil {
+			t.Fatal(err)
+		}
+		if task.Status != "done" || task.CompletedAt == nil || !task.CompletedAt.Equal(want) {
+			t.Errorf("task %s is %s, completed %v, want done at the estimate %v", id.Hex(), task.Status, task.CompletedAt, want)
+		}
+	}
+}
+
+func TestBackfillStepIDs(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	tasks := db.Collection("tasks")
+	kept := primitive.NewObjectID()
+	if _, err := tasks.InsertMany(ctx, []any{
+		bson.M{"_id": "legacy", "steps": bson.A{
+			bson.M{"_id": kept, "title": "has an ID"},
+			bson.D{{Key: "title", Value: "no ID"}, {Key: "description", Value: "kept"}},
+		}},
+		bson.M{"_id": "current", "steps": bson.A{bson.M{"_id": primitive.NewObjectID(), "title": "fine"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := backfillStepIDs.Pending(ctx, db); err != nil || n != 1 {
+		t.Fatalf("Pending() = %d, %v, want 1", n, err)
+	}
+	if n, err := backfillStepIDs.Up(ctx, db); err != nil || n != 1 {
+		t.Fatalf("Up() = %d, %v, want 1", n, err)
+	}
+	if n, err := backfillStepIDs.Pending(ctx, db); err != nil || n != 0 {
+		t.Errorf("Pending() after Up = %d, %v, want 0", n, err)
+	}
+
+	var task struct {
+		Steps []struct {
+			ID          primitive.ObjectID `bson:"_id"`
+			Title       string             `bson:"title"`
+			Description string             `bson:"description"`
+		} `bson:"steps"`
+	}
+	if err := tasks.FindOne(ctx, bson.M{"_id": "legacy"}).Decode(&task); err != nil {
+		t.Fatal(err)
+	}
+	if len(task.Steps) != 2 || task.Steps[0].ID != kept || task.Steps[1].ID.IsZero() ||
+		task.Steps[1].Title != "no ID" || task.Steps[1].Description != "kept" {
+		t.Errorf("steps = %+v, want the existing ID kept and a new one added", task.Steps)
+	}
+}
+
+func TestBackfillTaskTimestamps(t *testing.T) {
+	ctx := context.Background()
+	db := testMongoDB(t)
+	tasks := db.Collection("tasks")
+	legacyID := primitive.NewObjectIDFromTimestamp(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	stepDone := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	stamped := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := tasks.InsertMany(ctx, []any{
+		bson.M{"_id": legacyID, "status": "done", "steps": bson.A{
+			bson.M{"title": "a", "is_completed": true, "completed_at": stepDone},
+			bson.M{"title": "b", "is_completed": true},
+		}},
+		bson.M{"_id": primitive.NewObjectID(), "status": "todo", "created_at": stamped, "updated_at": stamped,
+			"steps": bson.A{bson.M{"title": "c", "created_at": stamped, "updated_at": stamped}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := backfillTaskTimestamps.Pending(ctx, db); err != nil || n != 1 {
+		t.Fatalf("Pending() = %d, %v, want 1", n, err)
+	}
+	if n, err := backfillTaskTimestamps.Up(ctx, db); err != nil || n != 1 {
+		t.Fatalf("Up() = %d, %v, want 1", n, err)
+	}
+	if n, err := backfillTaskTimestamps.Pending(ctx, db); err != nil || n != 0 {
+		t.Errorf("Pending() after Up = %d, %v, want 0", n, err)
+	}
+
+	var task struct {
+		CreatedAt   time.Time  `bson:"created_at"`
+		UpdatedAt   time.Time  `bson:"updated_at"`
+		CompletedAt *time.Time `bson:"completed_at"`
+		Steps       []struct {
+			CreatedAt time.Time `bson:"created_at"`
+			UpdatedAt time.Time `bson:"updated_at"`
+		} `bson:"steps"`
+	}
+	if err := tasks.FindOne(ctx, bson.M{"_id": legacyID}).Decode(&task); err != nil {
+		t.Fatal(err)
+	}
+	created := legacyID.Timestamp()
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(stepDone) || task.CompletedAt == nil || !task.CompletedAt.Equal(stepDone) {
+		t.Errorf("task created %v, updated %v, completed %v", task.CreatedAt, task.UpdatedAt, task.CompletedAt)
+	}
+	if len(task.Steps) != 2 || !task.Steps[0].UpdatedAt.Equal(stepDone) || !task.Steps[1].UpdatedAt.Equal(created) || !task.Steps[1].CreatedAt.Equal(created) {
+		t.Errorf("steps = %+v", task.Steps)
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Purposes of an AccountToken
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// AccountToken records a single-use token emailed to a user. The token
+// itself is signed and carries this record's ID; UsedAt is set when it is
+// redeemed. Email is the address the token was sent to, and the token is
+// only good while the user still has that address.
+type AccountToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"user_id"`
+	Email     string             `bson:"email"`
+	Purpose   string             `bson:"purpose"`
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operations that call the AI step generator
+const (
+	OperationBreakdown  = "breakdown"
+	OperationCreateTask = "create_task"
+)
+
+// AIUsage records one call to the AI step generator, successful or not
+type AIUsage struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	UserID       string             `bson:"user_id"`
+	Operation    string             `bson:"operation"`
+	Provider     string             `bson:"provider"`
+	Model        string             `bson:"model"`
+	PromptTokens int                `bson:"prompt_tokens"`
+	OutputTokens int                `bson:"output_tokens"`
+	TotalTokens  int                `bson:"total_tokens"`
+	LatencyMS    int64              `bson:"latency_ms"`
+	Success      bool               `bson:"success"`
+	Error        string             `bson:"error,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at"`
+}
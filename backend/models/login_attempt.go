@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks the recent failed logins for one account. Failures
+// counts those since WindowStart, and LockedUntil is set once there were too
+// many.
+type LoginAttempt struct {
+	Key         string     `bson:"_id"`
+	Failures    int        `bson:"failures"`
+	WindowStart time.Time  `bson:"window_start"`
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
+	// ExpiresAt is when the record stops mattering and can be dropped
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification is an entry in a user's in-app inbox
+type Notification struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID    string              `json:"user_id" bson:"user_id"`
+	Kind      string              `json:"kind" bson:"kind"` // Kind is the event type, e.g. "reminder"
+	Title     string              `json:"title" bson:"title"`
+	Body      string              `json:"body" bson:"body"`
+	TaskID    *primitive.ObjectID `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	Read      bool                `json:"read" bson:"read"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	ReadAt    *time.Time          `json:"read_at,omitempty" bson:"read_at,omitempty"`
+}
+
+// Notification channels a user can opt in to
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// NotificationPreferences picks the channels a user is notified on
+type NotificationPreferences struct {
+	InApp      bool   `json:"in_app" bson:"in_app"`
+	Email      bool   `json:"email" bson:"email"`
+	Webhook    bool   `json:"webhook" bson:"webhook"`
+	WebhookURL string `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`
+}
+
+// DefaultNotificationPreferences applies to users who never saved any
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{InApp: true, Email: true}
+}
+
+// Enabled reports whether the given channel is switched on
+func (p NotificationPreferences) Enabled(channel string) bool {
+	switch channel {
+	case ChannelInApp:
+		return p.InApp
+	case ChannelEmail:
+		return p.Email
+	case ChannelWebhook:
+		return p.Webhook && p.WebhookURL != ""
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one login. It holds the hash of its current refresh token, which
+// is replaced on every refresh.
+type Session struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      string             `bson:"user_id"`
+	RefreshHash string             `bson:"refresh_hash"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	ExpiresAt   time.Time          `bson:"expires_at"`
+	RevokedAt   *time.Time         `bson:"revoked_at,omitempty"`
+}
@@ -11,12 +11,112 @@ type Step struct {
 	Title       string             `json:"title" bson:"title"`
 	Description string             `json:"description" bson:"description"`
 	IsCompleted bool               `json:"is_completed" bson:"is_completed"` // Completed indicates if the step is done
+	CompletedAt *time.Time         `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CompletedBy string             `json:"completed_by,omitempty" bson:"completed_by,omitempty"` // CompletedBy is the ID of the user who completed the step
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// TaskStatus is derived from step completion and the deadline
+type TaskStatus string
+
+const (
+	StatusTodo       TaskStatus = "todo"
+	StatusInProgress TaskStatus = "in_progress"
+	StatusDone       TaskStatus = "done"
+	StatusOverdue    TaskStatus = "overdue"
+)
+
+// Valid reports whether s is one of the known statuses
+func (s TaskStatus) Valid() bool {
+	switch s {
+	case StatusTodo, StatusInProgress, StatusDone, StatusOverdue:
+		return true
+	}
+	return false
 }
 
 type Task struct {
 	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Title    string             `json:"title" bson:"title"`
-	Deadline time.Time             `json:"deadline" bson:"deadline"`
-	Steps    []Step             `json:"steps" bson:"steps"` // Steps is an array of Step objects
-	UserID    string 			`json:"user_id" bson:"user_id"` // Owner is the ID of the user who created the task
+	Deadline time.Time          `json:"deadline" bson:"deadline"`
+	Steps    []Step             `json:"steps" bson:"steps"`       // Steps is an array of Step objects
+	UserID   string             `json:"user_id" bson:"user_id"`   // Owner is the ID of the user who created the task
+	Progress int                `json:"progress" bson:"progress"` // Progress is the percentage of completed steps
+	Status   TaskStatus         `json:"status" bson:"status"`
+	// UpdatedAt is the last change made through the API; the scheduler
+	// flagging a task overdue does not count. CompletedAt is when the last
+	// step was completed, set only while the task is done. Tasks whose steps
+	// were completed before completion times were recorded have none until
+	// migration 0004 estimates it.
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// Touch records a change at now. Steps without a CreatedAt are new and get
+// now as both their creation and modification time. UpdatedAt always moves
+// forward, by a millisecond if the clock has not, so it doubles as a version.
+func (t *Task) Touch(now time.Time) {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	if now.After(t.UpdatedAt) {
+		t.UpdatedAt = now
+	} else {
+		t.UpdatedAt = t.UpdatedAt.Add(time.Millisecond)
+	}
+	StampNewSteps(t.Steps, now)
+}
+
+// StampNewSteps sets CreatedAt and UpdatedAt of the steps that have no
+// CreatedAt yet
+func StampNewSteps(steps []Step, now time.Time) {
+	for i := range steps {
+		if steps[i].CreatedAt.IsZero() {
+			steps[i].CreatedAt = now
+			steps[i].UpdatedAt = now
+		}
+	}
+}
+
+// Refresh recomputes Progress, Status and CompletedAt from the steps and
+// deadline. The Mongo repository mirrors these rules in an update pipeline.
+func (t *Task) Refresh(now time.Time) {
+	completed := 0
+	var lastCompleted *time.Time
+	for _, step := range t.Steps {
+		if step.IsCompleted {
+			completed++
+		}
+		if step.CompletedAt != nil && (lastCompleted == nil || step.CompletedAt.After(*lastCompleted)) {
+			lastCompleted = step.CompletedAt
+		}
+	}
+
+	t.Progress = 0
+	if len(t.Steps) > 0 {
+		t.Progress = (completed * 100) / len(t.Steps)
+	}
+
+	switch {
+	case len(t.Steps) > 0 && completed == len(t.Steps):
+		t.Status = StatusDone
+	case t.Deadline.Before(now):
+		t.Status = StatusOverdue
+	case completed > 0:
+		t.Status = StatusInProgress
+	default:
+		t.Status = StatusTodo
+	}
+
+	// A done task without step completion times keeps whatever it has, so
+	// the read of a legacy task does not pass off now as its completion time
+	switch {
+	case t.Status != StatusDone:
+		t.CompletedAt = nil
+	case lastCompleted != nil:
+		at := *lastCompleted
+		t.CompletedAt = &at
+	}
 }
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskRefresh(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	done := Step{IsCompleted: true}
+	open := Step{}
+
+	tests := []struct {
+		name         string
+		task         Task
+		wantProgress int
+		wantStatus   TaskStatus
+	}{
+		{"no steps", Task{Deadline: tomorrow}, 0, StatusTodo},
+		{"no steps past deadline", Task{Deadline: yesterday}, 0, StatusOverdue},
+		{"nothing completed", Task{Deadline: tomorrow, Steps: []Step{open, open}}, 0, StatusTodo},
+		{"partly completed", Task{Deadline: tomorrow, Steps: []Step{done, open, open}}, 33, StatusInProgress},
+		{"partly completed past deadline", Task{Deadline: yesterday, Steps: []Step{done, open}}, 50, StatusOverdue},
+		{"all completed", Task{Deadline: tomorrow, Steps: []Step{done, done}}, 100, StatusDone},
+		{"done wins over the deadline", Task{Deadline: yesterday, Steps: []Step{done}}, 100, StatusDone},
+		{"deadline now is not overdue", Task{Deadline: now, Steps: []Step{open}}, 0, StatusTodo},
+		{"stale values are replaced", Task{Deadline: tomorrow, Steps: []Step{done, open}, Progress: 100, Status: StatusDone}, 50, StatusInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			task.Refresh(now)
+			if task.Progress != tt.wantProgress || task.Status != tt.wantStatus {
+				t.Errorf("progress, status = %d, %s, want %d, %s", task.Progress, task.Status, tt.wantProgress, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTaskStatusValid(t *testing.T) {
+	for _, s := range []TaskStatus{StatusTodo, StatusInProgress, StatusDone, StatusOverdue} {
+		if !s.Valid() {
+			t.Errorf("%s is not valid", s)
+		}
+	}
+	for _, s := range []TaskStatus{"", "finished", "TODO"} {
+		if s.Valid() {
+			t.Errorf("%q is valid", s)
+		}
+	}
+}
+
+func TestTaskTouch(t *testing.T) {
+	created := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := created.Add(time.Hour)
+	task := Task{
+		CreatedAt: created,
+		Steps:     []Step{{Title: "old", CreatedAt: created, UpdatedAt: created}, {Title: "new"}},
+	}
+	task.Touch(now)
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(now) {
+		t.Errorf("task created %v, updated %v", task.CreatedAt, task.UpdatedAt)
+	}
+	if old := task.Steps[0]; !old.CreatedAt.Equal(created) || !old.UpdatedAt.Equal(created) {
+		t.Errorf("existing step = %+v, want it unchanged", old)
+	}
+	if step := task.Steps[1]; !step.CreatedAt.Equal(now) || !step.UpdatedAt.Equal(now) {
+		t.Errorf("new step = %+v, want it stamped at %v", step, now)
+	}
+
+	var fresh Task
+	fresh.Touch(now)
+	if !fresh.CreatedAt.Equal(now) || !fresh.UpdatedAt.Equal(now) {
+		t.Errorf("new task created %v, updated %v", fresh.CreatedAt, fresh.UpdatedAt)
+	}
+
+	// A clock that has not moved, or moved back, still advances UpdatedAt
+	for _, at := range []time.Time{now, created} {
+		before := task.UpdatedAt
+		task.Touch(at)
+		if want := before.Add(time.Millisecond); !task.UpdatedAt.Equal(want) {
+			t.Errorf("Touch(%v) after %v set UpdatedAt %v, want %v", at, before, task.UpdatedAt, want)
+		}
+	}
+}
+
+func TestTaskRefreshCompletedAt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	first, last := now.Add(-2*time.Hour), now.Add(-time.Hour)
+
+	task := Task{Steps: []Step{
+		{IsCompleted: true, CompletedAt: &last},
+		{IsCompleted: true, CompletedAt: &first},
+	}}
+	task.Refresh(now)
+	if task.CompletedAt == nil || !task.CompletedAt.Equal(last) {
+		t.Errorf("done task completed at %v, want the last step's %v", task.CompletedAt, last)
+	}
+	if task.CompletedAt == task.Steps[0].CompletedAt {
+		t.Error("CompletedAt aliases a step's time")
+	}
+
+	task.Steps = append(task.Steps, Step{})
+	task.Refresh(now)
+	if task.CompletedAt != nil {
+		t.Errorf("reopened task completed at %v, want nil", task.CompletedAt)
+	}
+
+	// Steps completed before completion times were recorded give no time,
+	// and now is not a stand-in for one
+	legacy := Task{Steps: []Step{{IsCompleted: true}}}
+	legacy.Refresh(now)
+	if legacy.Status != StatusDone || legacy.CompletedAt != nil {
+		t.Errorf("legacy task is %s, completed %v, want done without a time", legacy.Status, legacy.CompletedAt)
+	}
+	legacy.CompletedAt = &first
+	legacy.Refresh(now)
+	if legacy.CompletedAt == nil || !legacy.CompletedAt.Equal(first) {
+		t.Errorf("legacy task completed %v, want its estimate %v kept", legacy.CompletedAt, first)
+	}
+}
@@ -1,12 +1,42 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// User is the stored form of an account. It is not meant for API responses,
+// which use a view without the password hash; the hash is also excluded from
+// JSON here in case a User is ever serialized by mistake.
 type User struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name     string             `bson:"name" json:"name"`
-	Email    string             `bson:"email" json:"email"`
-	Password string             `bson:"password,omitempty" json:"password"`
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	Name         string             `bson:"name"`
+	Email        string             `bson:"email"`
+	PasswordHash string             `bson:"password,omitempty" json:"-"`
+
+	EmailVerifiedAt *time.Time `bson:"email_verified_at,omitempty"`
+
+	NotificationPreferences *NotificationPreferences `bson:"notification_preferences,omitempty"`
+}
+
+// Preferences returns the user's notification preferences, or the defaults
+// if they never saved any
+func (u *User) Preferences() NotificationPreferences {
+	if u.NotificationPreferences == nil {
+		return DefaultNotificationPreferences()
+	}
+	return *u.NotificationPreferences
+}
+
+// EmailVerified reports whether the user confirmed their email address
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// NormalizeEmail canonicalizes an address for storage and lookup. Emails are
+// stored lowercase so the unique index on users.email is case-insensitive.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
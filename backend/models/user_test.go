@@ -0,0 +1,15 @@
+package models
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	for in, want := range map[string]string{
+		"ada@example.com":      "ada@example.com",
+		"Ada@Example.COM":      "ada@example.com",
+		"  ada@example.com \n": "ada@example.com",
+	} {
+		if got := NormalizeEmail(in); got != want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
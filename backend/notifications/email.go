@@ -0,0 +1,25 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/Vanaraj10/taskmorph-backend/mailer"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// EmailNotifier emails messages to the user's account address
+type EmailNotifier struct {
+	mailer mailer.Mailer
+}
+
+func NewEmailNotifier(m mailer.Mailer) *EmailNotifier {
+	return &EmailNotifier{mailer: m}
+}
+
+func (n *EmailNotifier) Channel() string {
+	return models.ChannelEmail
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, user models.User, msg Message) error {
+	return n.mailer.Send(ctx, user.Email, msg.Title, msg.Body)
+}
@@ -0,0 +1,180 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/mailer"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// smtpStub is a minimal in-process SMTP server that records one message
+type smtpStub struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu   sync.Mutex
+	from string
+	to   []string
+	data string
+}
+
+func startSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{listener: l}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		l.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) serve() {
+	defer s.wg.Done()
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			reply("250 stub")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = line[len("MAIL FROM:"):]
+			s.mu.Unlock()
+			reply("250 ok")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			s.mu.Lock()
+			s.to = append(s.to, line[len("RCPT TO:"):])
+			s.mu.Unlock()
+			reply("250 ok")
+		case verb == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case verb == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestEmailNotifierDeliversOverSMTP(t *testing.T) {
+	stub := startSMTPStub(t)
+	m := mailer.NewSMTPMailer(mailer.Config{
+		Host:    "127.0.0.1",
+		Port:    stub.port(),
+		From:    "TaskMorph <noreply@taskmorph.test>",
+		Timeout: 5 * time.Second,
+	})
+	notifier := NewEmailNotifier(m)
+
+	user := models.User{Email: "ada@example.com"}
+	msg := Message{Kind: KindReminder, Title: "Deadline tomorrow", Body: "Ship it\nby noon"}
+	if err := notifier.Notify(context.Background(), user, msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	stub.listener.Close()
+	stub.wg.Wait()
+
+	if stub.from != "<noreply@taskmorph.test>" {
+		t.Errorf("MAIL FROM = %q", stub.from)
+	}
+	if len(stub.to) != 1 || stub.to[0] != "<ada@example.com>" {
+		t.Errorf("RCPT TO = %q", stub.to)
+	}
+	for _, want := range []string{
+		"From: \"TaskMorph\" <noreply@taskmorph.test>\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: Deadline tomorrow\r\n",
+		"Content-Type: text/plain; charset=UTF-8\r\n",
+		"\r\n\r\nShip it\r\nby noon\r\n",
+	} {
+		if !strings.Contains(stub.data, want) {
+			t.Errorf("message is missing %q:\n%s", want, stub.data)
+		}
+	}
+}
+
+func TestEmailNotifierReportsRejectedRecipient(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("220 stub ready\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "MAIL"):
+				conn.Write([]byte("250 ok\r\n"))
+			case strings.HasPrefix(line, "RCPT"):
+				conn.Write([]byte("550 no such user\r\n"))
+			default:
+				conn.Write([]byte("221 bye\r\n"))
+				return
+			}
+		}
+	}()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	m := mailer.NewSMTPMailer(mailer.Config{Host: "127.0.0.1", Port: port, From: "noreply@taskmorph.test", Timeout: 5 * time.Second})
+	err = NewEmailNotifier(m).Notify(context.Background(), models.User{Email: "nobody@example.com"}, Message{Title: "t", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), strconv.Itoa(550)) {
+		t.Fatalf("Notify error = %v, want the 550 rejection", err)
+	}
+}
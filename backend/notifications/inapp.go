@@ -0,0 +1,33 @@
+package notifications
+
+import (
+	"context"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+// InAppNotifier stores messages in the user's inbox, served by /notifications
+type InAppNotifier struct {
+	inbox repository.NotificationRepository
+}
+
+func NewInAppNotifier(inbox repository.NotificationRepository) *InAppNotifier {
+	return &InAppNotifier{inbox: inbox}
+}
+
+func (n *InAppNotifier) Channel() string {
+	return models.ChannelInApp
+}
+
+func (n *InAppNotifier) Notify(ctx context.Context, user models.User, msg Message) error {
+	return n.inbox.Create(ctx, &models.Notification{
+		UserID:    user.ID.Hex(),
+		Kind:      msg.Kind,
+		Title:     msg.Title,
+		Body:      msg.Body,
+		TaskID:    msg.TaskID,
+		CreatedAt: time.Now(),
+	})
+}
@@ -0,0 +1,139 @@
+// Package notifications delivers events such as deadline reminders to users
+// over the channels they opted in to: the in-app inbox, email and webhooks.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/mailer"
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/Vanaraj10/taskmorph-backend/scheduler"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message is a channel-independent notification
+type Message struct {
+	Kind   string
+	Title  string
+	Body   string
+	TaskID *primitive.ObjectID
+}
+
+// KindReminder is the Kind of deadline reminder messages
+const KindReminder = "reminder"
+
+// Notifier delivers a message to a user over one channel
+type Notifier interface {
+	// Channel is one of the models.Channel* constants
+	Channel() string
+	Notify(ctx context.Context, user models.User, msg Message) error
+}
+
+// Config configures the webhook channel
+type Config struct {
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+}
+
+// NewNotifiers builds every channel. Email goes through m, which only logs
+// when SMTP is not configured.
+func NewNotifiers(cfg Config, inbox repository.NotificationRepository, m mailer.Mailer) []Notifier {
+	return []Notifier{
+		NewInAppNotifier(inbox),
+		NewEmailNotifier(m),
+		NewWebhookNotifier(cfg.WebhookSecret, cfg.WebhookTimeout),
+	}
+}
+
+// Dispatcher fans a message out to the channels the user enabled
+type Dispatcher struct {
+	users     repository.UserRepository
+	notifiers []Notifier
+}
+
+func NewDispatcher(users repository.UserRepository, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{users: users, notifiers: notifiers}
+}
+
+// Notify delivers msg on every enabled channel, leaving out email until the
+// user has verified their address. A failing channel does not
+// stop the others; an error is returned only if no channel succeeded, so a
+// retry never duplicates a message that already went out.
+func (d *Dispatcher) Notify(ctx context.Context, userID string, msg Message) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q", userID)
+	}
+	user, err := d.users.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("load user %s: %w", userID, err)
+	}
+
+	prefs := user.Preferences()
+	var errs []error
+	attempted := 0
+	for _, notifier := range d.notifiers {
+		if !prefs.Enabled(notifier.Channel()) {
+			continue
+		}
+		// Nobody has shown the address is theirs, so it may be a stranger's
+		if notifier.Channel() == models.ChannelEmail && !user.EmailVerified() {
+			continue
+		}
+		attempted++
+		if err := notifier.Notify(ctx, *user, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", notifier.Channel(), err))
+		}
+	}
+
+	if attempted > 0 && len(errs) == attempted {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.WarnContext(ctx, "Notification partly failed", "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+// Send makes the Dispatcher a scheduler.ReminderSink
+func (d *Dispatcher) Send(ctx context.Context, r scheduler.Reminder) error {
+	return d.Notify(ctx, r.UserID, reminderMessage(r))
+}
+
+func reminderMessage(r scheduler.Reminder) Message {
+	taskID := r.TaskID
+	due := r.Deadline.Format("Jan 2, 2006")
+
+	msg := Message{Kind: KindReminder, TaskID: &taskID}
+	if r.Threshold == scheduler.ThresholdOverdue {
+		msg.Title = fmt.Sprintf("%q is overdue", r.TaskTitle)
+		msg.Body = fmt.Sprintf("Your task %q was due on %s and still has %d incomplete step(s).",
+			r.TaskTitle, due, r.IncompleteSteps)
+		return msg
+	}
+
+	msg.Title = fmt.Sprintf("%q is due soon", r.TaskTitle)
+	msg.Body = fmt.Sprintf("Your task %q is due on %s, within %s, and still has %d incomplete step(s).",
+		r.TaskTitle, due, formatLead(r.Threshold), r.IncompleteSteps)
+	return msg
+}
+
+// formatLead turns a threshold such as "24h0m0s" into "24h"
+func formatLead(threshold string) string {
+	lead, err := time.ParseDuration(threshold)
+	if err != nil {
+		return threshold
+	}
+	switch {
+	case lead%time.Hour == 0:
+		return fmt.Sprintf("%dh", lead/time.Hour)
+	case lead%time.Minute == 0:
+		return fmt.Sprintf("%dm", lead/time.Minute)
+	}
+	return lead.String()
+}
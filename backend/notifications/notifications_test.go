@@ -0,0 +1,192 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/Vanaraj10/taskmorph-backend/scheduler"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeNotifier records deliveries and fails with err if set
+type fakeNotifier struct {
+	channel string
+	err     error
+	sent    []Message
+}
+
+func (n *fakeNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, user models.User, msg Message) error {
+	n.sent = append(n.sent, msg)
+	return n.err
+}
+
+func addUser(t *testing.T, users repository.UserRepository, prefs *models.NotificationPreferences) *models.User {
+	t.Helper()
+	verified := time.Now()
+	user := &models.User{Email: "ada@example.com", EmailVerifiedAt: &verified, NotificationPreferences: prefs}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func newTestDispatcher(t *testing.T, prefs *models.NotificationPreferences) (*Dispatcher, string, map[string]*fakeNotifier) {
+	t.Helper()
+	users := repository.NewMemoryUserRepository()
+	user := addUser(t, users, prefs)
+	fakes := map[string]*fakeNotifier{
+		models.ChannelInApp:   {channel: models.ChannelInApp},
+		models.ChannelEmail:   {channel: models.ChannelEmail},
+		models.ChannelWebhook: {channel: models.ChannelWebhook},
+	}
+	d := NewDispatcher(users, fakes[models.ChannelInApp], fakes[models.ChannelEmail], fakes[models.ChannelWebhook])
+	return d, user.ID.Hex(), fakes
+}
+
+func TestDispatcherUsesEnabledChannels(t *testing.T) {
+	d, userID, fakes := newTestDispatcher(t, nil)
+	if err := d.Notify(context.Background(), userID, Message{Title: "Hi"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	// The defaults are the inbox and email
+	for channel, want := range map[string]int{models.ChannelInApp: 1, models.ChannelEmail: 1, models.ChannelWebhook: 0} {
+		if got := len(fakes[channel].sent); got != want {
+			t.Errorf("%s got %d messages, want %d", channel, got, want)
+		}
+	}
+
+	d, userID, fakes = newTestDispatcher(t, &models.NotificationPreferences{Webhook: true, WebhookURL: "https://93.184.216.34/hook"})
+	if err := d.Notify(context.Background(), userID, Message{Title: "Hi"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	for channel, want := range map[string]int{models.ChannelInApp: 0, models.ChannelEmail: 0, models.ChannelWebhook: 1} {
+		if got := len(fakes[channel].sent); got != want {
+			t.Errorf("%s got %d messages, want %d", channel, got, want)
+		}
+	}
+}
+
+func TestDispatcherSkipsUnverifiedEmail(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &models.User{Email: "ada@example.com"}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	inbox := &fakeNotifier{channel: models.ChannelInApp}
+	email := &fakeNotifier{channel: models.ChannelEmail}
+	if err := NewDispatcher(users, inbox, email).Notify(context.Background(), user.ID.Hex(), Message{Title: "Hi"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(inbox.sent) != 1 || len(email.sent) != 0 {
+		t.Errorf("inbox got %d, email got %d messages; want 1 and 0 for an unverified address", len(inbox.sent), len(email.sent))
+	}
+}
+
+func TestDispatcherFailures(t *testing.T) {
+	d, userID, fakes := newTestDispatcher(t, nil)
+	fakes[models.ChannelEmail].err = errors.New("smtp down")
+	if err := d.Notify(context.Background(), userID, Message{}); err != nil {
+		t.Errorf("one of two channels failing: %v, want nil so the reminder is not resent", err)
+	}
+
+	fakes[models.ChannelInApp].err = errors.New("inbox down")
+	err := d.Notify(context.Background(), userID, Message{})
+	if err == nil || !strings.Contains(err.Error(), "smtp down") || !strings.Contains(err.Error(), "inbox down") {
+		t.Errorf("every channel failing: %v, want both errors", err)
+	}
+
+	if err := d.Notify(context.Background(), primitive.NewObjectID().Hex(), Message{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown user: %v, want ErrNotFound", err)
+	}
+	if err := d.Notify(context.Background(), "not-an-id", Message{}); err == nil {
+		t.Error("invalid user ID accepted")
+	}
+}
+
+// TestScheduledRemindersReachTheInbox runs the reminder job twice through
+// the dispatcher and expects exactly one reminder and one inbox entry
+func TestScheduledRemindersReachTheInbox(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	user := addUser(t, users, &models.NotificationPreferences{InApp: true})
+	tasks := repository.NewMemoryTaskRepository()
+	inbox := repository.NewMemoryNotificationRepository()
+
+	now := time.Now()
+	task := models.Task{UserID: user.ID.Hex(), Title: "Taxes", Deadline: now.Add(30 * time.Minute), Steps: []models.Step{{ID: primitive.NewObjectID(), Title: "a"}}}
+	if err := tasks.Create(ctx, &task); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := scheduler.New(scheduler.DefaultConfig(), tasks, repository.NewMemoryReminderRepository(), NewDispatcher(users, NewInAppNotifier(inbox)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := 0
+	for run := 1; run <= 2; run++ {
+		n, err := jobs.RunReminders(ctx, now)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		sent += n
+	}
+	if sent != 1 {
+		t.Errorf("sent %d reminders over two runs, want 1", sent)
+	}
+
+	list, err := inbox.ListByUser(ctx, user.ID.Hex(), false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Kind != KindReminder || list[0].TaskID == nil || *list[0].TaskID != task.ID {
+		t.Errorf("inbox = %+v, want one reminder for the task", list)
+	}
+}
+
+func TestReminderMessage(t *testing.T) {
+	reminder := scheduler.Reminder{
+		UserID:          primitive.NewObjectID().Hex(),
+		TaskID:          primitive.NewObjectID(),
+		TaskTitle:       "Taxes",
+		Deadline:        time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC),
+		Threshold:       (24 * time.Hour).String(),
+		IncompleteSteps: 2,
+	}
+	msg := reminderMessage(reminder)
+	if msg.Kind != KindReminder || msg.TaskID == nil || *msg.TaskID != reminder.TaskID {
+		t.Errorf("message = %+v", msg)
+	}
+	if msg.Title != `"Taxes" is due soon` || msg.Body != `Your task "Taxes" is due on Apr 15, 2025, within 24h, and still has 2 incomplete step(s).` {
+		t.Errorf("due soon message = %q / %q", msg.Title, msg.Body)
+	}
+
+	reminder.Threshold = scheduler.ThresholdOverdue
+	msg = reminderMessage(reminder)
+	if msg.Title != `"Taxes" is overdue` || !strings.Contains(msg.Body, "was due on Apr 15, 2025") {
+		t.Errorf("overdue message = %q / %q", msg.Title, msg.Body)
+	}
+}
+
+func TestFormatLead(t *testing.T) {
+	tests := map[string]string{
+		(72 * time.Hour).String():            "72h",
+		(30 * time.Minute).String():          "30m",
+		(90 * time.Second).String():          "1m30s",
+		scheduler.ThresholdOverdue:           scheduler.ThresholdOverdue,
+		(2*time.Hour + time.Minute).String(): "121m",
+	}
+	for threshold, want := range tests {
+		if got := formatLead(threshold); got != want {
+			t.Errorf("formatLead(%q) = %q, want %q", threshold, got, want)
+		}
+	}
+}
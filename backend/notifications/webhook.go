@@ -0,0 +1,153 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body when a webhook
+// secret is configured, formatted as "sha256=<hex>"
+const SignatureHeader = "X-TaskMorph-Signature"
+
+var (
+	ErrWebhookURLInvalid    = errors.New("webhook_url must be an http or https URL")
+	ErrWebhookURLUnresolved = errors.New("webhook_url host cannot be resolved")
+	ErrWebhookURLPrivate    = errors.New("webhook_url must not point to a loopback, private or link-local address")
+)
+
+// ValidateWebhookURL accepts http and https URLs whose host resolves only to
+// public addresses, so users cannot aim the server at internal services.
+// Delivery checks the address again when connecting, since DNS can change.
+func ValidateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrWebhookURLInvalid
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrWebhookURLUnresolved
+	}
+	for _, addr := range addrs {
+		if blockedIP(addr.IP) {
+			return ErrWebhookURLPrivate
+		}
+	}
+	return nil
+}
+
+// blockedIP reports whether ip is an address webhooks must never reach
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// publicOnly is a net.Dialer Control hook that refuses blocked addresses. It
+// sees the address actually dialled, after DNS resolution.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs messages as JSON to the URL in the user's preferences
+type WebhookNotifier struct {
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhookNotifier(secret string, timeout time.Duration) *WebhookNotifier {
+	dialer := &net.Dialer{Timeout: timeout, Control: publicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would dial on our behalf, out of reach of the Control hook
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &WebhookNotifier{
+		secret: []byte(secret),
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect could lead anywhere, so the first response is final
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return models.ChannelWebhook
+}
+
+type webhookPayload struct {
+	Event  string    `json:"event"`
+	UserID string    `json:"user_id"`
+	TaskID string    `json:"task_id,omitempty"`
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, user models.User, msg Message) error {
+	url := user.Preferences().WebhookURL
+	if url == "" {
+		return fmt.Errorf("no webhook URL configured")
+	}
+
+	payload := webhookPayload{
+		Event:  msg.Kind,
+		UserID: user.ID.Hex(),
+		Title:  msg.Title,
+		Body:   msg.Body,
+		SentAt: time.Now().UTC(),
+	}
+	if msg.TaskID != nil {
+		payload.TaskID = msg.TaskID.Hex()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+func webhookUser(url string) models.User {
+	return models.User{NotificationPreferences: &models.NotificationPreferences{Webhook: true, WebhookURL: url}}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://93.184.216.34/hook", nil},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", nil},
+		{"ftp://93.184.216.34/hook", ErrWebhookURLInvalid},
+		{"https:///hook", ErrWebhookURLInvalid},
+		{"http://127.0.0.1/hook", ErrWebhookURLPrivate},
+		{"http://[::1]/hook", ErrWebhookURLPrivate},
+		{"http://0.0.0.0/hook", ErrWebhookURLPrivate},
+		{"http://10.1.2.3/hook", ErrWebhookURLPrivate},
+		{"http://172.16.0.1/hook", ErrWebhookURLPrivate},
+		{"http://192.168.1.1/hook", ErrWebhookURLPrivate},
+		{"http://169.254.169.254/latest/meta-data", ErrWebhookURLPrivate},
+		{"http://[fe80::1]/hook", ErrWebhookURLPrivate},
+		{"http://[fd00::1]/hook", ErrWebhookURLPrivate},
+		{"http://[::ffff:127.0.0.1]/hook", ErrWebhookURLPrivate},
+		{"http://localhost/hook", ErrWebhookURLPrivate},
+	}
+	for _, tt := range tests {
+		if err := ValidateWebhookURL(context.Background(), tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+}
+
+func TestWebhookNotifierRefusesLocalAddresses(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+
+	err := NewWebhookNotifier("", time.Second).Notify(context.Background(), webhookUser(srv.URL), Message{Kind: KindReminder})
+	if err == nil {
+		t.Fatal("Notify delivered to a loopback address")
+	}
+	if hit {
+		t.Fatal("the loopback server received a request")
+	}
+}
+
+func TestWebhookNotifierSignsAndDoesNotFollowRedirects(t *testing.T) {
+	var body []byte
+	var signature string
+	redirected := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected = true
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier("secret", time.Second)
+	// Allow the loopback test server while keeping the redirect policy
+	n.client.Transport = http.DefaultTransport
+
+	err := n.Notify(context.Background(), webhookUser(srv.URL), Message{Kind: KindReminder, Title: "Due", Body: "Soon"})
+	if err == nil {
+		t.Fatal("Notify accepted a redirect as success")
+	}
+	if redirected {
+		t.Fatal("Notify followed the redirect")
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.Event != KindReminder || payload.Title != "Due" || payload.Body != "Soon" {
+		t.Errorf("payload = %+v", payload)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+}
@@ -0,0 +1,136 @@
+// Package ratelimit throttles requests with token buckets kept in a
+// repository.RateLimitRepository, in memory or shared through MongoDB.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+// Rule allows Limit requests per Period, with bursts of up to Limit. The
+// zero Rule allows everything.
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRule parses "<limit>/<period>" such as "20/m", "100/h" or "5/10s".
+// "" and "off" give the zero Rule.
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Rule{}, nil
+	}
+	limit, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("rate limit %q must look like 20/m", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q needs a positive request count", s)
+	}
+	if period == "s" || period == "m" || period == "h" {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q needs a period like s, m, h or 10m", s)
+	}
+	return Rule{Limit: n, Period: d}, nil
+}
+
+// Enabled reports whether the rule limits anything
+func (r Rule) Enabled() bool {
+	return r.Limit > 0
+}
+
+// rate is the refill rate in tokens per second
+func (r Rule) rate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// Policy is the limit for one route group, applied per client IP and, on
+// authenticated routes, per user
+type Policy struct {
+	PerIP   Rule
+	PerUser Rule
+}
+
+// Config holds the policy of every route group
+type Config struct {
+	// Auth covers /auth, which is mostly unauthenticated
+	Auth Policy
+	// AI covers the routes that call the step generator
+	AI Policy
+	// API covers the remaining task and notification routes
+	API Policy
+	// Store is "memory" (default) or "mongo" to share limits across instances
+	Store string
+}
+
+// Decision is the outcome of one Allow call
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed
+	RetryAfter time.Duration
+}
+
+// Limiter checks requests against rules using the bucket store
+type Limiter struct {
+	buckets repository.RateLimitRepository
+}
+
+func NewLimiter(buckets repository.RateLimitRepository) *Limiter {
+	return &Limiter{buckets: buckets}
+}
+
+// Allow takes a token from key's bucket for the rule. Disabled rules always
+// allow.
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule, now time.Time) (Decision, error) {
+	if !rule.Enabled() {
+		return Decision{Allowed: true}, nil
+	}
+	allowed, tokens, err := l.buckets.Take(ctx, key, rule.rate(), rule.Limit, now)
+	if err != nil {
+		return Decision{}, err
+	}
+	return newDecision(rule, allowed, tokens), nil
+}
+
+// Check reports what Allow would decide without taking a token, so a request
+// can be checked against several buckets before drawing from any of them
+func (l *Limiter) Check(ctx context.Context, key string, rule Rule, now time.Time) (Decision, error) {
+	if !rule.Enabled() {
+		return Decision{Allowed: true}, nil
+	}
+	tokens, err := l.buckets.Peek(ctx, key, rule.rate(), rule.Limit, now)
+	if err != nil {
+		return Decision{}, err
+	}
+	allowed := tokens >= 1
+	if allowed {
+		// Remaining counts what is left after this request
+		tokens--
+	}
+	return newDecision(rule, allowed, tokens), nil
+}
+
+func newDecision(rule Rule, allowed bool, tokens float64) Decision {
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     rule.Limit,
+		Remaining: int(math.Floor(tokens)),
+	}
+	if !allowed {
+		decision.RetryAfter = time.Duration((1 - tokens) / rule.rate() * float64(time.Second))
+	}
+	return decision
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rule
+	}{
+		{"", Rule{}},
+		{"off", Rule{}},
+		{" 20/m ", Rule{Limit: 20, Period: time.Minute}},
+		{"100/h", Rule{Limit: 100, Period: time.Hour}},
+		{"1/s", Rule{Limit: 1, Period: time.Second}},
+		{"5/10s", Rule{Limit: 5, Period: 10 * time.Second}},
+		{"30/15m", Rule{Limit: 30, Period: 15 * time.Minute}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"20", "20/", "/m", "0/m", "-1/m", "many/m", "20/d", "20/0s", "20/-1m"} {
+		if got, err := ParseRule(in); err == nil {
+			t.Errorf("ParseRule(%q) = %+v, want an error", in, got)
+		}
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(repository.NewMemoryRateLimitRepository())
+	rule := Rule{Limit: 3, Period: time.Minute}
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		decision, err := limiter.Allow(ctx, "a", rule, now)
+		if err != nil || !decision.Allowed || decision.Limit != 3 || decision.Remaining != 2-i {
+			t.Fatalf("request %d = %+v, %v, want allowed with %d remaining", i+1, decision, err, 2-i)
+		}
+	}
+	decision, err := limiter.Allow(ctx, "a", rule, now)
+	if err != nil || decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("request over the burst = %+v, %v, want denied", decision, err)
+	}
+	// One token comes back every 20 seconds
+	if decision.RetryAfter != 20*time.Second {
+		t.Errorf("retry after %v, want 20s", decision.RetryAfter)
+	}
+
+	if decision, _ := limiter.Allow(ctx, "b", rule, now); !decision.Allowed {
+		t.Error("another key shares the exhausted bucket")
+	}
+	if decision, _ := limiter.Allow(ctx, "a", rule, now.Add(10*time.Second)); decision.Allowed || decision.RetryAfter != 10*time.Second {
+		t.Errorf("half a refill later = %+v, want denied for another 10s", decision)
+	}
+	if decision, _ := limiter.Allow(ctx, "a", rule, now.Add(20*time.Second)); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("a refill later = %+v, want allowed with 0 remaining", decision)
+	}
+	// The bucket never holds more than the burst
+	if decision, _ := limiter.Allow(ctx, "a", rule, now.Add(time.Hour)); !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("an hour later = %+v, want allowed with 2 remaining", decision)
+	}
+}
+
+func TestLimiterDisabledRule(t *testing.T) {
+	limiter := NewLimiter(repository.NewMemoryRateLimitRepository())
+	for i := 0; i < 100; i++ {
+		decision, err := limiter.Allow(context.Background(), "a", Rule{}, time.Now())
+		if err != nil || !decision.Allowed {
+			t.Fatalf("the zero rule denied request %d: %+v, %v", i+1, decision, err)
+		}
+	}
+}
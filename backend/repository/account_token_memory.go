@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAccountTokenRepository keeps tokens in process memory
+type MemoryAccountTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[primitive.ObjectID]models.AccountToken
+}
+
+func NewMemoryAccountTokenRepository() *MemoryAccountTokenRepository {
+	return &MemoryAccountTokenRepository{tokens: make(map[primitive.ObjectID]models.AccountToken)}
+}
+
+func (r *MemoryAccountTokenRepository) Create(ctx context.Context, token *models.AccountToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *MemoryAccountTokenRepository) Consume(ctx context.Context, id primitive.ObjectID, purpose string, now time.Time) (*models.AccountToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	token.UsedAt = &now
+	r.tokens[id] = token
+	return &token, nil
+}
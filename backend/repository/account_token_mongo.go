@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAccountTokenRepository stores tokens in the "account_tokens"
+// collection. A TTL index drops them once they expire.
+type MongoAccountTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAccountTokenRepository(db *mongo.Database) *MongoAccountTokenRepository {
+	return &MongoAccountTokenRepository{collection: db.Collection("account_tokens")}
+}
+
+// EnsureIndexes creates the expiry TTL index
+func (r *MongoAccountTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoAccountTokenRepository) Create(ctx context.Context, token *models.AccountToken) error {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+func (r *MongoAccountTokenRepository) Consume(ctx context.Context, id primitive.ObjectID, purpose string, now time.Time) (*models.AccountToken, error) {
+	var token models.AccountToken
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":        id,
+			"purpose":    purpose,
+			"used_at":    bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+func TestAccountTokenConsume(t *testing.T) {
+	run := func(t *testing.T, repo AccountTokenRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		token := &models.AccountToken{UserID: "u1", Purpose: models.PurposeVerifyEmail, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+		if err := repo.Create(ctx, token); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		if _, err := repo.Consume(ctx, token.ID, models.PurposeResetPassword, now); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Consume for another purpose = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.Consume(ctx, token.ID, models.PurposeVerifyEmail, now.Add(time.Hour)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Consume after expiry = %v, want ErrNotFound", err)
+		}
+		got, err := repo.Consume(ctx, token.ID, models.PurposeVerifyEmail, now)
+		if err != nil || got.UserID != "u1" || got.UsedAt == nil {
+			t.Fatalf("Consume = %+v, %v", got, err)
+		}
+		if _, err := repo.Consume(ctx, token.ID, models.PurposeVerifyEmail, now); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Consume = %v, want ErrNotFound", err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryAccountTokenRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoAccountTokenRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
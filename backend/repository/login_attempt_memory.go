@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// MemoryLoginAttemptRepository keeps failed login counts in process memory
+type MemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (r *MemoryLoginAttemptRepository) Get(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok || !time.Now().Before(attempt.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &attempt, nil
+}
+
+func (r *MemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, attempt := range r.attempts {
+		if !now.Before(attempt.ExpiresAt) {
+			delete(r.attempts, k)
+		}
+	}
+
+	attempt, ok := r.attempts[key]
+	if !ok || !attempt.WindowStart.After(now.Add(-window)) {
+		attempt.Key = key
+		attempt.Failures = 0
+		attempt.WindowStart = now
+	}
+	attempt.Failures++
+	if expiresAt := now.Add(window); expiresAt.After(attempt.ExpiresAt) {
+		attempt.ExpiresAt = expiresAt
+	}
+	r.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (r *MemoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := r.attempts[key]
+	attempt.Key = key
+	attempt.Failures = 0
+	attempt.LockedUntil = &until
+	if until.After(attempt.ExpiresAt) {
+		attempt.ExpiresAt = until
+	}
+	r.attempts[key] = attempt
+	return nil
+}
+
+func (r *MemoryLoginAttemptRepository) Clear(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
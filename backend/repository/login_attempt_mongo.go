@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLoginAttemptRepository stores failed login counts in the
+// "login_attempts" collection, keyed by account and expired by a TTL index
+type MongoLoginAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoLoginAttemptRepository(db *mongo.Database) *MongoLoginAttemptRepository {
+	return &MongoLoginAttemptRepository{collection: db.Collection("login_attempts")}
+}
+
+// EnsureIndexes creates the expiry TTL index
+func (r *MongoLoginAttemptRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoLoginAttemptRepository) Get(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&attempt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordFailure restarts or extends the window in one pipeline update, so
+// concurrent failures are all counted
+func (r *MongoLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	inWindow := bson.M{"$gt": bson.A{"$window_start", now.Add(-window)}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures":     bson.M{"$cond": bson.A{inWindow, bson.M{"$add": bson.A{"$failures", 1}}, 1}},
+			"window_start": bson.M{"$cond": bson.A{inWindow, "$window_start", now}},
+			"expires_at":   bson.M{"$max": bson.A{now.Add(window), "$expires_at"}},
+		}}},
+	}
+
+	var attempt models.LoginAttempt
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *MongoLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$set": bson.M{"failures": 0, "locked_until": until},
+			"$max": bson.M{"expires_at": until},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoLoginAttemptRepository) Clear(ctx context.Context, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoginAttempts(t *testing.T) {
+	run := func(t *testing.T, repo LoginAttemptRepository) {
+		ctx := context.Background()
+		now := time.Now().Truncate(time.Millisecond)
+		window := 15 * time.Minute
+
+		if _, err := repo.Get(ctx, "ada"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get before any failure = %v, want ErrNotFound", err)
+		}
+		for i := 1; i <= 2; i++ {
+			attempt, err := repo.RecordFailure(ctx, "ada", now, window)
+			if err != nil || attempt.Failures != i {
+				t.Fatalf("RecordFailure %d = %+v, %v", i, attempt, err)
+			}
+		}
+		// A failure after the window starts a new count
+		attempt, err := repo.RecordFailure(ctx, "ada", now.Add(window+time.Second), window)
+		if err != nil || attempt.Failures != 1 {
+			t.Fatalf("RecordFailure after the window = %+v, %v, want 1 failure", attempt, err)
+		}
+
+		until := now.Add(time.Hour)
+		if err := repo.Lock(ctx, "ada", until); err != nil {
+			t.Fatalf("Lock: %v", err)
+		}
+		attempt, err = repo.Get(ctx, "ada")
+		if err != nil || attempt.Failures != 0 || attempt.LockedUntil == nil || !attempt.LockedUntil.Equal(until) {
+			t.Fatalf("Get after Lock = %+v, %v", attempt, err)
+		}
+		if _, err := repo.Get(ctx, "bob"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("another key = %v, want ErrNotFound", err)
+		}
+
+		if err := repo.Clear(ctx, "ada"); err != nil {
+			t.Fatalf("Clear: %v", err)
+		}
+		if _, err := repo.Get(ctx, "ada"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get after Clear = %v, want ErrNotFound", err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryLoginAttemptRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoLoginAttemptRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
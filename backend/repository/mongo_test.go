@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoDB returns a fresh database on the server at TEST_MONGO_URI, which
+// is dropped when the test ends. The test is skipped when it is not set.
+func testMongoDB(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	db := client.Database("taskmorph_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+// forEachTaskRepository runs fn against the memory repository and, when
+// TEST_MONGO_URI is set, the MongoDB one, so the two stay interchangeable
+func forEachTaskRepository(t *testing.T, fn func(t *testing.T, repo TaskRepository)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewMemoryTaskRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoTaskRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		fn(t, repo)
+	})
+}
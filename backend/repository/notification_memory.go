@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryNotificationRepository keeps the inbox in process memory
+type MemoryNotificationRepository struct {
+	mu            sync.RWMutex
+	notifications []models.Notification
+}
+
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{}
+}
+
+func (r *MemoryNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	r.notifications = append(r.notifications, *notification)
+	return nil
+}
+
+func (r *MemoryNotificationRepository) ListByUser(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notifications []models.Notification
+	for i := len(r.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		n := r.notifications[i]
+		if n.UserID == userID && (!unreadOnly || !n.Read) {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
+
+func (r *MemoryNotificationRepository) CountUnread(ctx context.Context, userID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, n := range r.notifications {
+		if n.UserID == userID && !n.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryNotificationRepository) MarkRead(ctx context.Context, userID string, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.notifications {
+		if r.notifications[i].ID == id && r.notifications[i].UserID == userID {
+			r.notifications[i].Read = true
+			r.notifications[i].ReadAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryNotificationRepository) MarkAllRead(ctx context.Context, userID string, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for i := range r.notifications {
+		if r.notifications[i].UserID == userID && !r.notifications[i].Read {
+			r.notifications[i].Read = true
+			r.notifications[i].ReadAt = &at
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoNotificationRepository stores the inbox in the "notifications" collection
+type MongoNotificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoNotificationRepository(db *mongo.Database) *MongoNotificationRepository {
+	return &MongoNotificationRepository{collection: db.Collection("notifications")}
+}
+
+// EnsureIndexes creates the index backing the inbox listing
+func (r *MongoNotificationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func (r *MongoNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, notification)
+	return err
+}
+
+func (r *MongoNotificationRepository) ListByUser(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var notifications []models.Notification
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *MongoNotificationRepository) CountUnread(ctx context.Context, userID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+}
+
+func (r *MongoNotificationRepository) MarkRead(ctx context.Context, userID string, id primitive.ObjectID, at time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true, "read_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoNotificationRepository) MarkAllRead(ctx context.Context, userID string, at time.Time) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": at}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
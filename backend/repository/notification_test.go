@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotificationInbox(t *testing.T) {
+	run := func(t *testing.T, repo NotificationRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		var ids []primitive.ObjectID
+		for i, title := range []string{"First", "Second", "Third"} {
+			n := &models.Notification{UserID: "u1", Kind: "reminder", Title: title, CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+			if err := repo.Create(ctx, n); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			ids = append(ids, n.ID)
+		}
+		if err := repo.Create(ctx, &models.Notification{UserID: "u2", Title: "Not mine", CreatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+
+		list, err := repo.ListByUser(ctx, "u1", false, 2)
+		if err != nil || len(list) != 2 || list[0].Title != "Third" || list[1].Title != "Second" {
+			t.Fatalf("ListByUser = %+v, %v, want the newest two", list, err)
+		}
+
+		if err := repo.MarkRead(ctx, "u2", ids[2], now); !errors.Is(err, ErrNotFound) {
+			t.Errorf("MarkRead by another user = %v, want ErrNotFound", err)
+		}
+		if err := repo.MarkRead(ctx, "u1", ids[2], now); err != nil {
+			t.Fatalf("MarkRead: %v", err)
+		}
+		if n, err := repo.CountUnread(ctx, "u1"); err != nil || n != 2 {
+			t.Errorf("CountUnread = %d, %v, want 2", n, err)
+		}
+		unread, err := repo.ListByUser(ctx, "u1", true, 10)
+		if err != nil || len(unread) != 2 || unread[0].Title != "Second" {
+			t.Errorf("unread = %+v, %v", unread, err)
+		}
+
+		if n, err := repo.MarkAllRead(ctx, "u1", now); err != nil || n != 2 {
+			t.Errorf("MarkAllRead = %d, %v, want 2", n, err)
+		}
+		if n, err := repo.CountUnread(ctx, "u2"); err != nil || n != 1 {
+			t.Errorf("another user's unread count = %d, %v, want 1", n, err)
+		}
+		read, err := repo.ListByUser(ctx, "u1", false, 10)
+		if err != nil || len(read) != 3 || !read[0].Read || read[0].ReadAt == nil {
+			t.Errorf("after MarkAllRead = %+v, %v", read, err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryNotificationRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoNotificationRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
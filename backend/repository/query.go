@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskSort is the field a task list is ordered by
+type TaskSort string
+
+const (
+	SortDeadline TaskSort = "deadline"
+	SortCreated  TaskSort = "created"
+	SortUpdated  TaskSort = "updated"
+	SortProgress TaskSort = "progress"
+)
+
+// Valid reports whether s is one of the supported sort fields
+func (s TaskSort) Valid() bool {
+	switch s {
+	case SortDeadline, SortCreated, SortUpdated, SortProgress:
+		return true
+	}
+	return false
+}
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// TaskFilter narrows a task list; zero values match everything.
+// DeadlineTo is exclusive and the progress bounds are inclusive.
+type TaskFilter struct {
+	Statuses     []models.TaskStatus
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+	ProgressMin  *int
+	ProgressMax  *int
+	Title        string
+}
+
+// TaskQuery describes one page of a user's task list
+type TaskQuery struct {
+	Filter     TaskFilter
+	Sort       TaskSort
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// TaskPage is one page of results. NextCursor is empty on the last page and
+// Total counts every task matching the filter, across all pages.
+type TaskPage struct {
+	Tasks      []models.Task
+	NextCursor string
+	Total      int64
+}
+
+// normalize fills in defaults and clamps the page size
+func (q *TaskQuery) normalize() {
+	if q.Sort == "" {
+		q.Sort = SortCreated
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+}
+
+// pageCursor is the keyset position after the last task of a page. Ties on
+// the sort field are broken by _id, which is unique and creation-ordered.
+type pageCursor struct {
+	Sort       TaskSort `json:"s"`
+	Descending bool     `json:"d,omitempty"`
+	// Times are Unix milliseconds, matching BSON date precision
+	Deadline  int64              `json:"dl,omitempty"`
+	CreatedAt int64              `json:"ca,omitempty"`
+	UpdatedAt int64              `json:"ua,omitempty"`
+	Progress  int                `json:"p,omitempty"`
+	ID        primitive.ObjectID `json:"id"`
+}
+
+func newPageCursor(q TaskQuery, last models.Task) pageCursor {
+	return pageCursor{
+		Sort:       q.Sort,
+		Descending: q.Descending,
+		Deadline:   last.Deadline.UnixMilli(),
+		CreatedAt:  last.CreatedAt.UnixMilli(),
+		UpdatedAt:  last.UpdatedAt.UnixMilli(),
+		Progress:   last.Progress,
+		ID:         last.ID,
+	}
+}
+
+func (c pageCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePageCursor(q TaskQuery) (*pageCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != q.Sort || c.Descending != q.Descending || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// matches evaluates the filter in Go the way the Mongo query would
+func (f TaskFilter) matches(task models.Task) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if task.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.DeadlineFrom != nil && task.Deadline.Before(*f.DeadlineFrom) {
+		return false
+	}
+	if f.DeadlineTo != nil && !task.Deadline.Before(*f.DeadlineTo) {
+		return false
+	}
+	if f.ProgressMin != nil && task.Progress < *f.ProgressMin {
+		return false
+	}
+	if f.ProgressMax != nil && task.Progress > *f.ProgressMax {
+		return false
+	}
+	if f.Title != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	return true
+}
+
+// compareTasks orders two tasks by the sort field, then by ID
+func compareTasks(sort TaskSort, a, b models.Task) int {
+	switch sort {
+	case SortDeadline:
+		if c := compareInt64(a.Deadline.UnixMilli(), b.Deadline.UnixMilli()); c != 0 {
+			return c
+		}
+	case SortCreated:
+		if c := compareInt64(a.CreatedAt.UnixMilli(), b.CreatedAt.UnixMilli()); c != 0 {
+			return c
+		}
+	case SortUpdated:
+		if c := compareInt64(a.UpdatedAt.UnixMilli(), b.UpdatedAt.UnixMilli()); c != 0 {
+			return c
+		}
+	case SortProgress:
+		if c := compareInt64(int64(a.Progress), int64(b.Progress)); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ID.Hex(), b.ID.Hex())
+}
+
+// cursorTask rebuilds enough of the last task of a page to compare against
+func (c pageCursor) task() models.Task {
+	return models.Task{
+		ID:        c.ID,
+		Deadline:  time.UnixMilli(c.Deadline),
+		CreatedAt: time.UnixMilli(c.CreatedAt),
+		UpdatedAt: time.UnixMilli(c.UpdatedAt),
+		Progress:  c.Progress,
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
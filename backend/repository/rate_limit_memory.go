@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitRepository keeps token buckets in process memory, so each
+// instance enforces its own limits
+type MemoryRateLimitRepository struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket will have refilled, after which it is
+	// indistinguishable from a missing one
+	fullAt time.Time
+}
+
+func NewMemoryRateLimitRepository() *MemoryRateLimitRepository {
+	return &MemoryRateLimitRepository{buckets: make(map[string]*memoryBucket)}
+}
+
+func (r *MemoryRateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastSweep) >= time.Minute {
+		for k, b := range r.buckets {
+			if !now.Before(b.fullAt) {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(burst), updatedAt: now}
+		r.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+		b.updatedAt = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.fullAt = now.Add(time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second)))
+	return allowed, b.tokens, nil
+}
+
+func (r *MemoryRateLimitRepository) Peek(ctx context.Context, key string, rate float64, burst int, now time.Time) (float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		return float64(burst), nil
+	}
+	tokens := b.tokens
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(burst), tokens+elapsed*rate)
+	}
+	return tokens, nil
+}
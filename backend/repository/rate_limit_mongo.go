@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRateLimitRepository stores token buckets in the "rate_limits"
+// collection so every instance draws from the same buckets. Buckets are
+// dropped by a TTL index once they would have refilled.
+type MongoRateLimitRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRateLimitRepository(db *mongo.Database) *MongoRateLimitRepository {
+	return &MongoRateLimitRepository{collection: db.Collection("rate_limits")}
+}
+
+type rateLimitDocument struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+// EnsureIndexes creates the expiry TTL index
+func (r *MongoRateLimitRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Take refills and draws from the bucket in a single pipeline update, so
+// concurrent requests cannot spend the same token
+func (r *MongoRateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	// Seconds since the last update, ignoring clock skew between instances
+	elapsed := bson.M{"$max": bson.A{0, bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+		1000,
+	}}}}
+	refilled := bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$tokens", burst}},
+		bson.M{"$multiply": bson.A{elapsed, rate}},
+	}}}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+	fullIn := time.Duration(float64(burst) / rate * float64(time.Second))
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens":     refilled,
+			"updated_at": bson.M{"$max": bson.A{now, "$updated_at"}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed":    hasToken,
+			"tokens":     bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"expires_at": now.Add(fullIn),
+		}}},
+	}
+
+	var doc rateLimitDocument
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return false, 0, err
+	}
+	return doc.Allowed, doc.Tokens, nil
+}
+
+func (r *MongoRateLimitRepository) Peek(ctx context.Context, key string, rate float64, burst int, now time.Time) (float64, error) {
+	var doc struct {
+		Tokens    float64   `bson:"tokens"`
+		UpdatedAt time.Time `bson:"updated_at"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return float64(burst), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	tokens := doc.Tokens
+	if elapsed := now.Sub(doc.UpdatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(burst), tokens+elapsed*rate)
+	}
+	return tokens, nil
+}
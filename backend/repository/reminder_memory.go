@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryReminderRepository keeps sent reminders in process memory
+type MemoryReminderRepository struct {
+	mu   sync.Mutex
+	sent map[memoryReminderKey]bool
+}
+
+// memoryReminderKey compares deadlines by instant rather than by time.Time
+// value, which would also compare locations and monotonic readings
+type memoryReminderKey struct {
+	taskID    primitive.ObjectID
+	threshold string
+	deadline  int64
+}
+
+func NewMemoryReminderRepository() *MemoryReminderRepository {
+	return &MemoryReminderRepository{sent: make(map[memoryReminderKey]bool)}
+}
+
+func (r *MemoryReminderRepository) Claim(ctx context.Context, key ReminderKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := memoryReminderKey{key.TaskID, key.Threshold, key.Deadline.UnixMilli()}
+	if r.sent[k] {
+		return false, nil
+	}
+	r.sent[k] = true
+	return true, nil
+}
+
+func (r *MemoryReminderRepository) Release(ctx context.Context, key ReminderKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sent, memoryReminderKey{key.TaskID, key.Threshold, key.Deadline.UnixMilli()})
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoReminderRepository stores sent reminders in the "reminders" collection.
+// A unique index on the key makes Claim safe across instances.
+type MongoReminderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoReminderRepository(db *mongo.Database) *MongoReminderRepository {
+	return &MongoReminderRepository{collection: db.Collection("reminders")}
+}
+
+type reminderDocument struct {
+	TaskID    primitive.ObjectID `bson:"task_id"`
+	Threshold string             `bson:"threshold"`
+	Deadline  time.Time          `bson:"deadline"`
+	SentAt    time.Time          `bson:"sent_at"`
+}
+
+// EnsureIndexes creates the unique key index and the TTL index that drops
+// reminders ReminderRetention after they were sent
+func (r *MongoReminderRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "threshold", Value: 1},
+				{Key: "deadline", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "sent_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(ReminderRetention / time.Second)),
+		},
+	})
+	return err
+}
+
+func (r *MongoReminderRepository) Claim(ctx context.Context, key ReminderKey) (bool, error) {
+	_, err := r.collection.InsertOne(ctx, reminderDocument{
+		TaskID:    key.TaskID,
+		Threshold: key.Threshold,
+		Deadline:  key.Deadline,
+		SentAt:    time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *MongoReminderRepository) Release(ctx context.Context, key ReminderKey) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{
+		"task_id":   key.TaskID,
+		"threshold": key.Threshold,
+		"deadline":  key.Deadline,
+	})
+	return err
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReminderClaim(t *testing.T) {
+	run := func(t *testing.T, repo ReminderRepository) {
+		ctx := context.Background()
+		deadline := time.Now().Truncate(time.Millisecond)
+		key := ReminderKey{TaskID: primitive.NewObjectID(), Threshold: "1h0m0s", Deadline: deadline}
+
+		if ok, err := repo.Claim(ctx, key); err != nil || !ok {
+			t.Fatalf("first Claim = %v, %v, want true", ok, err)
+		}
+		// The same instant in another location is the same key
+		again := key
+		again.Deadline = deadline.In(time.FixedZone("UTC+2", 2*60*60))
+		if ok, err := repo.Claim(ctx, again); err != nil || ok {
+			t.Errorf("second Claim = %v, %v, want false", ok, err)
+		}
+
+		for _, other := range []ReminderKey{
+			{TaskID: key.TaskID, Threshold: "overdue", Deadline: deadline},
+			{TaskID: key.TaskID, Threshold: key.Threshold, Deadline: deadline.Add(time.Hour)},
+			{TaskID: primitive.NewObjectID(), Threshold: key.Threshold, Deadline: deadline},
+		} {
+			if ok, err := repo.Claim(ctx, other); err != nil || !ok {
+				t.Errorf("Claim(%+v) = %v, %v, want true", other, ok, err)
+			}
+		}
+
+		if err := repo.Release(ctx, key); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if ok, err := repo.Claim(ctx, key); err != nil || !ok {
+			t.Errorf("Claim after Release = %v, %v, want true", ok, err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryReminderRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoReminderRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+
+		specs, err := repo.collection.Indexes().ListSpecifications(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ttl := false
+		for _, spec := range specs {
+			if spec.ExpireAfterSeconds != nil && *spec.ExpireAfterSeconds == int32(ReminderRetention/time.Second) {
+				ttl = strings.Contains(string(spec.KeysDocument), "sent_at")
+			}
+		}
+		if !ttl {
+			t.Errorf("no TTL index on sent_at among %+v", specs)
+		}
+	})
+}
+
+func TestListDue(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		createTask(t, repo, models.Task{UserID: "u1", Title: "Too early", Deadline: now.Add(-2 * time.Hour), Steps: newSteps("a")})
+		createTask(t, repo, models.Task{UserID: "u1", Title: "From", Deadline: now.Add(-time.Hour), Steps: newSteps("a")})
+		createTask(t, repo, models.Task{UserID: "u2", Title: "Other user", Deadline: now, Steps: newSteps("a")})
+		createTask(t, repo, models.Task{UserID: "u1", Title: "No steps", Deadline: now})
+		finished := createTask(t, repo, models.Task{UserID: "u1", Title: "Finished", Deadline: now, Steps: newSteps("a")})
+		if _, err := repo.SetStepCompleted(ctx, "u1", finished.ID, finished.Steps[0].ID, true, now); err != nil {
+			t.Fatal(err)
+		}
+		createTask(t, repo, models.Task{UserID: "u1", Title: "To", Deadline: now.Add(time.Hour), Steps: newSteps("a")})
+
+		tasks, err := repo.ListDue(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("ListDue: %v", err)
+		}
+		titles := map[string]bool{}
+		for _, task := range tasks {
+			titles[task.Title] = true
+		}
+		if len(tasks) != 2 || !titles["From"] || !titles["Other user"] {
+			t.Errorf("ListDue = %v, want From and Other user", titles)
+		}
+	})
+}
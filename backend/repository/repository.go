@@ -0,0 +1,209 @@
+// Package repository hides how tasks and users are persisted so the
+// controllers can run against MongoDB or an in-memory store.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrNotFound is returned when no document matches the lookup
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when a unique field is already taken
+	ErrDuplicate = errors.New("duplicate")
+	// ErrConflict is returned when a conditional update finds the document
+	// changed since it was read
+	ErrConflict = errors.New("conflict")
+)
+
+// TaskRepository stores tasks. Every method except Create is scoped to the
+// owning user, so a task ID from another account behaves like a missing one.
+type TaskRepository interface {
+	Create(ctx context.Context, task *models.Task) error
+	ListByUser(ctx context.Context, userID string, query TaskQuery) (*TaskPage, error)
+	// Search ranks the user's tasks against text across task and step fields
+	Search(ctx context.Context, userID, text string, limit int) ([]SearchHit, error)
+	FindByID(ctx context.Context, userID string, taskID primitive.ObjectID) (*models.Task, error)
+	Update(ctx context.Context, userID string, taskID primitive.ObjectID, update TaskUpdate) (*models.Task, error)
+	SetStepCompleted(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, completed bool, at time.Time) (*models.Task, error)
+	AddStep(ctx context.Context, userID string, taskID primitive.ObjectID, step models.Step, position int) (*models.Task, error)
+	UpdateStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, update StepUpdate) (*models.Task, error)
+	MoveStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, position int) (*models.Task, error)
+	DeleteStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID) (*models.Task, error)
+	Delete(ctx context.Context, userID string, taskID primitive.ObjectID) error
+	// MarkOverdue flags every unfinished task whose deadline is before now
+	MarkOverdue(ctx context.Context, now time.Time) (int64, error)
+	// ListDue returns tasks of every user with at least one incomplete step
+	// and a deadline in [from, to)
+	ListDue(ctx context.Context, from, to time.Time) ([]models.Task, error)
+}
+
+// TaskUpdate lists the task fields to change. Nil fields are left untouched;
+// a non-nil Steps replaces the whole list in the given order.
+type TaskUpdate struct {
+	Title    *string
+	Deadline *time.Time
+	Steps    []models.Step
+	// StepsReadAt is the UpdatedAt of the task Steps was built from. A
+	// non-nil Steps is only written if the task is still at that version,
+	// otherwise Update returns ErrConflict, so concurrent step changes are
+	// not overwritten.
+	StepsReadAt time.Time
+}
+
+// StepUpdate lists the step fields to change; nil fields are left untouched
+type StepUpdate struct {
+	Title       *string
+	Description *string
+}
+
+// AppendPosition passed to AddStep puts the new step at the end of the list
+const AppendPosition = -1
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	UpdatePreferences(ctx context.Context, id primitive.ObjectID, prefs models.NotificationPreferences) error
+	// UpdateProfile applies the non-nil fields and returns the updated user.
+	// Changing the email clears its verification; a taken email returns
+	// ErrDuplicate.
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, update UserUpdate) (*models.User, error)
+	SetPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error
+	// MarkEmailVerified verifies the user's address if it is still email,
+	// returning ErrNotFound otherwise, so a link sent to an address the
+	// user has since changed cannot verify the new one
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error
+}
+
+// UserUpdate is a partial profile update; nil fields are left unchanged
+type UserUpdate struct {
+	Name  *string
+	Email *string
+}
+
+// NotificationRepository stores the in-app inbox. Reads and updates are
+// scoped to the owning user.
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	// ListByUser returns the newest notifications first
+	ListByUser(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID string) (int64, error)
+	MarkRead(ctx context.Context, userID string, id primitive.ObjectID, at time.Time) error
+	MarkAllRead(ctx context.Context, userID string, at time.Time) (int64, error)
+}
+
+// SessionRepository stores login sessions and their refresh token hashes
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	// FindByID returns ErrNotFound for unknown sessions, revoked or not
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	// Rotate swaps the refresh hash if the session is live and still holds
+	// oldHash, and returns ErrNotFound otherwise, so a refresh token can only
+	// be redeemed once
+	Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// RevokeAllForUser revokes every live session of a user and returns
+	// their IDs
+	RevokeAllForUser(ctx context.Context, userID string, at time.Time) ([]primitive.ObjectID, error)
+}
+
+// RevokedTokenRepository is the deny list checked on every authenticated
+// request. Entries only need to outlive the tokens they block.
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	// IsRevoked reports whether any of the IDs has been revoked
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+}
+
+// AccountTokenRepository stores the single-use tokens behind email
+// verification and password reset links
+type AccountTokenRepository interface {
+	Create(ctx context.Context, token *models.AccountToken) error
+	// Consume marks an unused, unexpired token of the given purpose as used
+	// and returns it, or returns ErrNotFound. It succeeds at most once per
+	// token.
+	Consume(ctx context.Context, id primitive.ObjectID, purpose string, now time.Time) (*models.AccountToken, error)
+}
+
+// ReminderKey identifies one reminder. The deadline is part of the key so
+// moving a task's deadline re-arms its reminders.
+type ReminderKey struct {
+	TaskID    primitive.ObjectID
+	Threshold string
+	Deadline  time.Time
+}
+
+// ReminderRetention is how long a sent reminder is remembered. It has to
+// outlast the window in which the scheduler could send it again, which the
+// scheduler checks against its lead times and lookback.
+const ReminderRetention = 30 * 24 * time.Hour
+
+// ReminderRepository records which reminders were sent so every task and
+// threshold is reminded at most once, even with several scheduler instances.
+// Records may be dropped ReminderRetention after they were sent.
+type ReminderRepository interface {
+	// Claim records the key and reports false if it was already recorded
+	Claim(ctx context.Context, key ReminderKey) (bool, error)
+	// Release forgets a claim so the reminder is retried on the next run
+	Release(ctx context.Context, key ReminderKey) error
+}
+
+// RateLimitRepository holds the token buckets behind the rate limiter. The
+// Mongo implementation lets several instances share the same limits.
+type RateLimitRepository interface {
+	// Take refills the bucket for key at rate tokens per second, up to burst,
+	// then takes one token if a whole one is left. It reports whether it did
+	// and how many tokens remain.
+	Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error)
+	// Peek returns how many tokens Take would find in key's bucket at now,
+	// without taking any
+	Peek(ctx context.Context, key string, rate float64, burst int, now time.Time) (float64, error)
+}
+
+// LoginAttemptRepository counts failed logins per account and client for the
+// lockout
+type LoginAttemptRepository interface {
+	// Get returns ErrNotFound when the key has no recorded failures
+	Get(ctx context.Context, key string) (*models.LoginAttempt, error)
+	// RecordFailure counts one failure and returns the updated record.
+	// Failures from before the last window are forgotten first.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	// Lock locks the key until the given time and resets its failure count
+	Lock(ctx context.Context, key string, until time.Time) error
+	Clear(ctx context.Context, key string) error
+}
+
+// UsageTotals sums a user's AI usage records
+type UsageTotals struct {
+	Requests     int64
+	Failed       int64
+	PromptTokens int64
+	OutputTokens int64
+	TotalTokens  int64
+}
+
+// UsageRepository stores a record of every AI generator call for metering
+// and quotas
+type UsageRepository interface {
+	Record(ctx context.Context, usage *models.AIUsage) error
+	// Totals sums the user's records created at or after since
+	Totals(ctx context.Context, userID string, since time.Time) (UsageTotals, error)
+	// ReserveRequest counts one request in the user's quota period starting
+	// at start, atomically and only while the count is below limit, so
+	// concurrent callers cannot overshoot it. A limit of 0 is unlimited. It
+	// reports false, counting nothing, when the period is full. The counter
+	// may be dropped after end.
+	ReserveRequest(ctx context.Context, userID string, start, end time.Time, limit int64) (bool, error)
+	// ReleaseRequest takes back a reservation that was not used
+	ReleaseRequest(ctx context.Context, userID string, start time.Time) error
+	// RequestCount returns how many requests are reserved in the user's
+	// quota period starting at start
+	RequestCount(ctx context.Context, userID string, start time.Time) (int64, error)
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevokedTokenRepository keeps the deny list in process memory
+type MemoryRevokedTokenRepository struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevokedTokenRepository() *MemoryRevokedTokenRepository {
+	return &MemoryRevokedTokenRepository{revoked: make(map[string]time.Time)}
+}
+
+func (r *MemoryRevokedTokenRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, expiry := range r.revoked {
+		if !now.Before(expiry) {
+			delete(r.revoked, key)
+		}
+	}
+	if expiresAt.After(r.revoked[id]) {
+		r.revoked[id] = expiresAt
+	}
+	return nil
+}
+
+func (r *MemoryRevokedTokenRepository) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if expiry, ok := r.revoked[id]; ok && now.Before(expiry) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
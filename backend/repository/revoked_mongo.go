@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRevokedTokenRepository stores revoked token and session IDs in the
+// "revoked_tokens" collection, keyed by ID and expired by a TTL index
+type MongoRevokedTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRevokedTokenRepository(db *mongo.Database) *MongoRevokedTokenRepository {
+	return &MongoRevokedTokenRepository{collection: db.Collection("revoked_tokens")}
+}
+
+type revokedTokenDocument struct {
+	ID        string    `bson:"_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// EnsureIndexes creates the expiry TTL index
+func (r *MongoRevokedTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoRevokedTokenRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$max": bson.M{"expires_at": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoRevokedTokenRepository) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	// The TTL monitor only runs once a minute, so expired entries may linger
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"_id":        bson.M{"$in": ids},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchHit is one task matching a full-text search. MatchedStepIDs lists the
+// steps containing a search term, best match first.
+type SearchHit struct {
+	Task           models.Task
+	Score          float64
+	MatchedStepIDs []primitive.ObjectID
+}
+
+// Field weights shared by the Mongo text index and the in-memory fallback
+const (
+	weightTitle           = 10
+	weightStepTitle       = 5
+	weightStepDescription = 1
+)
+
+// tokenize lowercases text and splits it into words of two or more characters
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	tokens := fields[:0]
+	for _, field := range fields {
+		if len(field) >= 2 {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// countMatches counts the words of text that start with one of the terms.
+// Prefix matching stands in for the stemming a Mongo text index does, so
+// "plan" finds "planning".
+func countMatches(terms []string, text string) int {
+	count := 0
+	for _, word := range tokenize(text) {
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// scoreTask ranks a task against the search terms and reports which steps
+// matched, ordered by their own score
+func scoreTask(terms []string, task models.Task) SearchHit {
+	hit := SearchHit{Task: task}
+	hit.Score = float64(weightTitle * countMatches(terms, task.Title))
+
+	type stepScore struct {
+		id    primitive.ObjectID
+		score int
+	}
+	var steps []stepScore
+	for _, step := range task.Steps {
+		score := weightStepTitle*countMatches(terms, step.Title) +
+			weightStepDescription*countMatches(terms, step.Description)
+		if score > 0 {
+			steps = append(steps, stepScore{id: step.ID, score: score})
+			hit.Score += float64(score)
+		}
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].score > steps[j].score })
+	for _, step := range steps {
+		hit.MatchedStepIDs = append(hit.MatchedStepIDs, step.id)
+	}
+	return hit
+}
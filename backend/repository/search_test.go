@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := map[string]string{
+		"Plan the Garden!":      "[plan the garden]",
+		"a b cd":                "[cd]",
+		"e-mail, v2.0 & Résumé": "[mail v2 résumé]",
+		"   ":                   "[]",
+	}
+	for text, want := range tests {
+		if got := fmt.Sprint(tokenize(text)); got != want {
+			t.Errorf("tokenize(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestScoreTask(t *testing.T) {
+	task := models.Task{
+		Title: "Plan the garden",
+		Steps: []models.Step{
+			{ID: primitive.NewObjectID(), Title: "Buy seeds", Description: "Garden centre, planning ahead"},
+			{ID: primitive.NewObjectID(), Title: "Planting day", Description: "Plant everything"},
+			{ID: primitive.NewObjectID(), Title: "Water", Description: "Every morning"},
+		},
+	}
+
+	hit := scoreTask([]string{"plan"}, task)
+	// Title 10, first step description 1, second step title 5 and description 1
+	if hit.Score != 17 {
+		t.Errorf("score = %v, want 17", hit.Score)
+	}
+	want := fmt.Sprint([]primitive.ObjectID{task.Steps[1].ID, task.Steps[0].ID})
+	if got := fmt.Sprint(hit.MatchedStepIDs); got != want {
+		t.Errorf("matched steps = %s, want the planting step before the seeds step %s", got, want)
+	}
+
+	if hit := scoreTask([]string{"lawn"}, task); hit.Score != 0 || hit.MatchedStepIDs != nil {
+		t.Errorf("unrelated term: %+v", hit)
+	}
+	// Terms only match at the start of words
+	if hit := scoreTask([]string{"arden"}, task); hit.Score != 0 {
+		t.Errorf("infix match scored %v", hit.Score)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		deadline := time.Now().Add(time.Hour)
+		described := createTask(t, repo, models.Task{UserID: "u1", Title: "Weekend chores", Deadline: deadline, Steps: []models.Step{
+			{ID: primitive.NewObjectID(), Title: "Laundry"},
+			{ID: primitive.NewObjectID(), Title: "Outside", Description: "Weed the garden"},
+		}})
+		titled := createTask(t, repo, models.Task{UserID: "u1", Title: "Garden makeover", Deadline: deadline, Steps: newSteps("Measure")})
+		createTask(t, repo, models.Task{UserID: "u1", Title: "Tax return", Deadline: deadline, Steps: newSteps("Collect receipts")})
+		createTask(t, repo, models.Task{UserID: "u2", Title: "Garden party", Deadline: deadline})
+
+		hits, err := repo.Search(ctx, "u1", "garden", 10)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if len(hits) != 2 {
+			t.Fatalf("got %d hits, want 2", len(hits))
+		}
+		if hits[0].Task.ID != titled.ID || hits[1].Task.ID != described.ID {
+			t.Errorf("ranking = %s, %s, want the title match first", hits[0].Task.Title, hits[1].Task.Title)
+		}
+		if hits[0].Score <= hits[1].Score {
+			t.Errorf("scores = %v, %v, want descending", hits[0].Score, hits[1].Score)
+		}
+		if got := hits[1].MatchedStepIDs; len(got) != 1 || got[0] != described.Steps[1].ID {
+			t.Errorf("matched steps = %v, want the step describing the garden", got)
+		}
+		if len(hits[0].MatchedStepIDs) != 0 {
+			t.Errorf("title match lists steps %v", hits[0].MatchedStepIDs)
+		}
+
+		if hits, err := repo.Search(ctx, "u1", "garden", 1); err != nil || len(hits) != 1 || hits[0].Task.ID != titled.ID {
+			t.Errorf("limited search = %v, %v, want only the best hit", hits, err)
+		}
+		if hits, err := repo.Search(ctx, "u1", "receipts", 10); err != nil || len(hits) != 1 {
+			t.Errorf("step title search = %v, %v, want one hit", hits, err)
+		}
+		if hits, err := repo.Search(ctx, "u1", "party", 10); err != nil || len(hits) != 0 {
+			t.Errorf("search for another user's task = %v, %v, want none", hits, err)
+		}
+	})
+}
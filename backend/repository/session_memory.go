@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySessionRepository keeps sessions in process memory
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]models.Session
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: make(map[primitive.ObjectID]models.Session)}
+}
+
+func (r *MemorySessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *MemorySessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (r *MemorySessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.RefreshHash != oldHash || session.RevokedAt != nil || !time.Now().Before(session.ExpiresAt) {
+		return ErrNotFound
+	}
+	session.RefreshHash = newHash
+	session.ExpiresAt = expiresAt
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *MemorySessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if ok && session.RevokedAt == nil {
+		session.RevokedAt = &at
+		r.sessions[id] = session
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeAllForUser(ctx context.Context, userID string, at time.Time) ([]primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []primitive.ObjectID
+	for id, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && at.Before(session.ExpiresAt) {
+			session.RevokedAt = &at
+			r.sessions[id] = session
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSessionRepository stores sessions in the "sessions" collection. A TTL
+// index drops them once they expire.
+type MongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSessionRepository(db *mongo.Database) *MongoSessionRepository {
+	return &MongoSessionRepository{collection: db.Collection("sessions")}
+}
+
+// EnsureIndexes creates the expiry TTL index and the per-user index
+func (r *MongoSessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *MongoSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *MongoSessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *MongoSessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":          id,
+		"refresh_hash": oldHash,
+		"revoked_at":   bson.M{"$exists": false},
+		"expires_at":   bson.M{"$gt": time.Now()},
+	}, bson.M{"$set": bson.M{"refresh_hash": newHash, "expires_at": expiresAt}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoSessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"revoked_at": at}})
+	return err
+}
+
+func (r *MongoSessionRepository) RevokeAllForUser(ctx context.Context, userID string, at time.Time) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": at},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSessionRotate(t *testing.T) {
+	run := func(t *testing.T, repo SessionRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		session := &models.Session{UserID: "u1", RefreshHash: "h1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+		if err := repo.Create(ctx, session); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		if err := repo.Rotate(ctx, session.ID, "h1", "h2", now.Add(2*time.Hour)); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+		// The old hash can only be redeemed once
+		if err := repo.Rotate(ctx, session.ID, "h1", "h3", now.Add(2*time.Hour)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Rotate from h1 = %v, want ErrNotFound", err)
+		}
+		got, err := repo.FindByID(ctx, session.ID)
+		if err != nil || got.RefreshHash != "h2" || got.UserID != "u1" {
+			t.Fatalf("FindByID = %+v, %v, want hash h2", got, err)
+		}
+
+		if err := repo.Revoke(ctx, session.ID, now); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		if err := repo.Rotate(ctx, session.ID, "h2", "h3", now.Add(2*time.Hour)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Rotate after Revoke = %v, want ErrNotFound", err)
+		}
+		if got, err := repo.FindByID(ctx, session.ID); err != nil || got.RevokedAt == nil {
+			t.Errorf("revoked session = %+v, %v, want RevokedAt set", got, err)
+		}
+
+		expired := &models.Session{UserID: "u1", RefreshHash: "h1", CreatedAt: now, ExpiresAt: now.Add(-time.Minute)}
+		if err := repo.Create(ctx, expired); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Rotate(ctx, expired.ID, "h1", "h2", now.Add(time.Hour)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Rotate of an expired session = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.FindByID(ctx, primitive.NewObjectID()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByID of an unknown session = %v, want ErrNotFound", err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemorySessionRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoSessionRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
+
+func TestRevokedTokens(t *testing.T) {
+	run := func(t *testing.T, repo RevokedTokenRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		if err := repo.Revoke(ctx, "jti-1", now.Add(time.Hour)); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		if err := repo.Revoke(ctx, "jti-2", now.Add(-time.Minute)); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+
+		tests := []struct {
+			ids  []string
+			want bool
+		}{
+			{[]string{"jti-1"}, true},
+			{[]string{"other", "jti-1"}, true},
+			{[]string{"other"}, false},
+			{[]string{"jti-2"}, false},
+		}
+		for _, tt := range tests {
+			if got, err := repo.IsRevoked(ctx, tt.ids...); err != nil || got != tt.want {
+				t.Errorf("IsRevoked(%v) = %v, %v, want %v", tt.ids, got, err, tt.want)
+			}
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryRevokedTokenRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoRevokedTokenRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
+
+func TestRevokeAllForUser(t *testing.T) {
+	run := func(t *testing.T, repo SessionRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		create := func(userID string, revoked bool) primitive.ObjectID {
+			session := &models.Session{UserID: userID, RefreshHash: "h", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+			if err := repo.Create(ctx, session); err != nil {
+				t.Fatal(err)
+			}
+			if revoked {
+				if err := repo.Revoke(ctx, session.ID, now); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return session.ID
+		}
+		live := []primitive.ObjectID{create("u1", false), create("u1", false)}
+		create("u1", true)
+		other := create("u2", false)
+
+		ids, err := repo.RevokeAllForUser(ctx, "u1", now)
+		if err != nil {
+			t.Fatalf("RevokeAllForUser: %v", err)
+		}
+		got := map[primitive.ObjectID]bool{}
+		for _, id := range ids {
+			got[id] = true
+		}
+		if len(ids) != 2 || !got[live[0]] || !got[live[1]] {
+			t.Errorf("revoked %v, want the two live sessions %v", ids, live)
+		}
+		if session, err := repo.FindByID(ctx, other); err != nil || session.RevokedAt != nil {
+			t.Errorf("another user's session = %+v, %v, want it live", session, err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemorySessionRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoSessionRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
@@ -0,0 +1,618 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newSteps(titles ...string) []models.Step {
+	steps := make([]models.Step, len(titles))
+	for i, title := range titles {
+		steps[i] = models.Step{ID: primitive.NewObjectID(), Title: title}
+	}
+	return steps
+}
+
+func createTask(t *testing.T, repo TaskRepository, task models.Task) models.Task {
+	t.Helper()
+	if err := repo.Create(context.Background(), &task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return task
+}
+
+// checkDerived fails unless the stored progress and status are what
+// models.Task.Refresh computes from the steps and deadline, which pins the
+// Mongo status pipeline to Refresh, and match the expected values
+func checkDerived(t *testing.T, repo TaskRepository, got *models.Task, err error, wantProgress int, wantStatus models.TaskStatus) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, err := repo.FindByID(context.Background(), got.UserID, got.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if stored.Progress != got.Progress || stored.Status != got.Status {
+		t.Errorf("returned task differs from the stored one: %d %s, stored %d %s", got.Progress, got.Status, stored.Progress, stored.Status)
+	}
+
+	want := *stored
+	want.Refresh(time.Now())
+	if stored.Progress != want.Progress || stored.Status != want.Status {
+		t.Errorf("stored %d %s, Refresh gives %d %s", stored.Progress, stored.Status, want.Progress, want.Status)
+	}
+	if stored.Progress != wantProgress || stored.Status != wantStatus {
+		t.Errorf("progress, status = %d, %s, want %d, %s", stored.Progress, stored.Status, wantProgress, wantStatus)
+	}
+}
+
+func TestTaskStatusMatchesRefresh(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		steps := newSteps("a", "b", "c")
+		a, b, c := steps[0].ID, steps[1].ID, steps[2].ID
+		task := createTask(t, repo, models.Task{UserID: "u1", Title: "Ship", Deadline: time.Now().Add(48 * time.Hour), Steps: steps})
+		checkDerived(t, repo, &task, nil, 0, models.StatusTodo)
+
+		got, err := repo.SetStepCompleted(ctx, "u1", task.ID, a, true, time.Now())
+		checkDerived(t, repo, got, err, 33, models.StatusInProgress)
+
+		repo.SetStepCompleted(ctx, "u1", task.ID, b, true, time.Now())
+		got, err = repo.SetStepCompleted(ctx, "u1", task.ID, c, true, time.Now())
+		checkDerived(t, repo, got, err, 100, models.StatusDone)
+
+		got, err = repo.SetStepCompleted(ctx, "u1", task.ID, b, false, time.Now())
+		checkDerived(t, repo, got, err, 66, models.StatusInProgress)
+
+		d := models.Step{ID: primitive.NewObjectID(), Title: "d"}
+		got, err = repo.AddStep(ctx, "u1", task.ID, d, 0)
+		checkDerived(t, repo, got, err, 50, models.StatusInProgress)
+
+		title := "d, renamed"
+		got, err = repo.UpdateStep(ctx, "u1", task.ID, d.ID, StepUpdate{Title: &title})
+		checkDerived(t, repo, got, err, 50, models.StatusInProgress)
+
+		got, err = repo.MoveStep(ctx, "u1", task.ID, d.ID, 3)
+		checkDerived(t, repo, got, err, 50, models.StatusInProgress)
+
+		got, err = repo.DeleteStep(ctx, "u1", task.ID, b)
+		checkDerived(t, repo, got, err, 66, models.StatusInProgress)
+
+		got, err = repo.DeleteStep(ctx, "u1", task.ID, d.ID)
+		checkDerived(t, repo, got, err, 100, models.StatusDone)
+
+		past := time.Now().Add(-48 * time.Hour)
+		got, err = repo.Update(ctx, "u1", task.ID, TaskUpdate{Deadline: &past})
+		checkDerived(t, repo, got, err, 100, models.StatusDone)
+
+		kept := got.Steps[0]
+		got, err = repo.Update(ctx, "u1", task.ID, TaskUpdate{Steps: append([]models.Step{kept}, newSteps("e")...), StepsReadAt: got.UpdatedAt})
+		checkDerived(t, repo, got, err, 50, models.StatusOverdue)
+
+		got, err = repo.SetStepCompleted(ctx, "u1", task.ID, got.Steps[1].ID, true, time.Now())
+		checkDerived(t, repo, got, err, 100, models.StatusDone)
+	})
+}
+
+func TestSetStepCompleted(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		task := createTask(t, repo, models.Task{UserID: "u1", Title: "Ship", Deadline: time.Now().Add(time.Hour), Steps: newSteps("a", "b")})
+		if task.ID.IsZero() {
+			t.Fatal("Create did not assign an ID")
+		}
+
+		at := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+		got, err := repo.SetStepCompleted(ctx, "u1", task.ID, task.Steps[1].ID, true, at)
+		if err != nil {
+			t.Fatalf("SetStepCompleted: %v", err)
+		}
+		stored, err := repo.FindByID(ctx, "u1", task.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		for _, task := range []*models.Task{got, stored} {
+			step := task.Steps[1]
+			if task.Steps[0].IsCompleted || !step.IsCompleted || step.CompletedAt == nil || !step.CompletedAt.Equal(at) || step.CompletedBy != "u1" {
+				t.Errorf("steps = %+v, want only b completed by u1 at %v", task.Steps, at)
+			}
+		}
+
+		got, err = repo.SetStepCompleted(ctx, "u1", task.ID, task.Steps[1].ID, false, time.Now())
+		if err != nil {
+			t.Fatalf("SetStepCompleted(false): %v", err)
+		}
+		if step := got.Steps[1]; step.IsCompleted || step.CompletedAt != nil || step.CompletedBy != "" {
+			t.Errorf("reopened step = %+v, want the completion cleared", step)
+		}
+	})
+}
+
+func TestTaskTimestamps(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		before := time.Now().Truncate(time.Millisecond)
+		task := createTask(t, repo, models.Task{UserID: "u1", Title: "Ship", Deadline: time.Now().Add(time.Hour), Steps: newSteps("a", "b")})
+		stored, err := repo.FindByID(ctx, "u1", task.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		created := stored.CreatedAt
+		if created.Before(before) || !stored.UpdatedAt.Equal(created) || stored.CompletedAt != nil {
+			t.Fatalf("new task created %v, updated %v, completed %v", created, stored.UpdatedAt, stored.CompletedAt)
+		}
+		for _, step := range stored.Steps {
+			if !step.CreatedAt.Equal(created) || !step.UpdatedAt.Equal(created) {
+				t.Errorf("new step = %+v, want it stamped with the task", step)
+			}
+		}
+
+		// An empty update changes nothing, not even the update time
+		time.Sleep(2 * time.Millisecond)
+		got, err := repo.Update(ctx, "u1", task.ID, TaskUpdate{})
+		if err != nil || !got.UpdatedAt.Equal(created) {
+			t.Errorf("empty update = %v, %v, want updated_at unchanged", got.UpdatedAt, err)
+		}
+
+		title := "Ship it"
+		got, err = repo.Update(ctx, "u1", task.ID, TaskUpdate{Title: &title})
+		if err != nil || !got.CreatedAt.Equal(created) || !got.UpdatedAt.After(created) {
+			t.Fatalf("after a title change: created %v, updated %v, %v", got.CreatedAt, got.UpdatedAt, err)
+		}
+		renamed := got.UpdatedAt
+
+		time.Sleep(2 * time.Millisecond)
+		got, err = repo.AddStep(ctx, "u1", task.ID, models.Step{ID: primitive.NewObjectID(), Title: "c"}, 2)
+		if err != nil {
+			t.Fatalf("AddStep: %v", err)
+		}
+		added := got.Steps[2]
+		if !added.CreatedAt.After(renamed) || !added.UpdatedAt.Equal(added.CreatedAt) || !got.Steps[0].CreatedAt.Equal(created) {
+			t.Errorf("steps after AddStep = %+v", got.Steps)
+		}
+
+		// Completing the last open step completes the task at that time
+		var at time.Time
+		for i, step := range got.Steps {
+			at = time.Now().Add(time.Duration(i) * time.Second).Truncate(time.Millisecond)
+			if got, err = repo.SetStepCompleted(ctx, "u1", task.ID, step.ID, true, at); err != nil {
+				t.Fatalf("SetStepCompleted: %v", err)
+			}
+		}
+		if got.Status != models.StatusDone || got.CompletedAt == nil || !got.CompletedAt.Equal(at) {
+			t.Errorf("done task completed at %v, want %v", got.CompletedAt, at)
+		}
+		if step := got.Steps[2]; !step.UpdatedAt.Equal(at) {
+			t.Errorf("completed step updated at %v, want %v", step.UpdatedAt, at)
+		}
+
+		got, err = repo.SetStepCompleted(ctx, "u1", task.ID, got.Steps[0].ID, false, time.Now())
+		if err != nil || got.Status == models.StatusDone || got.CompletedAt != nil {
+			t.Errorf("reopened task = %s completed at %v, %v", got.Status, got.CompletedAt, err)
+		}
+	})
+}
+
+func TestTaskRepositoryScopesToOwner(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		task := createTask(t, repo, models.Task{UserID: "u1", Title: "Mine", Deadline: time.Now().Add(time.Hour), Steps: newSteps("a")})
+		step := task.Steps[0].ID
+
+		if _, err := repo.FindByID(ctx, "u2", task.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByID by another user: %v, want ErrNotFound", err)
+		}
+		if _, err := repo.SetStepCompleted(ctx, "u2", task.ID, step, true, time.Now()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetStepCompleted by another user: %v, want ErrNotFound", err)
+		}
+		if _, err := repo.SetStepCompleted(ctx, "u1", task.ID, primitive.NewObjectID(), true, time.Now()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetStepCompleted on an unknown step: %v, want ErrNotFound", err)
+		}
+		if err := repo.Delete(ctx, "u2", task.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete by another user: %v, want ErrNotFound", err)
+		}
+		if err := repo.Delete(ctx, "u1", task.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.FindByID(ctx, "u1", task.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByID after Delete: %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestListByUser(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			createTask(t, repo, models.Task{UserID: "u1", Title: fmt.Sprintf("Task %d", i), Deadline: time.Now()})
+		}
+		createTask(t, repo, models.Task{UserID: "u2", Title: "Not mine", Deadline: time.Now()})
+
+		page, err := repo.ListByUser(ctx, "u1", TaskQuery{})
+		if err != nil {
+			t.Fatalf("ListByUser: %v", err)
+		}
+		var titles []string
+		for _, task := range page.Tasks {
+			titles = append(titles, task.Title)
+		}
+		if got := fmt.Sprint(titles); got != "[Task 0 Task 1 Task 2]" {
+			t.Errorf("ListByUser = %s, want the user's tasks in creation order", got)
+		}
+		if page, err := repo.ListByUser(ctx, "u3", TaskQuery{}); err != nil || len(page.Tasks) != 0 || page.Total != 0 {
+			t.Errorf("ListByUser for a user without tasks = %+v, %v", page, err)
+		}
+	})
+}
+
+func TestTaskRepositoryUpdate(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		task := createTask(t, repo, models.Task{UserID: "u1", Title: "Ship", Deadline: time.Now().Add(time.Hour), Steps: newSteps("a", "b")})
+
+		title := "Ship it"
+		got, err := repo.Update(ctx, "u1", task.ID, TaskUpdate{Title: &title})
+		if err != nil || got.Title != "Ship it" || len(got.Steps) != 2 {
+			t.Fatalf("Update(title) = %+v, %v", got, err)
+		}
+
+		deadline := time.Date(2099, 1, 31, 0, 0, 0, 0, time.UTC)
+		steps := append([]models.Step{task.Steps[1]}, newSteps("c")...)
+		if _, err := repo.Update(ctx, "u1", task.ID, TaskUpdate{Deadline: &deadline, Steps: steps, StepsReadAt: task.UpdatedAt}); !errors.Is(err, ErrConflict) {
+			t.Fatalf("Update(steps) read before the title change: %v, want ErrConflict", err)
+		}
+		if _, err := repo.Update(ctx, "u1", task.ID, TaskUpdate{Deadline: &deadline, Steps: steps, StepsReadAt: got.UpdatedAt}); err != nil {
+			t.Fatalf("Update(deadline, steps): %v", err)
+		}
+		stored, err := repo.FindByID(ctx, "u1", task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Title != "Ship it" || !stored.Deadline.Equal(deadline) || len(stored.Steps) != 2 || stored.Steps[0].ID != task.Steps[1].ID || stored.Steps[1].Title != "c" {
+			t.Errorf("stored task = %+v", stored)
+		}
+
+		if _, err := repo.Update(ctx, "u2", task.ID, TaskUpdate{Title: &title}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update by another user: %v, want ErrNotFound", err)
+		}
+		if _, err := repo.Update(ctx, "u2", task.ID, TaskUpdate{Steps: steps, StepsReadAt: stored.UpdatedAt}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update(steps) by another user: %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestStepOrdering(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		task := createTask(t, repo, models.Task{UserID: "u1", Title: "Order", Deadline: time.Now().Add(time.Hour), Steps: newSteps("a", "b", "c")})
+		titles := func(task *models.Task, err error) string {
+			t.Helper()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var s []string
+			for _, step := range task.Steps {
+				s = append(s, step.Title)
+			}
+			return fmt.Sprint(s)
+		}
+		add := func(title string, position int) (*models.Task, error) {
+			return repo.AddStep(ctx, "u1", task.ID, models.Step{ID: primitive.NewObjectID(), Title: title}, position)
+		}
+
+		steps := []struct {
+			name string
+			do   func() (*models.Task, error)
+			want string
+		}{
+			{"add first", func() (*models.Task, error) { return add("x", 0) }, "[x a b c]"},
+			{"add in the middle", func() (*models.Task, error) { return add("y", 2) }, "[x a y b c]"},
+			{"add past the end", func() (*models.Task, error) { return add("z", 99) }, "[x a y b c z]"},
+			{"append", func() (*models.Task, error) { return add("w", AppendPosition) }, "[x a y b c z w]"},
+			{"move last to first", func() (*models.Task, error) {
+				return repo.MoveStep(ctx, "u1", task.ID, task.Steps[2].ID, 0)
+			}, "[c x a y b z w]"},
+			{"move first past the end", func() (*models.Task, error) {
+				return repo.MoveStep(ctx, "u1", task.ID, task.Steps[2].ID, 99)
+			}, "[x a y b z w c]"},
+			{"move forward", func() (*models.Task, error) {
+				return repo.MoveStep(ctx, "u1", task.ID, task.Steps[0].ID, 3)
+			}, "[x y b a z w c]"},
+			{"delete", func() (*models.Task, error) {
+				return repo.DeleteStep(ctx, "u1", task.ID, task.Steps[1].ID)
+			}, "[x y a z w c]"},
+		}
+		for _, step := range steps {
+			if got := titles(step.do()); got != step.want {
+				t.Fatalf("%s: steps = %s, want %s", step.name, got, step.want)
+			}
+		}
+
+		title := "a, renamed"
+		got, err := repo.UpdateStep(ctx, "u1", task.ID, task.Steps[0].ID, StepUpdate{Title: &title})
+		if titles(got, err) != "[x y a, renamed z w c]" {
+			t.Errorf("UpdateStep(title) gave %s", titles(got, err))
+		}
+
+		if _, err := repo.MoveStep(ctx, "u1", task.ID, primitive.NewObjectID(), 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("MoveStep of an unknown step: %v, want ErrNotFound", err)
+		}
+		if _, err := repo.DeleteStep(ctx, "u1", task.ID, primitive.NewObjectID()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteStep of an unknown step: %v, want ErrNotFound", err)
+		}
+		description := ""
+		if _, err := repo.UpdateStep(ctx, "u1", task.ID, primitive.NewObjectID(), StepUpdate{Description: &description}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateStep of an unknown step: %v, want ErrNotFound", err)
+		}
+		if _, err := repo.AddStep(ctx, "u2", task.ID, models.Step{ID: primitive.NewObjectID(), Title: "v"}, 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddStep by another user: %v, want ErrNotFound", err)
+		}
+		if got := titles(repo.FindByID(ctx, "u1", task.ID)); got != "[x y a, renamed z w c]" {
+			t.Errorf("failed step changes modified the task: %s", got)
+		}
+	})
+}
+
+func TestMarkOverdue(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		future := createTask(t, repo, models.Task{UserID: "u1", Title: "Future", Deadline: now.Add(time.Hour), Steps: newSteps("a")})
+		lapsing := createTask(t, repo, models.Task{UserID: "u1", Title: "Lapsing", Deadline: now.Add(time.Minute), Steps: newSteps("a", "b")})
+		finished := createTask(t, repo, models.Task{UserID: "u2", Title: "Finished", Deadline: now.Add(time.Minute), Steps: newSteps("a")})
+		if _, err := repo.SetStepCompleted(ctx, "u1", lapsing.ID, lapsing.Steps[0].ID, true, now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.SetStepCompleted(ctx, "u2", finished.ID, finished.Steps[0].ID, true, now); err != nil {
+			t.Fatal(err)
+		}
+
+		later := now.Add(30 * time.Minute)
+		if n, err := repo.MarkOverdue(ctx, later); err != nil || n != 1 {
+			t.Fatalf("MarkOverdue = %d, %v, want 1 task", n, err)
+		}
+		if n, err := repo.MarkOverdue(ctx, later); err != nil || n != 0 {
+			t.Errorf("second MarkOverdue = %d, %v, want 0 tasks", n, err)
+		}
+
+		wantStatus := map[primitive.ObjectID]models.TaskStatus{
+			future.ID:   models.StatusTodo,
+			lapsing.ID:  models.StatusOverdue,
+			finished.ID: models.StatusDone,
+		}
+		for id, want := range wantStatus {
+			userID := "u1"
+			if id == finished.ID {
+				userID = "u2"
+			}
+			task, err := repo.FindByID(ctx, userID, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Status != want {
+				t.Errorf("%s status = %s, want %s", task.Title, task.Status, want)
+			}
+		}
+
+		after, err := repo.FindByID(ctx, "u1", lapsing.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.Progress != 50 {
+			t.Errorf("MarkOverdue changed progress to %d", after.Progress)
+		}
+	})
+}
+
+func TestListByUserFilters(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		day := time.Now().Add(24 * time.Hour).Truncate(24 * time.Hour)
+		create := func(title string, deadline time.Time, completed int) {
+			task := createTask(t, repo, models.Task{UserID: "u1", Title: title, Deadline: deadline, Steps: newSteps("a", "b", "c", "d")})
+			for _, step := range task.Steps[:completed] {
+				if _, err := repo.SetStepCompleted(ctx, "u1", task.ID, step.ID, true, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		create("Water plants", day, 0)
+		create("Water the lawn", day.Add(24*time.Hour), 1)
+		create("Paint fence", day.Add(48*time.Hour), 4)
+		create("Mow (weekly)", day.Add(-72*time.Hour), 2)
+
+		intp := func(v int) *int { return &v }
+		timep := func(v time.Time) *time.Time { return &v }
+		tests := []struct {
+			name   string
+			filter TaskFilter
+			want   string
+		}{
+			{"all", TaskFilter{}, "[Water plants Water the lawn Paint fence Mow (weekly)]"},
+			{"one status", TaskFilter{Statuses: []models.TaskStatus{models.StatusOverdue}}, "[Mow (weekly)]"},
+			{"statuses", TaskFilter{Statuses: []models.TaskStatus{models.StatusTodo, models.StatusDone}}, "[Water plants Paint fence]"},
+			{"title ignores case", TaskFilter{Title: "WATER"}, "[Water plants Water the lawn]"},
+			{"title is literal", TaskFilter{Title: "(weekly)"}, "[Mow (weekly)]"},
+			{"title regexp characters", TaskFilter{Title: "Water.*"}, "[]"},
+			{"deadline from is inclusive", TaskFilter{DeadlineFrom: timep(day.Add(24 * time.Hour))}, "[Water the lawn Paint fence]"},
+			{"deadline to is exclusive", TaskFilter{DeadlineTo: timep(day.Add(24 * time.Hour))}, "[Water plants Mow (weekly)]"},
+			{"progress bounds are inclusive", TaskFilter{ProgressMin: intp(25), ProgressMax: intp(50)}, "[Water the lawn Mow (weekly)]"},
+			{"combined", TaskFilter{Title: "water", ProgressMin: intp(1)}, "[Water the lawn]"},
+		}
+		for _, tt := range tests {
+			page, err := repo.ListByUser(ctx, "u1", TaskQuery{Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			var titles []string
+			for _, task := range page.Tasks {
+				titles = append(titles, task.Title)
+			}
+			if got := fmt.Sprint(titles); got != tt.want {
+				t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+			}
+			if page.Total != int64(len(page.Tasks)) {
+				t.Errorf("%s: total = %d, want %d", tt.name, page.Total, len(page.Tasks))
+			}
+		}
+	})
+}
+
+// sortKey is the value a task list is ordered by, at BSON date precision
+func sortKey(s TaskSort, task models.Task) int64 {
+	switch s {
+	case SortDeadline:
+		return task.Deadline.UnixMilli()
+	case SortCreated:
+		return task.CreatedAt.UnixMilli()
+	case SortUpdated:
+		return task.UpdatedAt.UnixMilli()
+	case SortProgress:
+		return int64(task.Progress)
+	}
+	return 0
+}
+
+// wantOrder sorts the tasks by the sort key, then by ID, independently of
+// compareTasks and the Mongo sort
+func wantOrder(tasks []models.Task, s TaskSort, descending bool) []primitive.ObjectID {
+	sorted := append([]models.Task(nil), tasks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sortKey(s, sorted[i]), sortKey(s, sorted[j])
+		if a != b {
+			return (a < b) != descending
+		}
+		return (bytes.Compare(sorted[i].ID[:], sorted[j].ID[:]) < 0) != descending
+	})
+	ids := make([]primitive.ObjectID, len(sorted))
+	for i, task := range sorted {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+// collectPages follows the cursors from the first page to the last
+func collectPages(t *testing.T, repo TaskRepository, query TaskQuery) ([]primitive.ObjectID, int64) {
+	t.Helper()
+	var ids []primitive.ObjectID
+	var total int64
+	for pages := 0; ; pages++ {
+		if pages > 50 {
+			t.Fatal("pagination does not end")
+		}
+		page, err := repo.ListByUser(context.Background(), "u1", query)
+		if err != nil {
+			t.Fatalf("ListByUser: %v", err)
+		}
+		if len(page.Tasks) > query.Limit {
+			t.Fatalf("page of %d tasks, limit %d", len(page.Tasks), query.Limit)
+		}
+		total = page.Total
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		if page.NextCursor == "" {
+			return ids, total
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func TestListByUserPagination(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		// Deadlines and progress repeat so ties are broken by ID
+		deadline := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Hour)
+		for i := 0; i < 23; i++ {
+			task := createTask(t, repo, models.Task{
+				UserID:   "u1",
+				Title:    fmt.Sprintf("Task %d", i),
+				Deadline: deadline.Add(time.Duration(i%4) * 24 * time.Hour),
+				Steps:    newSteps("a", "b", "c", "d"),
+			})
+			for s := 0; s < i%5; s++ {
+				if _, err := repo.SetStepCompleted(ctx, "u1", task.ID, task.Steps[s].ID, true, time.Now()); err != nil {
+					t.Fatalf("SetStepCompleted: %v", err)
+				}
+			}
+		}
+		createTask(t, repo, models.Task{UserID: "u2", Title: "Not mine", Deadline: deadline, Steps: newSteps("a")})
+
+		page, err := repo.ListByUser(ctx, "u1", TaskQuery{Limit: MaxPageSize})
+		if err != nil {
+			t.Fatalf("ListByUser: %v", err)
+		}
+		tasks := page.Tasks
+		if len(tasks) != 23 || page.NextCursor != "" {
+			t.Fatalf("got %d tasks and cursor %q on one page, want 23 and none", len(tasks), page.NextCursor)
+		}
+
+		for _, s := range []TaskSort{SortCreated, SortUpdated, SortDeadline, SortProgress} {
+			for _, descending := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s descending=%v", s, descending), func(t *testing.T) {
+					got, total := collectPages(t, repo, TaskQuery{Sort: s, Descending: descending, Limit: 4})
+					if total != 23 {
+						t.Errorf("total = %d, want 23", total)
+					}
+					want := wantOrder(tasks, s, descending)
+					if fmt.Sprint(got) != fmt.Sprint(want) {
+						t.Errorf("order\n got %v\nwant %v", got, want)
+					}
+				})
+			}
+		}
+
+		t.Run("filtered", func(t *testing.T) {
+			min := 50
+			got, total := collectPages(t, repo, TaskQuery{
+				Filter: TaskFilter{ProgressMin: &min},
+				Sort:   SortDeadline,
+				Limit:  2,
+			})
+			var matching []models.Task
+			for _, task := range tasks {
+				if task.Progress >= min {
+					matching = append(matching, task)
+				}
+			}
+			if total != int64(len(matching)) {
+				t.Errorf("total = %d, want %d", total, len(matching))
+			}
+			if want := wantOrder(matching, SortDeadline, false); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("order\n got %v\nwant %v", got, want)
+			}
+		})
+	})
+}
+
+func TestListByUserRejectsForeignCursors(t *testing.T) {
+	forEachTaskRepository(t, func(t *testing.T, repo TaskRepository) {
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			createTask(t, repo, models.Task{UserID: "u1", Title: fmt.Sprintf("Task %d", i), Deadline: time.Now()})
+		}
+		page, err := repo.ListByUser(ctx, "u1", TaskQuery{Sort: SortDeadline, Limit: 1})
+		if err != nil || page.NextCursor == "" {
+			t.Fatalf("ListByUser = %v, %v, want a next cursor", page, err)
+		}
+		for _, query := range []TaskQuery{
+			{Sort: SortProgress, Limit: 1, Cursor: page.NextCursor},
+			{Sort: SortDeadline, Descending: true, Limit: 1, Cursor: page.NextCursor},
+			{Sort: SortDeadline, Limit: 1, Cursor: "%%%"},
+			{Sort: SortDeadline, Limit: 1, Cursor: "bm90IGpzb24"},
+		} {
+			if _, err := repo.ListByUser(ctx, "u1", query); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("ListByUser(%+v) = %v, want ErrInvalidCursor", query, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryTaskRepository keeps tasks in process memory. It is meant for local
+// development and tests; data is lost on restart.
+type MemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[primitive.ObjectID]models.Task
+	order []primitive.ObjectID
+}
+
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{tasks: make(map[primitive.ObjectID]models.Task)}
+}
+
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task.ID.IsZero() {
+		task.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.tasks[task.ID]; exists {
+		return ErrDuplicate
+	}
+	now := time.Now()
+	task.Touch(now)
+	task.Refresh(now)
+	r.tasks[task.ID] = cloneTask(*task)
+	r.order = append(r.order, task.ID)
+	return nil
+}
+
+func (r *MemoryTaskRepository) ListByUser(ctx context.Context, userID string, query TaskQuery) (*TaskPage, error) {
+	query.normalize()
+	cursor, err := decodePageCursor(query)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	var matched []models.Task
+	for _, id := range r.order {
+		task := r.tasks[id]
+		if task.UserID == userID && query.Filter.matches(task) {
+			matched = append(matched, cloneTask(task))
+		}
+	}
+	r.mu.RUnlock()
+
+	less := func(a, b models.Task) bool {
+		if query.Descending {
+			return compareTasks(query.Sort, a, b) > 0
+		}
+		return compareTasks(query.Sort, a, b) < 0
+	}
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	page := &TaskPage{Total: int64(len(matched))}
+	start := 0
+	if cursor != nil {
+		last := cursor.task()
+		for start < len(matched) && !less(last, matched[start]) {
+			start++
+		}
+	}
+	end := start + query.Limit
+	if end < len(matched) {
+		page.NextCursor = newPageCursor(query, matched[end-1]).encode()
+	} else {
+		end = len(matched)
+	}
+	page.Tasks = matched[start:end]
+	return page, nil
+}
+
+// Search approximates the Mongo text index by matching word prefixes
+func (r *MemoryTaskRepository) Search(ctx context.Context, userID, text string, limit int) ([]SearchHit, error) {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	var hits []SearchHit
+	for _, id := range r.order {
+		task := r.tasks[id]
+		if task.UserID != userID {
+			continue
+		}
+		if hit := scoreTask(terms, cloneTask(task)); hit.Score > 0 {
+			hits = append(hits, hit)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (r *MemoryTaskRepository) FindByID(ctx context.Context, userID string, taskID primitive.ObjectID) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return nil, ErrNotFound
+	}
+	task = cloneTask(task)
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) Update(ctx context.Context, userID string, taskID primitive.ObjectID, update TaskUpdate) (*models.Task, error) {
+	if update.Title == nil && update.Deadline == nil && update.Steps == nil {
+		return r.FindByID(ctx, userID, taskID)
+	}
+	return r.modify(userID, taskID, func(task *models.Task) error {
+		if update.Steps != nil && !task.UpdatedAt.Equal(update.StepsReadAt) {
+			return ErrConflict
+		}
+		if update.Title != nil {
+			task.Title = *update.Title
+		}
+		if update.Deadline != nil {
+			task.Deadline = *update.Deadline
+		}
+		if update.Steps != nil {
+			task.Steps = append([]models.Step(nil), update.Steps...)
+		}
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) SetStepCompleted(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, completed bool, at time.Time) (*models.Task, error) {
+	return r.modify(userID, taskID, func(task *models.Task) error {
+		i := stepIndex(task.Steps, stepID)
+		if i < 0 {
+			return ErrNotFound
+		}
+		task.Steps[i].IsCompleted = completed
+		task.Steps[i].UpdatedAt = at
+		if completed {
+			task.Steps[i].CompletedAt = &at
+			task.Steps[i].CompletedBy = userID
+		} else {
+			task.Steps[i].CompletedAt = nil
+			task.Steps[i].CompletedBy = ""
+		}
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) AddStep(ctx context.Context, userID string, taskID primitive.ObjectID, step models.Step, position int) (*models.Task, error) {
+	return r.modify(userID, taskID, func(task *models.Task) error {
+		if step.ID.IsZero() {
+			step.ID = primitive.NewObjectID()
+		}
+		if position < 0 || position > len(task.Steps) {
+			position = len(task.Steps)
+		}
+		task.Steps = append(task.Steps[:position], append([]models.Step{step}, task.Steps[position:]...)...)
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) UpdateStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, update StepUpdate) (*models.Task, error) {
+	if update.Title == nil && update.Description == nil {
+		task, err := r.FindByID(ctx, userID, taskID)
+		if err == nil && stepIndex(task.Steps, stepID) < 0 {
+			return nil, ErrNotFound
+		}
+		return task, err
+	}
+	return r.modify(userID, taskID, func(task *models.Task) error {
+		i := stepIndex(task.Steps, stepID)
+		if i < 0 {
+			return ErrNotFound
+		}
+		if update.Title != nil {
+			task.Steps[i].Title = *update.Title
+		}
+		if update.Description != nil {
+			task.Steps[i].Description = *update.Description
+		}
+		task.Steps[i].UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) MoveStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, position int) (*models.Task, error) {
+	return r.modify(userID, taskID, func(task *models.Task) error {
+		i := stepIndex(task.Steps, stepID)
+		if i < 0 {
+			return ErrNotFound
+		}
+		step := task.Steps[i]
+		rest := append(task.Steps[:i:i], task.Steps[i+1:]...)
+		if position < 0 {
+			position = 0
+		}
+		if position > len(rest) {
+			position = len(rest)
+		}
+		task.Steps = append(rest[:position:position], append([]models.Step{step}, rest[position:]...)...)
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) DeleteStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID) (*models.Task, error) {
+	return r.modify(userID, taskID, func(task *models.Task) error {
+		i := stepIndex(task.Steps, stepID)
+		if i < 0 {
+			return ErrNotFound
+		}
+		task.Steps = append(task.Steps[:i], task.Steps[i+1:]...)
+		return nil
+	})
+}
+
+// modify applies fn to a private copy of the task and stores the result only
+// if fn succeeds, mirroring the all-or-nothing behaviour of a Mongo update.
+// Every successful modification counts as a change to the task.
+func (r *MemoryTaskRepository) modify(userID string, taskID primitive.ObjectID, fn func(task *models.Task) error) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[taskID]
+	if !ok || stored.UserID != userID {
+		return nil, ErrNotFound
+	}
+	task := cloneTask(stored)
+	if err := fn(&task); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	task.Touch(now)
+	task.Refresh(now)
+	r.tasks[taskID] = task
+
+	task = cloneTask(task)
+	return &task, nil
+}
+
+func stepIndex(steps []models.Step, stepID primitive.ObjectID) int {
+	for i := range steps {
+		if steps[i].ID == stepID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *MemoryTaskRepository) Delete(ctx context.Context, userID string, taskID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.tasks, taskID)
+	for i, id := range r.order {
+		if id == taskID {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *MemoryTaskRepository) ListDue(ctx context.Context, from, to time.Time) ([]models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []models.Task
+	for _, id := range r.order {
+		task := r.tasks[id]
+		if task.Deadline.Before(from) || !task.Deadline.Before(to) {
+			continue
+		}
+		for _, step := range task.Steps {
+			if !step.IsCompleted {
+				tasks = append(tasks, cloneTask(task))
+				break
+			}
+		}
+	}
+	return tasks, nil
+}
+
+func (r *MemoryTaskRepository) MarkOverdue(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var modified int64
+	for id, task := range r.tasks {
+		if task.Deadline.Before(now) && task.Status != models.StatusDone && task.Status != models.StatusOverdue {
+			task.Status = models.StatusOverdue
+			r.tasks[id] = task
+			modified++
+		}
+	}
+	return modified, nil
+}
+
+// cloneTask copies the steps slice so callers never share memory with the store
+func cloneTask(task models.Task) models.Task {
+	task.Steps = append([]models.Step(nil), task.Steps...)
+	return task
+}
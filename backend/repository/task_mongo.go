@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoTaskRepository stores tasks in the "tasks" collection
+type MongoTaskRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTaskRepository(db *mongo.Database) *MongoTaskRepository {
+	return &MongoTaskRepository{collection: db.Collection("tasks")}
+}
+
+func (r *MongoTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if task.ID.IsZero() {
+		task.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	task.Touch(now)
+	task.Refresh(now)
+	_, err := r.collection.InsertOne(ctx, task)
+	return err
+}
+
+// EnsureIndexes creates the indexes backing the task list queries
+func (r *MongoTaskRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "deadline", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "progress", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "deadline", Value: 1}, {Key: "status", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "steps.title", Value: "text"},
+				{Key: "steps.description", Value: "text"},
+			},
+			Options: options.Index().SetName("task_text").SetWeights(bson.M{
+				"title":             weightTitle,
+				"steps.title":       weightStepTitle,
+				"steps.description": weightStepDescription,
+			}),
+		},
+	})
+	return err
+}
+
+func (r *MongoTaskRepository) ListByUser(ctx context.Context, userID string, query TaskQuery) (*TaskPage, error) {
+	query.normalize()
+	cursor, err := decodePageCursor(query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := taskFilterQuery(userID, query.Filter)
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	field := sortField(query.Sort)
+	direction := 1
+	if query.Descending {
+		direction = -1
+	}
+	sortSpec := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sortSpec = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+
+	if cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, keysetQuery(field, query.Descending, *cursor)}}
+	}
+
+	// Fetch one extra document to learn whether another page exists
+	opts := options.Find().SetSort(sortSpec).SetLimit(int64(query.Limit + 1))
+	found, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer found.Close(ctx)
+
+	var tasks []models.Task
+	if err = found.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	page := &TaskPage{Total: total}
+	if len(tasks) > query.Limit {
+		tasks = tasks[:query.Limit]
+		page.NextCursor = newPageCursor(query, tasks[len(tasks)-1]).encode()
+	}
+	page.Tasks = tasks
+	return page, nil
+}
+
+// Search uses the task_text index for ranking; which steps matched is worked
+// out in Go because the text score only covers the whole document
+func (r *MongoTaskRepository) Search(ctx context.Context, userID, text string, limit int) ([]SearchHit, error) {
+	filter := bson.M{"user_id": userID, "$text": bson.M{"$search": text}}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		models.Task `bson:",inline"`
+		Score       float64 `bson:"score"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	terms := tokenize(text)
+	hits := make([]SearchHit, len(results))
+	for i, result := range results {
+		hits[i] = scoreTask(terms, result.Task)
+		hits[i].Score = result.Score
+	}
+	return hits, nil
+}
+
+func taskFilterQuery(userID string, f TaskFilter) bson.M {
+	query := bson.M{"user_id": userID}
+	if len(f.Statuses) > 0 {
+		query["status"] = bson.M{"$in": f.Statuses}
+	}
+
+	deadline := bson.M{}
+	if f.DeadlineFrom != nil {
+		deadline["$gte"] = *f.DeadlineFrom
+	}
+	if f.DeadlineTo != nil {
+		deadline["$lt"] = *f.DeadlineTo
+	}
+	if len(deadline) > 0 {
+		query["deadline"] = deadline
+	}
+
+	progress := bson.M{}
+	if f.ProgressMin != nil {
+		progress["$gte"] = *f.ProgressMin
+	}
+	if f.ProgressMax != nil {
+		progress["$lte"] = *f.ProgressMax
+	}
+	if len(progress) > 0 {
+		query["progress"] = progress
+	}
+
+	if f.Title != "" {
+		query["title"] = bson.M{"$regex": regexp.QuoteMeta(f.Title), "$options": "i"}
+	}
+	return query
+}
+
+func sortField(sort TaskSort) string {
+	switch sort {
+	case SortDeadline:
+		return "deadline"
+	case SortCreated:
+		return "created_at"
+	case SortUpdated:
+		return "updated_at"
+	case SortProgress:
+		return "progress"
+	}
+	return "_id"
+}
+
+// keysetQuery matches the documents that sort strictly after the cursor
+func keysetQuery(field string, descending bool, c pageCursor) bson.M {
+	op := "$gt"
+	if descending {
+		op = "$lt"
+	}
+	if field == "_id" {
+		return bson.M{"_id": bson.M{op: c.ID}}
+	}
+
+	var value interface{}
+	switch field {
+	case "deadline":
+		value = time.UnixMilli(c.Deadline)
+	case "created_at":
+		value = time.UnixMilli(c.CreatedAt)
+	case "updated_at":
+		value = time.UnixMilli(c.UpdatedAt)
+	case "progress":
+		value = c.Progress
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: c.ID}},
+	}}
+}
+
+func (r *MongoTaskRepository) FindByID(ctx context.Context, userID string, taskID primitive.ObjectID) (*models.Task, error) {
+	var task models.Task
+	err := r.collection.FindOne(ctx, bson.M{
+		"_id":     taskID,
+		"user_id": userID,
+	}).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *MongoTaskRepository) Update(ctx context.Context, userID string, taskID primitive.ObjectID, update TaskUpdate) (*models.Task, error) {
+	set := bson.M{}
+	if update.Title != nil {
+		set["title"] = bson.M{"$literal": *update.Title}
+	}
+	if update.Deadline != nil {
+		set["deadline"] = *update.Deadline
+	}
+	filter := bson.M{"_id": taskID, "user_id": userID}
+	if update.Steps != nil {
+		models.StampNewSteps(update.Steps, time.Now())
+		set["steps"] = bson.M{"$literal": update.Steps}
+		filter["updated_at"] = update.StepsReadAt
+	}
+	if len(set) == 0 {
+		return r.FindByID(ctx, userID, taskID)
+	}
+
+	task, err := r.findOneAndUpdate(ctx, filter, set)
+	if errors.Is(err, ErrNotFound) && update.Steps != nil {
+		// Tell a task changed since it was read from one that is gone
+		if _, err := r.FindByID(ctx, userID, taskID); err != nil {
+			return nil, err
+		}
+		return nil, ErrConflict
+	}
+	return task, err
+}
+
+// SetStepCompleted marks a step done or not done. Completing records who and
+// when; un-completing clears both so the step looks untouched again.
+func (r *MongoTaskRepository) SetStepCompleted(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, completed bool, at time.Time) (*models.Task, error) {
+	step := bson.M{"$mergeObjects": bson.A{
+		withoutFields("$$this", "completed_at", "completed_by"),
+		bson.M{"is_completed": false, "updated_at": at},
+	}}
+	if completed {
+		step = bson.M{"$mergeObjects": bson.A{
+			"$$this",
+			bson.M{"is_completed": true, "updated_at": at, "completed_at": at, "completed_by": bson.M{"$literal": userID}},
+		}}
+	}
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": taskID, "user_id": userID, "steps._id": stepID},
+		bson.M{"steps": mapStep(stepID, step)},
+	)
+}
+
+func (r *MongoTaskRepository) AddStep(ctx context.Context, userID string, taskID primitive.ObjectID, step models.Step, position int) (*models.Task, error) {
+	if step.ID.IsZero() {
+		step.ID = primitive.NewObjectID()
+	}
+	steps := []models.Step{step}
+	models.StampNewSteps(steps, time.Now())
+	added := bson.M{"$literal": steps}
+
+	var updated interface{} = bson.M{"$concatArrays": bson.A{stepsOrEmpty, added}}
+	if position >= 0 {
+		updated = insertAt(stepsOrEmpty, added, position)
+	}
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": taskID, "user_id": userID},
+		bson.M{"steps": updated},
+	)
+}
+
+func (r *MongoTaskRepository) UpdateStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, update StepUpdate) (*models.Task, error) {
+	set := bson.M{}
+	if update.Title != nil {
+		set["title"] = bson.M{"$literal": *update.Title}
+	}
+	if update.Description != nil {
+		set["description"] = bson.M{"$literal": *update.Description}
+	}
+	filter := bson.M{"_id": taskID, "user_id": userID, "steps._id": stepID}
+	if len(set) == 0 {
+		var task models.Task
+		if err := r.collection.FindOne(ctx, filter).Decode(&task); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		return &task, nil
+	}
+	set["updated_at"] = time.Now()
+	return r.findOneAndUpdate(ctx, filter,
+		bson.M{"steps": mapStep(stepID, bson.M{"$mergeObjects": bson.A{"$$this", set}})},
+	)
+}
+
+// MoveStep pulls the step out and re-inserts it at position
+func (r *MongoTaskRepository) MoveStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID, position int) (*models.Task, error) {
+	if position < 0 {
+		position = 0
+	}
+	rest := bson.M{"$filter": bson.M{
+		"input": stepsOrEmpty,
+		"cond":  bson.M{"$ne": bson.A{"$$this._id", stepID}},
+	}}
+	moved := bson.M{"$filter": bson.M{
+		"input": stepsOrEmpty,
+		"cond":  bson.M{"$eq": bson.A{"$$this._id", stepID}},
+	}}
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": taskID, "user_id": userID, "steps._id": stepID},
+		bson.M{"steps": bson.M{"$let": bson.M{
+			"vars": bson.M{"rest": rest, "moved": moved},
+			"in":   insertAt("$$rest", "$$moved", position),
+		}}},
+	)
+}
+
+func (r *MongoTaskRepository) DeleteStep(ctx context.Context, userID string, taskID, stepID primitive.ObjectID) (*models.Task, error) {
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": taskID, "user_id": userID, "steps._id": stepID},
+		bson.M{"steps": bson.M{"$filter": bson.M{
+			"input": stepsOrEmpty,
+			"cond":  bson.M{"$ne": bson.A{"$$this._id", stepID}},
+		}}},
+	)
+}
+
+// findOneAndUpdate sets the fields in set, which are aggregation expressions,
+// then recomputes progress and status and stamps updated_at, all in one
+// pipeline update. The task is never stored with a stale status, and other
+// writers see either none or all of the change. Client values must be wrapped
+// in $literal, or a string starting with "$" would be read as a field path.
+func (r *MongoTaskRepository) findOneAndUpdate(ctx context.Context, filter, set bson.M) (*models.Task, error) {
+	now := time.Now()
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: set}}}
+	pipeline = append(pipeline, StatusPipeline(now)...)
+	pipeline = append(pipeline, touchStage(now))
+
+	var task models.Task
+	err := r.collection.FindOneAndUpdate(ctx, filter, pipeline,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// stepsOrEmpty is the steps array, empty for tasks stored without one
+var stepsOrEmpty = bson.M{"$ifNull": bson.A{"$steps", bson.A{}}}
+
+// mapStep rewrites the step with stepID to the expression step, in which
+// $$this is the current step
+func mapStep(stepID primitive.ObjectID, step interface{}) bson.M {
+	return bson.M{"$map": bson.M{
+		"input": stepsOrEmpty,
+		"in":    bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$this._id", stepID}}, step, "$$this"}},
+	}}
+}
+
+// withoutFields is the document doc without the given fields
+func withoutFields(doc string, fields ...string) bson.M {
+	return bson.M{"$arrayToObject": bson.M{"$filter": bson.M{
+		"input": bson.M{"$objectToArray": doc},
+		"as":    "field",
+		"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$field.k", fields}}}},
+	}}}
+}
+
+// insertAt is the array expression items spliced into list before position
+func insertAt(list, items interface{}, position int) bson.M {
+	return bson.M{"$concatArrays": bson.A{
+		bson.M{"$slice": bson.A{list, position}},
+		items,
+		// $slice needs a positive count, an empty tail is returned either way
+		bson.M{"$slice": bson.A{list, position, bson.M{"$max": bson.A{1, bson.M{"$size": list}}}}},
+	}}
+}
+
+// touchStage is the server-side twin of models.Task.Touch for updated_at,
+// which only moves forward so that it can be compared as a version
+func touchStage(now time.Time) bson.D {
+	return bson.D{{Key: "$set", Value: bson.M{
+		"updated_at": bson.M{"$max": bson.A{now, bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$updated_at", now}}, 1}}}},
+	}}}
+}
+
+// StatusPipeline is the server-side twin of models.Task.Refresh, setting
+// progress, status and completed_at. Computing from the stored document means
+// concurrent step updates cannot leave a stale status behind.
+func StatusPipeline(now time.Time) mongo.Pipeline {
+	steps := bson.M{"$ifNull": bson.A{"$steps", bson.A{}}}
+	total := bson.M{"$size": steps}
+	completed := bson.M{"$size": bson.M{"$filter": bson.M{
+		"input": steps,
+		"cond":  bson.M{"$eq": bson.A{"$$this.is_completed", true}},
+	}}}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"progress": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{total, 0}},
+				bson.M{"$toInt": bson.M{"$floor": bson.M{"$divide": bson.A{bson.M{"$multiply": bson.A{completed, 100}}, total}}}},
+				0,
+			}},
+			"status": bson.M{"$switch": bson.M{
+				"branches": bson.A{
+					bson.M{"case": bson.M{"$and": bson.A{bson.M{"$gt": bson.A{total, 0}}, bson.M{"$eq": bson.A{completed, total}}}}, "then": models.StatusDone},
+					bson.M{"case": bson.M{"$lt": bson.A{"$deadline", now}}, "then": models.StatusOverdue},
+					bson.M{"case": bson.M{"$gt": bson.A{completed, 0}}, "then": models.StatusInProgress},
+				},
+				"default": models.StatusTodo,
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"completed_at": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", models.StatusDone}},
+				bson.M{"$ifNull": bson.A{bson.M{"$max": "$steps.completed_at"}, bson.M{"$ifNull": bson.A{"$completed_at", "$$REMOVE"}}}},
+				"$$REMOVE",
+			}},
+		}}},
+	}
+}
+
+func (r *MongoTaskRepository) ListDue(ctx context.Context, from, to time.Time) ([]models.Task, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"deadline": bson.M{"$gte": from, "$lt": to},
+		"steps":    bson.M{"$elemMatch": bson.M{"is_completed": false}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *MongoTaskRepository) MarkOverdue(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{
+			"deadline": bson.M{"$lt": now},
+			"status":   bson.M{"$nin": bson.A{models.StatusDone, models.StatusOverdue}},
+		},
+		bson.M{"$set": bson.M{"status": models.StatusOverdue}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *MongoTaskRepository) Delete(ctx context.Context, userID string, taskID primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id":     taskID,
+		"user_id": userID,
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUsageRepository keeps AI usage records in process memory
+type MemoryUsageRepository struct {
+	mu       sync.Mutex
+	records  []models.AIUsage
+	requests map[usagePeriodKey]int64
+}
+
+type usagePeriodKey struct {
+	userID string
+	start  time.Time
+}
+
+func NewMemoryUsageRepository() *MemoryUsageRepository {
+	return &MemoryUsageRepository{requests: make(map[usagePeriodKey]int64)}
+}
+
+func (r *MemoryUsageRepository) ReserveRequest(ctx context.Context, userID string, start, end time.Time, limit int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := usagePeriodKey{userID, start.UTC()}
+	if limit > 0 && r.requests[key] >= limit {
+		return false, nil
+	}
+	r.requests[key]++
+	return true, nil
+}
+
+func (r *MemoryUsageRepository) ReleaseRequest(ctx context.Context, userID string, start time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := usagePeriodKey{userID, start.UTC()}
+	if r.requests[key] > 0 {
+		r.requests[key]--
+	}
+	return nil
+}
+
+func (r *MemoryUsageRepository) RequestCount(ctx context.Context, userID string, start time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.requests[usagePeriodKey{userID, start.UTC()}], nil
+}
+
+func (r *MemoryUsageRepository) Record(ctx context.Context, usage *models.AIUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if usage.ID.IsZero() {
+		usage.ID = primitive.NewObjectID()
+	}
+	r.records = append(r.records, *usage)
+	return nil
+}
+
+func (r *MemoryUsageRepository) Totals(ctx context.Context, userID string, since time.Time) (UsageTotals, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var totals UsageTotals
+	for _, usage := range r.records {
+		if usage.UserID != userID || usage.CreatedAt.Before(since) {
+			continue
+		}
+		totals.Requests++
+		if !usage.Success {
+			totals.Failed++
+		}
+		totals.PromptTokens += int64(usage.PromptTokens)
+		totals.OutputTokens += int64(usage.OutputTokens)
+		totals.TotalTokens += int64(usage.TotalTokens)
+	}
+	return totals, nil
+}
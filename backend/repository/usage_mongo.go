@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUsageRepository stores AI usage records in the "ai_usage" collection
+// and the per-period request counters behind quota reservations in
+// "ai_request_counters"
+type MongoUsageRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+}
+
+func NewMongoUsageRepository(db *mongo.Database) *MongoUsageRepository {
+	return &MongoUsageRepository{
+		collection: db.Collection("ai_usage"),
+		counters:   db.Collection("ai_request_counters"),
+	}
+}
+
+// EnsureIndexes creates the index behind the per-user totals and the TTL
+// index that drops counters of past periods
+func (r *MongoUsageRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.counters.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func counterID(userID string, start time.Time) string {
+	return userID + "/" + start.UTC().Format(time.RFC3339)
+}
+
+// ReserveRequest increments the counter only if it is below limit. When the
+// counter is full the filter misses and the upsert collides with the
+// existing document, which is how a full period shows up.
+func (r *MongoUsageRepository) ReserveRequest(ctx context.Context, userID string, start, end time.Time, limit int64) (bool, error) {
+	filter := bson.M{"_id": counterID(userID, start)}
+	if limit > 0 {
+		filter["requests"] = bson.M{"$lt": limit}
+	}
+	update := bson.M{
+		"$inc":         bson.M{"requests": 1},
+		"$setOnInsert": bson.M{"user_id": userID, "start": start.UTC(), "expires_at": end.UTC()},
+	}
+	// Two first requests of a period can both try to insert; the loser sees
+	// a duplicate key once, then finds the document on the retry
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := r.counters.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			return true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (r *MongoUsageRepository) ReleaseRequest(ctx context.Context, userID string, start time.Time) error {
+	_, err := r.counters.UpdateOne(ctx,
+		bson.M{"_id": counterID(userID, start), "requests": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"requests": -1}},
+	)
+	return err
+}
+
+func (r *MongoUsageRepository) RequestCount(ctx context.Context, userID string, start time.Time) (int64, error) {
+	var doc struct {
+		Requests int64 `bson:"requests"`
+	}
+	err := r.counters.FindOne(ctx, bson.M{"_id": counterID(userID, start)}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return doc.Requests, err
+}
+
+func (r *MongoUsageRepository) Record(ctx context.Context, usage *models.AIUsage) error {
+	if usage.ID.IsZero() {
+		usage.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, usage)
+	return err
+}
+
+func (r *MongoUsageRepository) Totals(ctx context.Context, userID string, since time.Time) (UsageTotals, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "created_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           nil,
+			"requests":      bson.M{"$sum": 1},
+			"failed":        bson.M{"$sum": bson.M{"$cond": bson.A{"$success", 0, 1}}},
+			"prompt_tokens": bson.M{"$sum": "$prompt_tokens"},
+			"output_tokens": bson.M{"$sum": "$output_tokens"},
+			"total_tokens":  bson.M{"$sum": "$total_tokens"},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return UsageTotals{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals UsageTotals
+	if cursor.Next(ctx) {
+		var doc struct {
+			Requests     int64 `bson:"requests"`
+			Failed       int64 `bson:"failed"`
+			PromptTokens int64 `bson:"prompt_tokens"`
+			OutputTokens int64 `bson:"output_tokens"`
+			TotalTokens  int64 `bson:"total_tokens"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return UsageTotals{}, err
+		}
+		totals = UsageTotals(doc)
+	}
+	return totals, cursor.Err()
+}
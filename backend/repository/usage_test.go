@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReserveRequest(t *testing.T) {
+	run := func(t *testing.T, repo UsageRepository) {
+		ctx := context.Background()
+		start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+		end := start.Add(24 * time.Hour)
+
+		// Concurrent callers get exactly limit reservations between them
+		var wg sync.WaitGroup
+		var granted atomic.Int64
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := repo.ReserveRequest(ctx, "ada", start, end, 3)
+				if err != nil {
+					t.Errorf("ReserveRequest: %v", err)
+				}
+				if ok {
+					granted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if granted.Load() != 3 {
+			t.Fatalf("%d concurrent reservations granted, want 3", granted.Load())
+		}
+
+		if err := repo.ReleaseRequest(ctx, "ada", start); err != nil {
+			t.Fatalf("ReleaseRequest: %v", err)
+		}
+		if ok, err := repo.ReserveRequest(ctx, "ada", start, end, 3); err != nil || !ok {
+			t.Errorf("ReserveRequest after a release = %v, %v, want a reservation", ok, err)
+		}
+		if ok, err := repo.ReserveRequest(ctx, "ada", start, end, 3); err != nil || ok {
+			t.Errorf("ReserveRequest in a full period = %v, %v, want none", ok, err)
+		}
+		if n, err := repo.RequestCount(ctx, "ada", start); err != nil || n != 3 {
+			t.Errorf("RequestCount of a full period = %d, %v, want 3", n, err)
+		}
+		if n, err := repo.RequestCount(ctx, "bob", start); err != nil || n != 0 {
+			t.Errorf("RequestCount without reservations = %d, %v, want 0", n, err)
+		}
+
+		// Periods, users and unlimited quotas are counted separately
+		if ok, err := repo.ReserveRequest(ctx, "ada", end, end.Add(24*time.Hour), 3); err != nil || !ok {
+			t.Errorf("ReserveRequest in the next period = %v, %v", ok, err)
+		}
+		if ok, err := repo.ReserveRequest(ctx, "bob", start, end, 3); err != nil || !ok {
+			t.Errorf("ReserveRequest for another user = %v, %v", ok, err)
+		}
+		if ok, err := repo.ReserveRequest(ctx, "ada", start, end, 0); err != nil || !ok {
+			t.Errorf("ReserveRequest without a limit = %v, %v", ok, err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryUsageRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoUsageRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
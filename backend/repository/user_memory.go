@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository keeps users in process memory
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[primitive.ObjectID]models.User)}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) UpdatePreferences(ctx context.Context, id primitive.ObjectID, prefs models.NotificationPreferences) error {
+	return r.update(id, func(user *models.User) {
+		user.NotificationPreferences = &prefs
+	})
+}
+
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, update UserUpdate) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if update.Email != nil {
+		for otherID, other := range r.users {
+			if otherID != id && other.Email == *update.Email {
+				return nil, ErrDuplicate
+			}
+		}
+		user.Email = *update.Email
+		user.EmailVerifiedAt = nil
+	}
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	r.users[id] = user
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) SetPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	return r.update(id, func(user *models.User) {
+		user.PasswordHash = passwordHash
+	})
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Email != email {
+		return ErrNotFound
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &at
+		r.users[id] = user
+	}
+	return nil
+}
+
+// update applies fn to one user under the write lock
+func (r *MemoryUserRepository) update(id primitive.ObjectID, fn func(user *models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&user)
+	r.users[id] = user
+	return nil
+}
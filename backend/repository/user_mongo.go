@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserRepository stores users in the "users" collection
+type MongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUserRepository(db *mongo.Database) *MongoUserRepository {
+	return &MongoUserRepository{collection: db.Collection("users")}
+}
+
+// EnsureIndexes creates the unique email index. Emails are stored
+// normalized, so this also rejects addresses differing only in case.
+func (r *MongoUserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *MongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *MongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoUserRepository) UpdatePreferences(ctx context.Context, id primitive.ObjectID, prefs models.NotificationPreferences) error {
+	return r.set(ctx, id, bson.M{"notification_preferences": prefs})
+}
+
+func (r *MongoUserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, update UserUpdate) (*models.User, error) {
+	set := bson.M{}
+	if update.Name != nil {
+		set["name"] = *update.Name
+	}
+	change := bson.M{}
+	if update.Email != nil {
+		set["email"] = *update.Email
+		change["$unset"] = bson.M{"email_verified_at": ""}
+	}
+	if len(set) == 0 {
+		return r.FindByID(ctx, id)
+	}
+	change["$set"] = set
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, change,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *MongoUserRepository) SetPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	return r.set(ctx, id, bson.M{"password": passwordHash})
+}
+
+func (r *MongoUserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error {
+	// Keep the original verification time if the address was already verified
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "email": email, "email_verified_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		_, err := r.findOne(ctx, bson.M{"_id": id, "email": email})
+		return err
+	}
+	return nil
+}
+
+// set applies a $set to one user, returning ErrNotFound if there is none
+func (r *MongoUserRepository) set(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
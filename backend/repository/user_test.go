@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUserUpdateProfile(t *testing.T) {
+	run := func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		verified := time.Now()
+		ada := &models.User{Name: "Ada", Email: "ada@example.com", PasswordHash: "hash", EmailVerifiedAt: &verified}
+		bob := &models.User{Name: "Bob", Email: "bob@example.com", PasswordHash: "hash"}
+		for _, user := range []*models.User{ada, bob} {
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		name := "Ada L."
+		got, err := repo.UpdateProfile(ctx, ada.ID, UserUpdate{Name: &name})
+		if err != nil || got.Name != name || got.Email != ada.Email || !got.EmailVerified() {
+			t.Fatalf("renaming = %+v, %v", got, err)
+		}
+
+		email := "lovelace@example.com"
+		got, err = repo.UpdateProfile(ctx, ada.ID, UserUpdate{Email: &email})
+		if err != nil || got.Email != email || got.Name != name || got.EmailVerified() {
+			t.Fatalf("changing the email = %+v, %v, want it unverified", got, err)
+		}
+		if found, err := repo.FindByEmail(ctx, email); err != nil || found.ID != ada.ID {
+			t.Errorf("FindByEmail(new address) = %+v, %v", found, err)
+		}
+
+		taken := bob.Email
+		if _, err := repo.UpdateProfile(ctx, ada.ID, UserUpdate{Email: &taken}); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("taking another user's email = %v, want ErrDuplicate", err)
+		}
+		if _, err := repo.UpdateProfile(ctx, primitive.NewObjectID(), UserUpdate{Name: &name}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("updating a missing user = %v, want ErrNotFound", err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryUserRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoUserRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
+
+func TestUserMarkEmailVerified(t *testing.T) {
+	run := func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		ada := &models.User{Name: "Ada", Email: "ada@example.com", PasswordHash: "hash"}
+		if err := repo.Create(ctx, ada); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		if err := repo.MarkEmailVerified(ctx, ada.ID, "old@example.com", time.Now()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("verifying an address the user no longer has = %v, want ErrNotFound", err)
+		}
+		if got, err := repo.FindByID(ctx, ada.ID); err != nil || got.EmailVerified() {
+			t.Fatalf("after a stale verification = %+v, %v, want it unverified", got, err)
+		}
+
+		first := time.Now().Truncate(time.Millisecond)
+		if err := repo.MarkEmailVerified(ctx, ada.ID, ada.Email, first); err != nil {
+			t.Fatalf("MarkEmailVerified: %v", err)
+		}
+		// Verifying again keeps the original time
+		if err := repo.MarkEmailVerified(ctx, ada.ID, ada.Email, first.Add(time.Hour)); err != nil {
+			t.Fatalf("MarkEmailVerified again: %v", err)
+		}
+		if got, err := repo.FindByID(ctx, ada.ID); err != nil || got.EmailVerifiedAt == nil || !got.EmailVerifiedAt.Equal(first) {
+			t.Errorf("verified user = %+v, %v, want verified at %v", got, err, first)
+		}
+
+		if err := repo.MarkEmailVerified(ctx, primitive.NewObjectID(), ada.Email, first); !errors.Is(err, ErrNotFound) {
+			t.Errorf("verifying a missing user = %v, want ErrNotFound", err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		run(t, NewMemoryUserRepository())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		repo := NewMongoUserRepository(testMongoDB(t))
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+		run(t, repo)
+	})
+}
@@ -6,28 +6,73 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine) {
+// SetupRoutes registers every route. requireAuth guards the protected groups
+// and limits throttles each group. The AI limit also covers task creation,
+// which generates steps.
+func SetupRoutes(router *gin.Engine, requireAuth gin.HandlerFunc, limits middleware.RateLimits, authController *controllers.AuthController, taskController *controllers.TaskController, notificationController *controllers.NotificationController, usageController *controllers.UsageController, healthController *controllers.HealthController) {
+	// Probes, outside every limit so orchestrators are never throttled
+	router.GET("/livez", healthController.Livez)
+	router.GET("/readyz", healthController.Readyz)
+	// Kept for existing monitors
+	router.GET("/health", healthController.Readyz)
+
 	// Auth routes
 	auth := router.Group("/auth")
+	auth.Use(limits.Auth)
 	{
-		auth.POST("/register", controllers.Register)
-		auth.POST("/login", controllers.Login)
+		auth.POST("/register", authController.Register)
+		auth.POST("/login", authController.Login)
+		auth.POST("/refresh", authController.Refresh)
+		auth.POST("/logout", authController.Logout)
+		auth.POST("/verify", authController.VerifyEmail)
+		auth.POST("/resend-verification", authController.ResendVerification)
+		auth.POST("/forgot-password", authController.ForgotPassword)
+		auth.POST("/reset-password", authController.ResetPassword)
+		auth.GET("/me", requireAuth, authController.Me)
+		auth.PATCH("/me", requireAuth, authController.UpdateMe)
+		auth.POST("/change-password", requireAuth, authController.ChangePassword)
 	}
 
-	// AI routes
+	// AI routes, authenticated so anonymous clients cannot spend the AI quota
 	ai := router.Group("/ai")
+	ai.Use(requireAuth, limits.AI)
 	{
-		ai.POST("/breakdown", controllers.BreakdownTask)
+		ai.POST("/breakdown", taskController.BreakdownTask)
 	}
 
 	// Protected task routes
 	tasks := router.Group("/tasks")
-	tasks.Use(middleware.AuthMiddleware())
+	tasks.Use(requireAuth, limits.API)
+	{
+		tasks.POST("/create", limits.AI, taskController.CreateTask)
+		tasks.GET("/", taskController.GetTasks)
+		tasks.GET("/search", taskController.SearchTasks)
+		tasks.GET("/:id", taskController.GetTask)
+		tasks.PATCH("/:id", taskController.UpdateTask)
+		tasks.PATCH("/:id/step/:stepID/complete", taskController.CompleteStep)
+		tasks.PATCH("/:id/step/:stepID/uncomplete", taskController.UncompleteStep)
+		tasks.POST("/:id/step", taskController.AddStep)
+		tasks.PATCH("/:id/step/:stepID", taskController.UpdateStep)
+		tasks.PATCH("/:id/step/:stepID/move", taskController.MoveStep)
+		tasks.DELETE("/:id/step/:stepID", taskController.DeleteStep)
+		tasks.DELETE("/:id", taskController.DeleteTask)
+	}
+
+	// Protected notification routes
+	notifications := router.Group("/notifications")
+	notifications.Use(requireAuth, limits.API)
+	{
+		notifications.GET("/", notificationController.GetNotifications)
+		notifications.PATCH("/:id/read", notificationController.MarkRead)
+		notifications.POST("/read-all", notificationController.MarkAllRead)
+		notifications.GET("/preferences", notificationController.GetPreferences)
+		notifications.PUT("/preferences", notificationController.UpdatePreferences)
+	}
+
+	// Protected AI usage route
+	usage := router.Group("/usage")
+	usage.Use(requireAuth, limits.API)
 	{
-		tasks.POST("/create", controllers.CreateTask)
-		tasks.GET("/", controllers.GetTasks)
-		tasks.GET("/:id", controllers.GetTask)
-		tasks.PATCH("/:taskID/step/:stepID/complete", controllers.CompleteStep)
-		tasks.DELETE("/:id", controllers.DeleteTask)
+		usage.GET("/", usageController.GetUsage)
 	}
 }
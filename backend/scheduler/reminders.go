@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ThresholdOverdue is the threshold of reminders sent after the deadline
+const ThresholdOverdue = "overdue"
+
+// Reminder is emitted once per task, threshold and deadline
+type Reminder struct {
+	TaskID          primitive.ObjectID
+	UserID          string
+	TaskTitle       string
+	Deadline        time.Time
+	Threshold       string // a lead time such as "24h0m0s", or ThresholdOverdue
+	IncompleteSteps int
+}
+
+// ReminderSink receives reminder events
+type ReminderSink interface {
+	Send(ctx context.Context, reminder Reminder) error
+}
+
+// RunReminders emits a reminder for every unfinished task whose deadline is
+// within the longest lead time or passed less than Lookback ago. Each task
+// gets only its most urgent threshold per run, and each threshold only once.
+func (s *Scheduler) RunReminders(ctx context.Context, now time.Time) (int, error) {
+	maxLead := s.cfg.LeadTimes[len(s.cfg.LeadTimes)-1]
+	tasks, err := s.tasks.ListDue(ctx, now.Add(-s.cfg.Lookback), now.Add(maxLead))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, task := range tasks {
+		threshold := s.threshold(task.Deadline, now)
+		if threshold == "" {
+			continue
+		}
+
+		key := repository.ReminderKey{TaskID: task.ID, Threshold: threshold, Deadline: task.Deadline}
+		claimed, err := s.reminders.Claim(ctx, key)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.sink.Send(ctx, newReminder(task, threshold)); err != nil {
+			// Give the reminder back so the next run retries it
+			if releaseErr := s.reminders.Release(ctx, key); releaseErr != nil {
+				return sent, releaseErr
+			}
+			slog.ErrorContext(ctx, "Failed to send reminder", "task_id", task.ID.Hex(), "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// threshold returns the most urgent threshold reached at now, or "" if none
+func (s *Scheduler) threshold(deadline, now time.Time) string {
+	if !now.Before(deadline) {
+		return ThresholdOverdue
+	}
+	remaining := deadline.Sub(now)
+	for _, lead := range s.cfg.LeadTimes {
+		if remaining <= lead {
+			return lead.String()
+		}
+	}
+	return ""
+}
+
+func newReminder(task models.Task, threshold string) Reminder {
+	incomplete := 0
+	for _, step := range task.Steps {
+		if !step.IsCompleted {
+			incomplete++
+		}
+	}
+	return Reminder{
+		TaskID:          task.ID,
+		UserID:          task.UserID,
+		TaskTitle:       task.Title,
+		Deadline:        task.Deadline,
+		Threshold:       threshold,
+		IncompleteSteps: incomplete,
+	}
+}
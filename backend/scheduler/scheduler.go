@@ -0,0 +1,139 @@
+// Package scheduler runs the periodic background jobs: flagging overdue
+// tasks and emitting deadline reminders.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"github.com/robfig/cron/v3"
+)
+
+// Config holds the job schedules (standard 5-field cron expressions) and the
+// reminder lead times
+type Config struct {
+	ReminderSpec string
+	OverdueSpec  string
+	// LeadTimes are the offsets before a deadline at which a reminder fires
+	LeadTimes []time.Duration
+	// Lookback bounds how long after a deadline an overdue reminder is still sent
+	Lookback time.Duration
+}
+
+// DefaultConfig checks every five minutes and reminds a day and an hour ahead
+func DefaultConfig() Config {
+	return Config{
+		ReminderSpec: "*/5 * * * *",
+		OverdueSpec:  "*/5 * * * *",
+		LeadTimes:    []time.Duration{24 * time.Hour, time.Hour},
+		Lookback:     72 * time.Hour,
+	}
+}
+
+// Validate checks the cron expressions and that there is at least one lead
+// time. A reminder can be sent as early as the longest lead time before the
+// deadline and must be remembered until the lookback after it, so together
+// they have to fit in repository.ReminderRetention.
+func (cfg Config) Validate() error {
+	if len(cfg.LeadTimes) == 0 {
+		return fmt.Errorf("at least one reminder lead time is required")
+	}
+	if window := slices.Max(cfg.LeadTimes) + cfg.Lookback; window >= repository.ReminderRetention {
+		return fmt.Errorf("the longest reminder lead time plus the lookback (%s) must be under %s", window, repository.ReminderRetention)
+	}
+	if _, err := cron.ParseStandard(cfg.OverdueSpec); err != nil {
+		return fmt.Errorf("invalid overdue schedule %q: %v", cfg.OverdueSpec, err)
+	}
+	if _, err := cron.ParseStandard(cfg.ReminderSpec); err != nil {
+		return fmt.Errorf("invalid reminder schedule %q: %v", cfg.ReminderSpec, err)
+	}
+	return nil
+}
+
+// ParseLeadTimes parses a comma-separated list of positive durations
+func ParseLeadTimes(raw string) ([]time.Duration, error) {
+	var leads []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		lead, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || lead <= 0 {
+			return nil, fmt.Errorf("invalid reminder lead time %q", part)
+		}
+		leads = append(leads, lead)
+	}
+	return leads, nil
+}
+
+// Scheduler owns the cron instance and its jobs
+type Scheduler struct {
+	cron      *cron.Cron
+	cfg       Config
+	tasks     repository.TaskRepository
+	reminders repository.ReminderRepository
+	sink      ReminderSink
+}
+
+// New validates the configuration and registers the jobs without starting them
+func New(cfg Config, tasks repository.TaskRepository, reminders repository.ReminderRepository, sink ReminderSink) (*Scheduler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	// Most urgent first, so a late run picks the tightest threshold
+	cfg.LeadTimes = append([]time.Duration(nil), cfg.LeadTimes...)
+	sort.Slice(cfg.LeadTimes, func(i, j int) bool { return cfg.LeadTimes[i] < cfg.LeadTimes[j] })
+
+	s := &Scheduler{
+		cron:      cron.New(),
+		cfg:       cfg,
+		tasks:     tasks,
+		reminders: reminders,
+		sink:      sink,
+	}
+
+	if _, err := s.cron.AddFunc(cfg.OverdueSpec, s.runJob("overdue", s.RunOverdue)); err != nil {
+		return nil, fmt.Errorf("invalid overdue schedule %q: %v", cfg.OverdueSpec, err)
+	}
+	if _, err := s.cron.AddFunc(cfg.ReminderSpec, s.runJob("reminders", s.RunReminders)); err != nil {
+		return nil, fmt.Errorf("invalid reminder schedule %q: %v", cfg.ReminderSpec, err)
+	}
+	return s, nil
+}
+
+// Start runs the jobs in the background
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop stops scheduling new runs; the returned context is done once running
+// jobs have finished
+func (s *Scheduler) Stop() context.Context {
+	return s.cron.Stop()
+}
+
+func (s *Scheduler) runJob(name string, job func(ctx context.Context, now time.Time) (int, error)) func() {
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		count, err := job(ctx, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Scheduler job failed", "job", name, "error", err)
+			return
+		}
+		if count > 0 {
+			slog.InfoContext(ctx, "Scheduler job finished", "job", name, "tasks", count)
+		}
+	}
+}
+
+// RunOverdue flags unfinished tasks whose deadline has passed. Deadlines pass
+// without any write touching the task, so this is the only place that happens.
+func (s *Scheduler) RunOverdue(ctx context.Context, now time.Time) (int, error) {
+	count, err := s.tasks.MarkOverdue(ctx, now)
+	return int(count), err
+}
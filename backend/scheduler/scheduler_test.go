@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordingSink collects reminders and fails while err is set
+type recordingSink struct {
+	sent []Reminder
+	err  error
+}
+
+func (s *recordingSink) Send(ctx context.Context, r Reminder) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, r)
+	return nil
+}
+
+func newTestScheduler(t *testing.T) (*Scheduler, *repository.MemoryTaskRepository, *recordingSink) {
+	t.Helper()
+	tasks := repository.NewMemoryTaskRepository()
+	sink := &recordingSink{}
+	s, err := New(DefaultConfig(), tasks, repository.NewMemoryReminderRepository(), sink)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s, tasks, sink
+}
+
+func addTask(t *testing.T, tasks repository.TaskRepository, title string, deadline time.Time, steps ...models.Step) models.Task {
+	t.Helper()
+	if steps == nil {
+		steps = []models.Step{{ID: primitive.NewObjectID(), Title: "a"}, {ID: primitive.NewObjectID(), Title: "b"}}
+	}
+	task := models.Task{UserID: "u1", Title: title, Deadline: deadline, Steps: steps}
+	if err := tasks.Create(context.Background(), &task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return task
+}
+
+func TestParseLeadTimes(t *testing.T) {
+	leads, err := ParseLeadTimes("24h, 90m,1h")
+	if err != nil || fmt.Sprint(leads) != "[24h0m0s 1h30m0s 1h0m0s]" {
+		t.Errorf("ParseLeadTimes = %v, %v", leads, err)
+	}
+	for _, raw := range []string{"", "soon", "1h,", "-1h", "0s"} {
+		if _, err := ParseLeadTimes(raw); err == nil {
+			t.Errorf("ParseLeadTimes(%q) accepted", raw)
+		}
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tasks, reminders := repository.NewMemoryTaskRepository(), repository.NewMemoryReminderRepository()
+
+	cfg := DefaultConfig()
+	cfg.LeadTimes = nil
+	if _, err := New(cfg, tasks, reminders, &recordingSink{}); err == nil {
+		t.Error("New accepted no lead times")
+	}
+
+	// Reminders sent that early could be forgotten and sent again
+	cfg = DefaultConfig()
+	cfg.LeadTimes = []time.Duration{time.Hour, repository.ReminderRetention - cfg.Lookback}
+	if _, err := New(cfg, tasks, reminders, &recordingSink{}); err == nil {
+		t.Error("New accepted lead times longer than the reminders are kept")
+	}
+
+	cfg = DefaultConfig()
+	cfg.ReminderSpec = "every minute"
+	if _, err := New(cfg, tasks, reminders, &recordingSink{}); err == nil {
+		t.Error("New accepted an invalid reminder schedule")
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	now := time.Now()
+	tests := []struct {
+		deadline time.Time
+		want     string
+	}{
+		{now.Add(48 * time.Hour), ""},
+		{now.Add(24 * time.Hour), "24h0m0s"},
+		{now.Add(2 * time.Hour), "24h0m0s"},
+		{now.Add(30 * time.Minute), "1h0m0s"},
+		{now, ThresholdOverdue},
+		{now.Add(-time.Hour), ThresholdOverdue},
+	}
+	for _, tt := range tests {
+		if got := s.threshold(tt.deadline, now); got != tt.want {
+			t.Errorf("threshold %v before the deadline = %q, want %q", tt.deadline.Sub(now), got, tt.want)
+		}
+	}
+}
+
+func TestRunRemindersSendsEachReminderOnce(t *testing.T) {
+	s, tasks, sink := newTestScheduler(t)
+	now := time.Now()
+	soon := addTask(t, tasks, "Soon", now.Add(30*time.Minute))
+	addTask(t, tasks, "Later", now.Add(72*time.Hour))
+	addTask(t, tasks, "Long overdue", now.Add(-96*time.Hour))
+	done := []models.Step{{ID: primitive.NewObjectID(), Title: "a", IsCompleted: true}}
+	addTask(t, tasks, "Finished", now.Add(30*time.Minute), done...)
+
+	for run := 1; run <= 2; run++ {
+		if _, err := s.RunReminders(context.Background(), now); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+	if len(sink.sent) != 1 {
+		t.Fatalf("sent %d reminders over two runs, want 1: %+v", len(sink.sent), sink.sent)
+	}
+	got := sink.sent[0]
+	if got.TaskID != soon.ID || got.Threshold != "1h0m0s" || got.IncompleteSteps != 2 || got.UserID != "u1" {
+		t.Errorf("reminder = %+v", got)
+	}
+
+	// The next threshold is a new reminder
+	if n, err := s.RunReminders(context.Background(), now.Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("run after the deadline = %d, %v, want 1", n, err)
+	}
+	if got := sink.sent[1].Threshold; got != ThresholdOverdue {
+		t.Errorf("threshold after the deadline = %q, want overdue", got)
+	}
+}
+
+func TestRunRemindersRetriesFailedSends(t *testing.T) {
+	s, tasks, sink := newTestScheduler(t)
+	now := time.Now()
+	addTask(t, tasks, "Soon", now.Add(30*time.Minute))
+
+	sink.err = errors.New("mail server down")
+	if n, err := s.RunReminders(context.Background(), now); err != nil || n != 0 {
+		t.Fatalf("failing run = %d, %v, want 0 sent and no error", n, err)
+	}
+
+	sink.err = nil
+	if n, err := s.RunReminders(context.Background(), now); err != nil || n != 1 {
+		t.Errorf("retry = %d, %v, want the reminder sent", n, err)
+	}
+}
+
+func TestRunRemindersRearmsOnNewDeadline(t *testing.T) {
+	s, tasks, sink := newTestScheduler(t)
+	now := time.Now()
+	task := addTask(t, tasks, "Soon", now.Add(30*time.Minute))
+	if _, err := s.RunReminders(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := now.Add(45 * time.Minute)
+	if _, err := tasks.Update(context.Background(), "u1", task.ID, repository.TaskUpdate{Deadline: &deadline}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RunReminders(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.sent) != 2 || !sink.sent[1].Deadline.Equal(deadline) {
+		t.Errorf("sent %+v, want a second reminder for the new deadline", sink.sent)
+	}
+}
+
+func TestRunOverdue(t *testing.T) {
+	s, tasks, _ := newTestScheduler(t)
+	now := time.Now()
+	task := addTask(t, tasks, "Soon", now.Add(time.Minute))
+
+	if n, err := s.RunOverdue(context.Background(), now.Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("RunOverdue = %d, %v, want 1", n, err)
+	}
+	got, err := tasks.FindByID(context.Background(), "u1", task.ID)
+	if err != nil || got.Status != models.StatusOverdue {
+		t.Errorf("task after RunOverdue = %+v, %v", got, err)
+	}
+}
@@ -2,84 +2,124 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/Vanaraj10/taskmorph-backend/models"
 )
 
-type Step struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	IsCompleted bool   `json:"is_completed"` // Completed indicates if the step is done
+const (
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	defaultGeminiModel   = "gemini-1.5-flash"
+)
+
+// GeminiGenerator generates steps with Google's Gemini generateContent API
+type GeminiGenerator struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
 }
 
-func AskGemini(taskTitle string) ([]models.Step, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY is missing")
+func NewGeminiGenerator(cfg GeneratorConfig) *GeminiGenerator {
+	g := &GeminiGenerator{
+		apiKey:  cfg.APIKey,
+		model:   cfg.Model,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}
+	if g.model == "" {
+		g.model = defaultGeminiModel
 	}
+	if g.baseURL == "" {
+		g.baseURL = defaultGeminiBaseURL
+	}
+	return g
+}
 
-	prompt := fmt.Sprintf(`Break the task "%s" into 5 short steps. 
-Respond ONLY as raw JSON array: 
-[{"title": "Step 1", "description": "..."}, ...]`, taskTitle)
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (g *GeminiGenerator) GenerateSteps(ctx context.Context, taskTitle string) ([]models.Step, Usage, error) {
+	usage := Usage{Model: g.model}
+	if g.apiKey == "" {
+		return nil, usage, fmt.Errorf("GEMINI_API_KEY is missing")
+	}
 
 	reqBody := map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
 				"parts": []map[string]string{
-					{"text": prompt},
+					{"text": buildPrompt(taskTitle)},
 				},
 				"role": "user",
 			},
 		},
 	}
 
-	jsonBody, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequest("POST", "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent?key="+apiKey, bytes.NewBuffer(jsonBody))
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, usage, err
+	}
+	// The key goes in a header rather than the URL, which ends up in errors
+	url := fmt.Sprintf("%s/models/%s:generateContent", g.baseURL, g.model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, usage, err
+	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", g.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, usage, err
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, usage, err
+	}
 
-	// 🔎 Print raw response
-	fmt.Println("🧪 Gemini Raw:", string(bodyBytes))
+	slog.DebugContext(ctx, "Gemini response", "status", resp.StatusCode, "bytes", len(bodyBytes))
+	if err := checkStatus("gemini", resp, bodyBytes); err != nil {
+		return nil, usage, err
+	}
 
-	var parsed map[string]interface{}
+	var parsed geminiResponse
 	if err := json.Unmarshal(bodyBytes, &parsed); err != nil {
-		return nil, err
+		return nil, usage, err
 	}
-
-	candidates, ok := parsed["candidates"].([]interface{})
-	if !ok || len(candidates) == 0 {
-		return nil, fmt.Errorf("No candidates returned")
+	usage.PromptTokens = parsed.UsageMetadata.PromptTokenCount
+	usage.OutputTokens = parsed.UsageMetadata.CandidatesTokenCount
+	usage.TotalTokens = parsed.UsageMetadata.TotalTokenCount
+	if parsed.Error != nil {
+		return nil, usage, fmt.Errorf("gemini error: %s", parsed.Error.Message)
 	}
-
-	content := candidates[0].(map[string]interface{})["content"]
-	parts := content.(map[string]interface{})["parts"].([]interface{})
-	text := parts[0].(map[string]interface{})["text"].(string)
-
-	// 🧹 Clean Markdown wrapper
-	clean := strings.TrimSpace(text)
-	clean = strings.TrimPrefix(clean, "```json")
-	clean = strings.TrimSuffix(clean, "```")
-	clean = strings.TrimSpace(clean)
-
-	// ✅ Parse the cleaned JSON string
-	var steps []models.Step
-	if err := json.Unmarshal([]byte(clean), &steps); err != nil {
-		return nil, fmt.Errorf("Failed to parse steps: %v", err)
+	if len(parsed.Candidates) == 0 || len(parsed.Candidates[0].Content.Parts) == 0 {
+		return nil, usage, fmt.Errorf("no candidates returned")
 	}
 
-	return steps, nil
-}
\ No newline at end of file
+	steps, err := parseSteps(parsed.Candidates[0].Content.Parts[0].Text)
+	return steps, usage, err
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// StepGenerator breaks a task title down into actionable steps. It reports
+// the usage of the call even when it fails, as far as it is known.
+type StepGenerator interface {
+	GenerateSteps(ctx context.Context, taskTitle string) ([]models.Step, Usage, error)
+}
+
+// Usage is what one generation cost: the model that ran and the tokens the
+// provider counted. Token counts are zero when the provider reports none.
+type Usage struct {
+	Model        string
+	PromptTokens int
+	OutputTokens int
+	TotalTokens  int
+}
+
+const (
+	ProviderGemini  = "gemini"
+	ProviderOpenAI  = "openai"
+	ProviderOffline = "offline"
+)
+
+// GeneratorConfig selects and configures the AI provider used for breakdowns
+type GeneratorConfig struct {
+	Provider string
+	APIKey   string
+	Model    string
+	BaseURL  string
+	Timeout  time.Duration
+}
+
+// NewStepGenerator returns the StepGenerator for the configured provider
+func NewStepGenerator(cfg GeneratorConfig) (StepGenerator, error) {
+	switch cfg.Provider {
+	case ProviderGemini:
+		return NewGeminiGenerator(cfg), nil
+	case ProviderOpenAI:
+		return NewOpenAIGenerator(cfg), nil
+	case ProviderOffline:
+		return NewOfflineGenerator(), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q", cfg.Provider)
+	}
+}
+
+// buildPrompt is the instruction shared by every remote provider
+func buildPrompt(taskTitle string) string {
+	return fmt.Sprintf(`Break the task "%s" into 5 short steps.
+Respond ONLY as raw JSON array:
+[{"title": "Step 1", "description": "..."}, ...]`, taskTitle)
+}
+
+// parseSteps decodes the JSON array returned by a model, tolerating a
+// Markdown code fence around it
+func parseSteps(text string) ([]models.Step, error) {
+	clean := strings.TrimSpace(text)
+	clean = strings.TrimPrefix(clean, "```json")
+	clean = strings.TrimPrefix(clean, "```")
+	clean = strings.TrimSuffix(clean, "```")
+	clean = strings.TrimSpace(clean)
+
+	var steps []models.Step
+	if err := json.Unmarshal([]byte(clean), &steps); err != nil {
+		return nil, fmt.Errorf("failed to parse steps: %v", err)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("model returned no steps")
+	}
+	return steps, nil
+}
+
+// checkStatus turns a non-2xx provider response into an error, quoting the
+// provider's message when the body has the usual {"error": {"message": ...}}
+func checkStatus(provider string, resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var parsed struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error != nil && parsed.Error.Message != "" {
+		return fmt.Errorf("%s returned %s: %s", provider, resp.Status, parsed.Error.Message)
+	}
+	return fmt.Errorf("%s returned %s", provider, resp.Status)
+}
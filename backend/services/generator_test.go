@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	for _, text := range []string{
+		`[{"title": "Plan", "description": "Write it down"}, {"title": "Do"}]`,
+		"```json\n[{\"title\": \"Plan\", \"description\": \"Write it down\"}, {\"title\": \"Do\"}]\n```",
+		"```\n[{\"title\": \"Plan\", \"description\": \"Write it down\"}, {\"title\": \"Do\"}]```  ",
+	} {
+		steps, err := parseSteps(text)
+		if err != nil || len(steps) != 2 || steps[0].Title != "Plan" || steps[0].Description != "Write it down" || steps[1].Title != "Do" {
+			t.Errorf("parseSteps(%q) = %+v, %v", text, steps, err)
+		}
+	}
+
+	for _, text := range []string{"", "[]", "Here are your steps:", `{"title": "Plan"}`} {
+		if steps, err := parseSteps(text); err == nil {
+			t.Errorf("parseSteps(%q) = %+v, want an error", text, steps)
+		}
+	}
+}
+
+func TestOfflineGenerator(t *testing.T) {
+	steps, _, err := NewOfflineGenerator().GenerateSteps(context.Background(), "  Launch  ")
+	if err != nil || len(steps) != 5 {
+		t.Fatalf("GenerateSteps() = %d steps, %v, want 5", len(steps), err)
+	}
+	for _, step := range steps {
+		if step.Title == "" || !strings.Contains(step.Description, `"Launch"`) {
+			t.Errorf("step %+v does not mention the trimmed title", step)
+		}
+	}
+	if _, _, err := NewOfflineGenerator().GenerateSteps(context.Background(), " "); err == nil {
+		t.Error("an empty title gave steps")
+	}
+}
+
+func TestNewStepGenerator(t *testing.T) {
+	for provider, want := range map[string]string{
+		ProviderGemini:  "*services.GeminiGenerator",
+		ProviderOpenAI:  "*services.OpenAIGenerator",
+		ProviderOffline: "*services.OfflineGenerator",
+	} {
+		gen, err := NewStepGenerator(GeneratorConfig{Provider: provider})
+		if got := fmt.Sprintf("%T", gen); err != nil || got != want {
+			t.Errorf("NewStepGenerator(%s) = %s, %v, want a %s", provider, got, err, want)
+		}
+	}
+	if _, err := NewStepGenerator(GeneratorConfig{Provider: "claude"}); err == nil {
+		t.Error("an unknown provider gave a generator")
+	}
+}
+
+// providerServer serves body with status to every request, recording the
+// last one
+func providerServer(t *testing.T, status int, body string, last **http.Request) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("request body: %v", err)
+		}
+		if last != nil {
+			*last = r
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+const modelSteps = `[{\"title\": \"Plan\", \"description\": \"Write it down\"}]`
+
+func TestOpenAIGenerator(t *testing.T) {
+	var req *http.Request
+	srv := providerServer(t, http.StatusOK, `{"choices": [{"message": {"content": "`+modelSteps+`"}}],
+		"usage": {"prompt_tokens": 40, "completion_tokens": 12, "total_tokens": 52}}`, &req)
+	gen := NewOpenAIGenerator(GeneratorConfig{APIKey: "sk-test", Model: "gpt-test", BaseURL: srv.URL + "/"})
+	steps, usage, err := gen.GenerateSteps(context.Background(), "Launch")
+	if err != nil || len(steps) != 1 || steps[0].Title != "Plan" {
+		t.Fatalf("GenerateSteps() = %+v, %v", steps, err)
+	}
+	if usage != (Usage{Model: "gpt-test", PromptTokens: 40, OutputTokens: 12, TotalTokens: 52}) {
+		t.Errorf("usage = %+v", usage)
+	}
+	if req.URL.Path != "/chat/completions" || req.Header.Get("Authorization") != "Bearer sk-test" {
+		t.Errorf("request to %s with Authorization %q", req.URL.Path, req.Header.Get("Authorization"))
+	}
+
+	for name, tt := range map[string]struct {
+		status int
+		body   string
+		want   string
+	}{
+		"provider error": {http.StatusBadRequest, `{"error": {"message": "model not found"}}`, "openai returned 400 Bad Request: model not found"},
+		"gateway error":  {http.StatusBadGateway, `<html>Bad Gateway</html>`, "openai returned 502 Bad Gateway"},
+		"error status":   {http.StatusServiceUnavailable, `{"choices": [{"message": {"content": "` + modelSteps + `"}}]}`, "openai returned 503 Service Unavailable"},
+		"no choices":     {http.StatusOK, `{"choices": []}`, "no choices returned"},
+		"malformed body": {http.StatusOK, `{"choices": [`, "unexpected end of JSON input"},
+		"not JSON steps": {http.StatusOK, `{"choices": [{"message": {"content": "Sure! Step one..."}}]}`, "failed to parse steps"},
+	} {
+		srv := providerServer(t, tt.status, tt.body, nil)
+		gen := NewOpenAIGenerator(GeneratorConfig{BaseURL: srv.URL})
+		if steps, _, err := gen.GenerateSteps(context.Background(), "Launch"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: GenerateSteps() = %+v, %v, want an error containing %q", name, steps, err, tt.want)
+		}
+	}
+}
+
+func TestGeminiGenerator(t *testing.T) {
+	var req *http.Request
+	srv := providerServer(t, http.StatusOK, `{"candidates": [{"content": {"parts": [{"text": "`+modelSteps+`"}]}}],
+		"usageMetadata": {"promptTokenCount": 30, "candidatesTokenCount": 9, "totalTokenCount": 39}}`, &req)
+	gen := NewGeminiGenerator(GeneratorConfig{APIKey: "g-test", Model: "gemini-test", BaseURL: srv.URL})
+	steps, usage, err := gen.GenerateSteps(context.Background(), "Launch")
+	if err != nil || len(steps) != 1 || steps[0].Title != "Plan" {
+		t.Fatalf("GenerateSteps() = %+v, %v", steps, err)
+	}
+	if usage != (Usage{Model: "gemini-test", PromptTokens: 30, OutputTokens: 9, TotalTokens: 39}) {
+		t.Errorf("usage = %+v", usage)
+	}
+	if req.URL.Path != "/models/gemini-test:generateContent" || req.Header.Get("x-goog-api-key") != "g-test" {
+		t.Errorf("request to %s with x-goog-api-key %q", req.URL, req.Header.Get("x-goog-api-key"))
+	}
+	if req.URL.RawQuery != "" {
+		t.Errorf("the API key leaked into the URL: %s", req.URL)
+	}
+
+	if _, _, err := NewGeminiGenerator(GeneratorConfig{BaseURL: srv.URL}).GenerateSteps(context.Background(), "Launch"); err == nil {
+		t.Error("a missing API key gave steps")
+	}
+
+	for name, tt := range map[string]struct {
+		status int
+		body   string
+		want   string
+	}{
+		"provider error": {http.StatusForbidden, `{"error": {"message": "API key not valid"}}`, "gemini returned 403 Forbidden: API key not valid"},
+		"rate limited":   {http.StatusTooManyRequests, ``, "gemini returned 429 Too Many Requests"},
+		"error status":   {http.StatusInternalServerError, `{"candidates": [{"content": {"parts": [{"text": "` + modelSteps + `"}]}}]}`, "gemini returned 500 Internal Server Error"},
+		"no candidates":  {http.StatusOK, `{"candidates": []}`, "no candidates returned"},
+		"no parts":       {http.StatusOK, `{"candidates": [{"content": {"parts": []}}]}`, "no candidates returned"},
+		"malformed body": {http.StatusOK, `<html>`, "invalid character"},
+	} {
+		srv := providerServer(t, tt.status, tt.body, nil)
+		gen := NewGeminiGenerator(GeneratorConfig{APIKey: "g-test", BaseURL: srv.URL})
+		if steps, _, err := gen.GenerateSteps(context.Background(), "Launch"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: GenerateSteps() = %+v, %v, want an error containing %q", name, steps, err, tt.want)
+		}
+	}
+}
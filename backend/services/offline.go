@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+// OfflineGenerator returns a fixed five-step plan built from the task title.
+// It never touches the network, so it is used for local development and tests.
+type OfflineGenerator struct{}
+
+func NewOfflineGenerator() *OfflineGenerator {
+	return &OfflineGenerator{}
+}
+
+var offlineTemplates = []struct{ title, description string }{
+	{"Define the goal", "Write down what \"%s\" means when it is done."},
+	{"Gather resources", "Collect the information and tools needed for \"%s\"."},
+	{"Plan the work", "Split \"%s\" into a short ordered checklist."},
+	{"Do the work", "Work through the checklist for \"%s\"."},
+	{"Review and wrap up", "Check the result of \"%s\" and close any loose ends."},
+}
+
+func (g *OfflineGenerator) GenerateSteps(ctx context.Context, taskTitle string) ([]models.Step, Usage, error) {
+	usage := Usage{Model: ProviderOffline}
+	title := strings.TrimSpace(taskTitle)
+	if title == "" {
+		return nil, usage, fmt.Errorf("task title is empty")
+	}
+
+	steps := make([]models.Step, len(offlineTemplates))
+	for i, t := range offlineTemplates {
+		steps[i] = models.Step{
+			Title:       t.title,
+			Description: fmt.Sprintf(t.description, title),
+		}
+	}
+	return steps, usage, nil
+}
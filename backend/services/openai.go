@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIGenerator generates steps with any OpenAI-compatible
+// /chat/completions endpoint (OpenAI, Azure proxies, Ollama, vLLM, ...)
+type OpenAIGenerator struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+func NewOpenAIGenerator(cfg GeneratorConfig) *OpenAIGenerator {
+	g := &OpenAIGenerator{
+		apiKey:  cfg.APIKey,
+		model:   cfg.Model,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}
+	if g.model == "" {
+		g.model = defaultOpenAIModel
+	}
+	if g.baseURL == "" {
+		g.baseURL = defaultOpenAIBaseURL
+	}
+	return g
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (g *OpenAIGenerator) GenerateSteps(ctx context.Context, taskTitle string) ([]models.Step, Usage, error) {
+	usage := Usage{Model: g.model}
+	reqBody := map[string]interface{}{
+		"model": g.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": buildPrompt(taskTitle)},
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, usage, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, usage, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Local OpenAI-compatible servers usually run without a key
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, usage, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	slog.DebugContext(ctx, "OpenAI response", "status", resp.StatusCode, "bytes", len(bodyBytes))
+	if err := checkStatus("openai", resp, bodyBytes); err != nil {
+		return nil, usage, err
+	}
+
+	var parsed openAIResponse
+	if err := json.Unmarshal(bodyBytes, &parsed); err != nil {
+		return nil, usage, err
+	}
+	usage.PromptTokens = parsed.Usage.PromptTokens
+	usage.OutputTokens = parsed.Usage.CompletionTokens
+	usage.TotalTokens = parsed.Usage.TotalTokens
+	if parsed.Error != nil {
+		return nil, usage, fmt.Errorf("openai error: %s", parsed.Error.Message)
+	}
+	if len(parsed.Choices) == 0 {
+		return nil, usage, fmt.Errorf("no choices returned")
+	}
+
+	steps, err := parseSteps(parsed.Choices[0].Message.Content)
+	return steps, usage, err
+}
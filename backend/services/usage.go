@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+// QuotaConfig caps how much AI generation a user gets per UTC day and month.
+// A limit of 0 means unlimited.
+type QuotaConfig struct {
+	DailyRequests   int64
+	MonthlyRequests int64
+	DailyTokens     int64
+	MonthlyTokens   int64
+}
+
+// QuotaError is returned by UsageMeter.GenerateSteps when the user has used
+// up a quota. The generator is not called.
+type QuotaError struct {
+	Period   string // "daily" or "monthly"
+	Resource string // "requests" or "tokens"
+	Limit    int64
+	ResetsAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s AI %s quota of %d reached", e.Period, e.Resource, e.Limit)
+}
+
+// UsagePeriod is a user's consumption in one quota period. Limits of 0 are
+// unlimited.
+type UsagePeriod struct {
+	Start        time.Time `json:"start"`
+	ResetsAt     time.Time `json:"resets_at"`
+	Requests     int64     `json:"requests"`
+	Failed       int64     `json:"failed"`
+	PromptTokens int64     `json:"prompt_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	TotalTokens  int64     `json:"total_tokens"`
+	RequestLimit int64     `json:"request_limit"`
+	TokenLimit   int64     `json:"token_limit"`
+	RequestsLeft *int64    `json:"requests_left"`
+	TokensLeft   *int64    `json:"tokens_left"`
+}
+
+// UsageReport is what /usage returns
+type UsageReport struct {
+	Daily   UsagePeriod `json:"daily"`
+	Monthly UsagePeriod `json:"monthly"`
+}
+
+// UsageMeter wraps the StepGenerator with per-user accounting. Every call is
+// recorded with its token counts, latency and outcome, and calls beyond the
+// daily or monthly quota are refused.
+//
+// Each call reserves its place in the request quotas atomically before the
+// provider is called, so concurrent calls cannot exceed them. Failed calls
+// count as requests too: the provider may bill for them, and it stops a
+// client retrying a failing call without limit. Token counts are only known
+// afterwards, so token quotas are checked before the call and concurrent
+// calls or a large final response can overshoot them slightly.
+type UsageMeter struct {
+	cfg       QuotaConfig
+	provider  string
+	generator StepGenerator
+	usage     repository.UsageRepository
+}
+
+func NewUsageMeter(cfg QuotaConfig, provider string, generator StepGenerator, usage repository.UsageRepository) *UsageMeter {
+	return &UsageMeter{cfg: cfg, provider: provider, generator: generator, usage: usage}
+}
+
+// GenerateSteps generates steps on behalf of a user, returning a *QuotaError
+// if they are out of quota
+func (m *UsageMeter) GenerateSteps(ctx context.Context, userID, operation, taskTitle string) ([]models.Step, error) {
+	start := time.Now()
+	report, err := m.Report(ctx, userID, start)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkTokenQuota(report); err != nil {
+		return nil, err
+	}
+	if err := m.reserveRequest(ctx, userID, report); err != nil {
+		return nil, err
+	}
+
+	steps, usage, genErr := m.generator.GenerateSteps(ctx, taskTitle)
+	record := models.AIUsage{
+		UserID:       userID,
+		Operation:    operation,
+		Provider:     m.provider,
+		Model:        usage.Model,
+		PromptTokens: usage.PromptTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.TotalTokens,
+		LatencyMS:    time.Since(start).Milliseconds(),
+		Success:      genErr == nil,
+		CreatedAt:    start,
+	}
+	if genErr != nil {
+		record.Error = genErr.Error()
+		slog.WarnContext(ctx, "AI generation failed", "provider", m.provider, "model", usage.Model, "latency_ms", record.LatencyMS, "error", genErr)
+	}
+	// Record even if the client went away; the provider call happened
+	if err := m.usage.Record(context.WithoutCancel(ctx), &record); err != nil {
+		slog.ErrorContext(ctx, "Failed to record AI usage", "user_id", userID, "error", err)
+	}
+	return steps, genErr
+}
+
+// Report sums the user's usage in the current UTC day and month. Requests
+// are read from the same counters the request quotas are enforced on, so
+// requests_left matches what the next call will find; token counts and
+// failures come from the usage records.
+func (m *UsageMeter) Report(ctx context.Context, userID string, now time.Time) (*UsageReport, error) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	daily, err := m.totals(ctx, userID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := m.totals(ctx, userID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageReport{
+		Daily:   newUsagePeriod(daily, dayStart, dayStart.AddDate(0, 0, 1), m.cfg.DailyRequests, m.cfg.DailyTokens),
+		Monthly: newUsagePeriod(monthly, monthStart, monthStart.AddDate(0, 1, 0), m.cfg.MonthlyRequests, m.cfg.MonthlyTokens),
+	}, nil
+}
+
+// totals sums the period starting at start, taking the request count from
+// its quota counter
+func (m *UsageMeter) totals(ctx context.Context, userID string, start time.Time) (repository.UsageTotals, error) {
+	totals, err := m.usage.Totals(ctx, userID, start)
+	if err != nil {
+		return totals, err
+	}
+	totals.Requests, err = m.usage.RequestCount(ctx, userID, start)
+	return totals, err
+}
+
+type namedPeriod struct {
+	name   string
+	period UsagePeriod
+}
+
+func (r *UsageReport) periods() []namedPeriod {
+	return []namedPeriod{{"daily", r.Daily}, {"monthly", r.Monthly}}
+}
+
+func (m *UsageMeter) checkTokenQuota(report *UsageReport) error {
+	for _, p := range report.periods() {
+		if p.period.TokensLeft != nil && *p.period.TokensLeft == 0 {
+			return &QuotaError{Period: p.name, Resource: "tokens", Limit: p.period.TokenLimit, ResetsAt: p.period.ResetsAt}
+		}
+	}
+	return nil
+}
+
+// reserveRequest counts the call in the daily and then the monthly request
+// quota. If the month is full, the day's reservation is given back.
+func (m *UsageMeter) reserveRequest(ctx context.Context, userID string, report *UsageReport) error {
+	periods := report.periods()
+	for i, p := range periods {
+		ok, err := m.usage.ReserveRequest(ctx, userID, p.period.Start, p.period.ResetsAt, p.period.RequestLimit)
+		if err == nil && ok {
+			continue
+		}
+		for _, reserved := range periods[:i] {
+			if err := m.usage.ReleaseRequest(context.WithoutCancel(ctx), userID, reserved.period.Start); err != nil {
+				slog.ErrorContext(ctx, "Failed to release AI request reservation", "user_id", userID, "error", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return &QuotaError{Period: p.name, Resource: "requests", Limit: p.period.RequestLimit, ResetsAt: p.period.ResetsAt}
+	}
+	return nil
+}
+
+func newUsagePeriod(totals repository.UsageTotals, start, resetsAt time.Time, requestLimit, tokenLimit int64) UsagePeriod {
+	return UsagePeriod{
+		Start:        start,
+		ResetsAt:     resetsAt,
+		Requests:     totals.Requests,
+		Failed:       totals.Failed,
+		PromptTokens: totals.PromptTokens,
+		OutputTokens: totals.OutputTokens,
+		TotalTokens:  totals.TotalTokens,
+		RequestLimit: requestLimit,
+		TokenLimit:   tokenLimit,
+		RequestsLeft: remaining(requestLimit, totals.Requests),
+		TokensLeft:   remaining(tokenLimit, totals.TotalTokens),
+	}
+}
+
+// remaining is what is left of limit, or nil if it is unlimited
+func remaining(limit, used int64) *int64 {
+	if limit == 0 {
+		return nil
+	}
+	left := max(limit-used, 0)
+	return &left
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Vanaraj10/taskmorph-backend/models"
+	"github.com/Vanaraj10/taskmorph-backend/repository"
+)
+
+// fakeGenerator returns one step and the configured usage, or err
+type fakeGenerator struct {
+	calls  atomic.Int64
+	tokens int
+	err    error
+}
+
+func (g *fakeGenerator) GenerateSteps(ctx context.Context, taskTitle string) ([]models.Step, Usage, error) {
+	g.calls.Add(1)
+	usage := Usage{Model: "fake", PromptTokens: g.tokens / 2, OutputTokens: g.tokens - g.tokens/2, TotalTokens: g.tokens}
+	if g.err != nil {
+		return nil, usage, g.err
+	}
+	return []models.Step{{Title: taskTitle}}, usage, nil
+}
+
+// quotaError returns err as a *QuotaError, failing the test if it is not one
+func quotaError(t *testing.T, err error) *QuotaError {
+	t.Helper()
+	var quota *QuotaError
+	if !errors.As(err, &quota) {
+		t.Fatalf("error = %v, want a quota error", err)
+	}
+	return quota
+}
+
+func TestUsageMeterRequestQuota(t *testing.T) {
+	ctx := context.Background()
+	gen := &fakeGenerator{}
+	meter := NewUsageMeter(QuotaConfig{DailyRequests: 2}, ProviderOffline, gen, repository.NewMemoryUsageRepository())
+
+	for i := 0; i < 2; i++ {
+		if _, err := meter.GenerateSteps(ctx, "ada", "create", "Launch"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	_, err := meter.GenerateSteps(ctx, "ada", "create", "Launch")
+	quota := quotaError(t, err)
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if quota.Period != "daily" || quota.Resource != "requests" || quota.Limit != 2 || !quota.ResetsAt.Equal(tomorrow) {
+		t.Errorf("quota error = %+v", quota)
+	}
+	if calls := gen.calls.Load(); calls != 2 {
+		t.Errorf("generator called %d times, want 2", calls)
+	}
+
+	report, err := meter.Report(ctx, "ada", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Daily.Requests != 2 || *report.Daily.RequestsLeft != 0 || report.Monthly.RequestsLeft != nil {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Quotas are per user
+	if _, err := meter.GenerateSteps(ctx, "bob", "create", "Launch"); err != nil {
+		t.Errorf("another user: %v", err)
+	}
+}
+
+func TestUsageMeterConcurrentReservations(t *testing.T) {
+	gen := &fakeGenerator{}
+	meter := NewUsageMeter(QuotaConfig{DailyRequests: 5, MonthlyRequests: 7}, ProviderOffline, gen, repository.NewMemoryUsageRepository())
+
+	var wg sync.WaitGroup
+	var succeeded, refused atomic.Int64
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := meter.GenerateSteps(context.Background(), "ada", "create", "Launch")
+			var quota *QuotaError
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.As(err, &quota):
+				refused.Add(1)
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded.Load() != 5 || refused.Load() != 35 || gen.calls.Load() != 5 {
+		t.Errorf("%d succeeded, %d refused, %d generator calls; want 5, 35, 5", succeeded.Load(), refused.Load(), gen.calls.Load())
+	}
+}
+
+func TestUsageMeterCountsFailedCalls(t *testing.T) {
+	ctx := context.Background()
+	genErr := errors.New("provider down")
+	gen := &fakeGenerator{tokens: 10, err: genErr}
+	meter := NewUsageMeter(QuotaConfig{DailyRequests: 2}, ProviderOffline, gen, repository.NewMemoryUsageRepository())
+
+	for i := 0; i < 2; i++ {
+		if _, err := meter.GenerateSteps(ctx, "ada", "create", "Launch"); !errors.Is(err, genErr) {
+			t.Fatalf("call %d = %v, want the generator's error", i+1, err)
+		}
+	}
+	_, err := meter.GenerateSteps(ctx, "ada", "create", "Launch")
+	quotaError(t, err)
+
+	report, err := meter.Report(ctx, "ada", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Daily.Requests != 2 || report.Daily.Failed != 2 || report.Daily.TotalTokens != 20 {
+		t.Errorf("daily usage = %+v, want 2 failed requests and 20 tokens", report.Daily)
+	}
+}
+
+func TestUsageMeterFullMonthReleasesDay(t *testing.T) {
+	ctx := context.Background()
+	usage := repository.NewMemoryUsageRepository()
+	gen := &fakeGenerator{}
+	meter := NewUsageMeter(QuotaConfig{DailyRequests: 2, MonthlyRequests: 1}, ProviderOffline, gen, usage)
+
+	if _, err := meter.GenerateSteps(ctx, "ada", "create", "Launch"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := meter.GenerateSteps(ctx, "ada", "create", "Launch")
+	if quota := quotaError(t, err); quota.Period != "monthly" || quota.Limit != 1 {
+		t.Errorf("quota error = %+v, want the monthly one", quota)
+	}
+
+	// Had the refused call kept its daily reservation, the day would be full
+	unlimitedMonth := NewUsageMeter(QuotaConfig{DailyRequests: 2}, ProviderOffline, gen, usage)
+	if _, err := unlimitedMonth.GenerateSteps(ctx, "ada", "create", "Launch"); err != nil {
+		t.Errorf("second request of the day: %v", err)
+	}
+}
+
+func TestUsageReportMatchesReservations(t *testing.T) {
+	ctx := context.Background()
+	usage := repository.NewMemoryUsageRepository()
+	meter := NewUsageMeter(QuotaConfig{DailyRequests: 3}, ProviderOffline, &fakeGenerator{}, usage)
+
+	if _, err := meter.GenerateSteps(ctx, "ada", "create", "Launch"); err != nil {
+		t.Fatal(err)
+	}
+	// A call still waiting on the provider holds a reservation but has no
+	// usage record yet; the report counts it like the quota does
+	report, err := meter.Report(ctx, "ada", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := usage.ReserveRequest(ctx, "ada", report.Daily.Start, report.Daily.ResetsAt, 3); err != nil || !ok {
+		t.Fatalf("ReserveRequest = %v, %v", ok, err)
+	}
+	report, err = meter.Report(ctx, "ada", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Daily.Requests != 2 || *report.Daily.RequestsLeft != 1 {
+		t.Errorf("daily usage = %+v, want 2 requests and 1 left", report.Daily)
+	}
+}
+
+func TestUsageMeterTokenQuota(t *testing.T) {
+	ctx := context.Background()
+	gen := &fakeGenerator{tokens: 60}
+	meter := NewUsageMeter(QuotaConfig{MonthlyTokens: 100}, ProviderOffline, gen, repository.NewMemoryUsageRepository())
+
+	// The second call starts with tokens left and may overshoot the quota
+	for i := 0; i < 2; i++ {
+		if _, err := meter.GenerateSteps(ctx, "ada", "create", "Launch"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	_, err := meter.GenerateSteps(ctx, "ada", "create", "Launch")
+	if quota := quotaError(t, err); quota.Period != "monthly" || quota.Resource != "tokens" || quota.Limit != 100 {
+		t.Errorf("quota error = %+v", quota)
+	}
+
+	report, err := meter.Report(ctx, "ada", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := report.Monthly; m.TotalTokens != 120 || m.PromptTokens != 60 || m.OutputTokens != 60 || *m.TokensLeft != 0 {
+		t.Errorf("monthly usage = %+v", m)
+	}
+}
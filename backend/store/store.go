@@ -0,0 +1,111 @@
+// Package store owns the MongoDB connection. main creates one Store at
+// startup and hands its database to the repositories.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Config holds the connection settings
+type Config struct {
+	URI      string
+	Database string
+	// MaxPoolSize and MinPoolSize bound the connections kept per server.
+	// MaxPoolSize of 0 means unlimited.
+	MaxPoolSize uint64
+	MinPoolSize uint64
+	// ConnectTimeout bounds opening one connection and ServerSelectionTimeout
+	// how long an operation waits for a usable server
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	// ConnectRetries is how many more times Connect tries after a failed
+	// first attempt, waiting RetryBackoff, then twice that, and so on
+	ConnectRetries int
+	RetryBackoff   time.Duration
+}
+
+// maxBackoff caps the wait between connection attempts
+const maxBackoff = 30 * time.Second
+
+// Indexer is a repository that creates its indexes on boot
+type Indexer interface {
+	EnsureIndexes(ctx context.Context) error
+}
+
+// Store is a connected MongoDB client and the database the server uses
+type Store struct {
+	client *mongo.Client
+	db     *mongo.Database
+}
+
+// Connect connects and pings the primary, retrying with exponential backoff
+// so the server survives starting before the database does
+func Connect(ctx context.Context, cfg Config) (*Store, error) {
+	opts := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("connect to MongoDB: %w", err)
+	}
+
+	backoff := cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = client.Ping(ctx, readpref.Primary())
+		if err == nil {
+			break
+		}
+		if attempt == cfg.ConnectRetries || ctx.Err() != nil {
+			client.Disconnect(context.Background())
+			return nil, fmt.Errorf("ping MongoDB after %d attempts: %w", attempt+1, err)
+		}
+		slog.WarnContext(ctx, "MongoDB not reachable, retrying", "attempt", attempt+1, "retry_in", backoff.String(), "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+
+	slog.InfoContext(ctx, "Connected to MongoDB", "database", cfg.Database)
+	return &Store{client: client, db: client.Database(cfg.Database)}, nil
+}
+
+// DB is the database the repositories use
+func (s *Store) DB() *mongo.Database {
+	return s.db
+}
+
+// Ping checks that the primary answers
+func (s *Store) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx, readpref.Primary())
+}
+
+// EnsureIndexes creates the indexes of every repository, reporting all
+// failures rather than stopping at the first
+func (s *Store) EnsureIndexes(ctx context.Context, repos ...Indexer) error {
+	var errs []error
+	for _, repo := range repos {
+		if err := repo.EnsureIndexes(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%T: %w", repo, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the connection pool, waiting for operations in progress until
+// ctx is done
+func (s *Store) Close(ctx context.Context) error {
+	return s.client.Disconnect(ctx)
+}
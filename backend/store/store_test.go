@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unreachable points at a port nothing listens on, failing fast
+func unreachable(retries int) Config {
+	return Config{
+		URI:                    "mongodb://127.0.0.1:1/?connect=direct",
+		Database:               "taskmorph_test",
+		ConnectTimeout:         50 * time.Millisecond,
+		ServerSelectionTimeout: 50 * time.Millisecond,
+		ConnectRetries:         retries,
+		RetryBackoff:           10 * time.Millisecond,
+	}
+}
+
+func TestConnectRetries(t *testing.T) {
+	_, err := Connect(context.Background(), unreachable(2))
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Connect = %v, want a failure after 3 attempts", err)
+	}
+}
+
+func TestConnectStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	cfg := unreachable(1000)
+	cfg.RetryBackoff = time.Second
+
+	start := time.Now()
+	if _, err := Connect(ctx, cfg); err == nil {
+		t.Fatal("Connect succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Connect kept retrying for %v after the context ended", elapsed)
+	}
+}
+
+func TestConnectRejectsBadURI(t *testing.T) {
+	if _, err := Connect(context.Background(), Config{URI: "not a uri"}); err == nil {
+		t.Error("Connect accepted a malformed URI")
+	}
+}
+
+// indexer fails with err, if set, and counts its calls
+type indexer struct {
+	calls int
+	err   error
+}
+
+func (i *indexer) EnsureIndexes(ctx context.Context) error {
+	i.calls++
+	return i.err
+}
+
+func TestEnsureIndexesReportsEveryFailure(t *testing.T) {
+	first := &indexer{err: errors.New("first failed")}
+	ok := &indexer{}
+	last := &indexer{err: errors.New("last failed")}
+
+	err := (&Store{}).EnsureIndexes(context.Background(), first, ok, last)
+	if err == nil || !strings.Contains(err.Error(), "first failed") || !strings.Contains(err.Error(), "last failed") {
+		t.Errorf("EnsureIndexes = %v, want both failures", err)
+	}
+	if first.calls != 1 || ok.calls != 1 || last.calls != 1 {
+		t.Errorf("calls = %d %d %d, want every repository tried once", first.calls, ok.calls, last.calls)
+	}
+	if err := (&Store{}).EnsureIndexes(context.Background(), ok); err != nil {
+		t.Errorf("EnsureIndexes = %v, want nil", err)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+	ctx := context.Background()
+	s, err := Connect(ctx, Config{
+		URI:                    uri,
+		Database:               "taskmorph_store_test",
+		MaxPoolSize:            4,
+		ConnectTimeout:         5 * time.Second,
+		ServerSelectionTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if s.DB().Name() != "taskmorph_store_test" {
+		t.Errorf("database = %s", s.DB().Name())
+	}
+	if err := s.Ping(ctx); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	if err := s.Close(ctx); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := s.Ping(ctx); err == nil {
+		t.Error("Ping after Close succeeded")
+	}
+}
@@ -1,52 +0,0 @@
-package utils
-
-import (
-	"fmt"
-	"os"
-	"time"
-
-	"github.com/dgrijalva/jwt-go"
-)
-
-var jwtSecret []byte
-
-func init() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		panic("JWT_SECRET environment variable is required")
-	}
-	jwtSecret = []byte(secret)
-}
-
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(email string, userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"email":  email,
-		"userId": userID,
-		"exp":    time.Now().Add(72 * time.Hour).Unix(), // Token expires in 72 hours
-		"iat":    time.Now().Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
-// ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString string) (*jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtSecret, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &claims, nil
-	}
-
-	return nil, fmt.Errorf("invalid token")
-}
@@ -0,0 +1,15 @@
+package utils
+
+import "strings"
+
+// SplitList splits a comma-separated list, trimming entries and dropping
+// empty ones
+func SplitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,6 +7,7 @@ const AuthForm = ({ onSuccess }) => {
   const [showPassword, setShowPassword] = useState(false);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
+  const [fieldErrors, setFieldErrors] = useState({});
   const [formData, setFormData] = useState({
     name: '',
     email: '',
@@ -21,12 +22,14 @@ const AuthForm = ({ onSuccess }) => {
       [e.target.name]: e.target.value,
     });
     setError('');
+    setFieldErrors({ ...fieldErrors, [e.target.name]: undefined });
   };
 
   const handleSubmit = async (e) => {
     e.preventDefault();
     setLoading(true);
     setError('');
+    setFieldErrors({});
 
     try {
       let result;
@@ -42,6 +45,8 @@ const AuthForm = ({ onSuccess }) => {
 
       if (result.success) {
         onSuccess();
+      } else if (result.fields && Object.keys(result.fields).length > 0) {
+        setFieldErrors(result.fields);
       } else {
         setError(result.error);
       }
@@ -55,6 +60,7 @@ const AuthForm = ({ onSuccess }) => {
   const toggleMode = () => {
     setIsLogin(!isLogin);
     setError('');
+    setFieldErrors({});
     setFormData({ name: '', email: '', password: '' });
   };
 
@@ -92,6 +98,11 @@ const AuthForm = ({ onSuccess }) => {
                     onChange={handleChange}
                   />
                 </div>
+                {fieldErrors.name && (
+                  <p className="mt-1 text-sm text-red-400">
+                    Name {fieldErrors.name}
+                  </p>
+                )}
               </div>
             )}
 
@@ -114,6 +125,11 @@ const AuthForm = ({ onSuccess }) => {
                   onChange={handleChange}
                 />
               </div>
+              {fieldErrors.email && (
+                <p className="mt-1 text-sm text-red-400">
+                  Email {fieldErrors.email}
+                </p>
+              )}
             </div>
 
             <div>
@@ -146,6 +162,11 @@ const AuthForm = ({ onSuccess }) => {
                   )}
                 </button>
               </div>
+              {fieldErrors.password && (
+                <p className="mt-1 text-sm text-red-400">
+                  Password {fieldErrors.password}
+                </p>
+              )}
             </div>
           </div>
 
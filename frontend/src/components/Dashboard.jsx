@@ -1,26 +1,43 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useCallback, useMemo } from 'react';
 import { Plus, Search, Filter, Brain, CheckCircle, Clock, AlertCircle } from 'lucide-react';
 import { taskService } from '../services/api';
 import TaskCard from './TaskCard';
 import TaskForm from './TaskForm';
 
+const PAGE_SIZE = 20;
+
+// Status query parameter for each filter option
+const STATUS_FILTERS = {
+  all: undefined,
+  completed: 'done',
+  pending: 'todo,in_progress',
+  overdue: 'overdue',
+};
+
 const Dashboard = () => {
   const [tasks, setTasks] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
+  const [stats, setStats] = useState({ total: 0, completed: 0, pending: 0, overdue: 0 });
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [showTaskForm, setShowTaskForm] = useState(false);
   const [searchTerm, setSearchTerm] = useState('');
   const [filterStatus, setFilterStatus] = useState('all'); // all, completed, pending, overdue
   const [error, setError] = useState('');
 
-  useEffect(() => {
-    fetchTasks();
-  }, []);
+  // Filtering happens server-side, so only the pages shown are ever loaded
+  const query = useMemo(() => ({
+    sort: 'deadline',
+    limit: PAGE_SIZE,
+    status: STATUS_FILTERS[filterStatus],
+    q: searchTerm.trim() || undefined,
+  }), [filterStatus, searchTerm]);
 
-  const fetchTasks = async () => {
+  const fetchTasks = useCallback(async () => {
     try {
-      setLoading(true);
-      const response = await taskService.getTasks();
-      setTasks(response.data);
+      const { data } = await taskService.getTasks(query);
+      setTasks(data.tasks);
+      setNextCursor(data.next_cursor);
       setError('');
     } catch (err) {
       setError('Failed to fetch tasks');
@@ -28,52 +45,59 @@ const Dashboard = () => {
     } finally {
       setLoading(false);
     }
-  };
+  }, [query]);
 
-  const handleTaskCreated = () => {
-    fetchTasks();
+  const fetchStats = useCallback(async () => {
+    try {
+      const [total, completed, pending, overdue] = await Promise.all(
+        [undefined, 'done', 'todo,in_progress', 'overdue'].map((status) => taskService.countTasks({ status }))
+      );
+      setStats({ total, completed, pending, overdue });
+    } catch (err) {
+      console.error('Failed to fetch task counts:', err);
+    }
+  }, []);
+
+  const loadMore = async () => {
+    try {
+      setLoadingMore(true);
+      const { data } = await taskService.getTasks({ ...query, cursor: nextCursor });
+      setTasks((loaded) => [...loaded, ...data.tasks]);
+      setNextCursor(data.next_cursor);
+    } catch (err) {
+      setError('Failed to load more tasks');
+      console.error('Failed to load more tasks:', err);
+    } finally {
+      setLoadingMore(false);
+    }
   };
 
-  const handleTaskUpdated = () => {
+  useEffect(() => {
+    fetchStats();
+  }, [fetchStats]);
+
+  // Wait for a pause in typing before searching
+  useEffect(() => {
+    const timer = setTimeout(fetchTasks, 300);
+    return () => clearTimeout(timer);
+  }, [fetchTasks]);
+
+  const refresh = () => {
     fetchTasks();
+    fetchStats();
   };
 
-  const handleTaskDeleted = (taskId) => {
-    setTasks(tasks.filter(task => task.id !== taskId));
+  const handleTaskCreated = () => {
+    refresh();
   };
 
-  const isOverdue = (deadline) => {
-    return new Date(deadline) < new Date();
+  const handleTaskUpdated = () => {
+    refresh();
   };
 
-  const filteredTasks = tasks.filter(task => {
-    // Search filter
-    const matchesSearch = task.title.toLowerCase().includes(searchTerm.toLowerCase());
-    
-    // Status filter
-    let matchesStatus = true;
-    switch (filterStatus) {
-      case 'completed':
-        matchesStatus = task.progress === 100;
-        break;
-      case 'pending':
-        matchesStatus = task.progress < 100 && (!task.deadline || !isOverdue(task.deadline));
-        break;
-      case 'overdue':
-        matchesStatus = task.progress < 100 && task.deadline && isOverdue(task.deadline);
-        break;
-      default:
-        matchesStatus = true;
-    }
-    
-    return matchesSearch && matchesStatus;
-  });
-
-  const stats = {
-    total: tasks.length,
-    completed: tasks.filter(task => task.progress === 100).length,
-    pending: tasks.filter(task => task.progress < 100 && (!task.deadline || !isOverdue(task.deadline))).length,
-    overdue: tasks.filter(task => task.progress < 100 && task.deadline && isOverdue(task.deadline)).length,
+  const handleTaskDeleted = (taskId) => {
+    setTasks(tasks.filter(task => task.id !== taskId));
+    fetchStats();
   };
 
   if (loading) {
@@ -185,16 +209,29 @@ const Dashboard = () => {
       )}
 
       {/* Tasks Grid */}
-      {filteredTasks.length > 0 ? (
-        <div className="grid gap-6 md:grid-cols-2 lg:grid-cols-3">
-          {filteredTasks.map((task) => (
-            <TaskCard
-              key={task.id}
-              task={task}
-              onTaskUpdated={handleTaskUpdated}
-              onTaskDeleted={handleTaskDeleted}
-            />
-          ))}
+      {tasks.length > 0 ? (
+        <div className="space-y-6">
+          <div className="grid gap-6 md:grid-cols-2 lg:grid-cols-3">
+            {tasks.map((task) => (
+              <TaskCard
+                key={task.id}
+                task={task}
+                onTaskUpdated={handleTaskUpdated}
+                onTaskDeleted={handleTaskDeleted}
+              />
+            ))}
+          </div>
+          {nextCursor && (
+            <div className="text-center">
+              <button
+                onClick={loadMore}
+                disabled={loadingMore}
+                className="btn-secondary"
+              >
+                {loadingMore ? 'Loading...' : 'Load more'}
+              </button>
+            </div>
+          )}
         </div>
       ) : (
         <div className="text-center py-12">
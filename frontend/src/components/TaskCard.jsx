@@ -19,13 +19,13 @@ const TaskCard = ({ task, onTaskUpdated, onTaskDeleted }) => {
     return new Date(deadline) < new Date() && task.progress < 100;
   };
 
-  const handleStepComplete = async (stepId) => {
+  const handleStepToggle = async (step) => {
     setLoading(true);
     try {
-      await taskService.completeStep(task.id, stepId);
+      await taskService.completeStep(task.id, step.id, !step.is_completed);
       onTaskUpdated();
     } catch (error) {
-      console.error('Failed to complete step:', error);
+      console.error('Failed to update step:', error);
     } finally {
       setLoading(false);
     }
@@ -135,8 +135,8 @@ const TaskCard = ({ task, onTaskUpdated, onTaskDeleted }) => {
                 }`}
               >
                 <button
-                  onClick={() => !step.is_completed && handleStepComplete(step.id)}
-                  disabled={loading || step.is_completed}
+                  onClick={() => handleStepToggle(step)}
+                  disabled={loading}
                   className={`mt-0.5 transition-colors duration-200 ${
                     step.is_completed
                       ? 'text-green-400'
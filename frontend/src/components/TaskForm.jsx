@@ -34,7 +34,7 @@ const TaskForm = ({ onTaskCreated, onClose }) => {
       setPreviewSteps(response.data);
       setShowPreview(true);
     } catch (err) {
-      setError('Failed to generate task breakdown. Please try again.');
+      setError(err.response?.data?.error || 'Failed to generate task breakdown. Please try again.');
     } finally {
       setLoading(false);
     }
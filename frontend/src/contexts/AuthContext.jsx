@@ -28,17 +28,19 @@ export const AuthProvider = ({ children }) => {
   const login = async (credentials) => {
     try {
       const response = await authService.login(credentials);
-      const { token } = response.data;
+      const { token, refresh_token: refreshToken, user: profile } = response.data;
       
       localStorage.setItem('token', token);
-      localStorage.setItem('user', JSON.stringify({ email: credentials.email }));
-      setUser({ email: credentials.email });
+      localStorage.setItem('refreshToken', refreshToken);
+      localStorage.setItem('user', JSON.stringify(profile));
+      setUser(profile);
       
       return { success: true };
     } catch (error) {
       return { 
         success: false, 
-        error: error.response?.data?.error || 'Login failed' 
+        error: error.response?.data?.error || 'Login failed',
+        fields: error.response?.data?.fields || {},
       };
     }
   };
@@ -50,13 +52,14 @@ export const AuthProvider = ({ children }) => {
     } catch (error) {
       return { 
         success: false, 
-        error: error.response?.data?.error || 'Registration failed' 
+        error: error.response?.data?.error || 'Registration failed',
+        fields: error.response?.data?.fields || {},
       };
     }
   };
 
-  const logout = () => {
-    authService.logout();
+  const logout = async () => {
+    await authService.logout();
     setUser(null);
   };
 
@@ -19,13 +19,44 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
-// Response interceptor to handle auth errors
+const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refreshToken');
+  localStorage.removeItem('user');
+};
+
+// Shared so concurrent 401s trigger a single refresh
+let refreshing = null;
+
+const refreshSession = async () => {
+  const refreshToken = localStorage.getItem('refreshToken');
+  if (!refreshToken) {
+    throw new Error('No refresh token');
+  }
+  const { data } = await axios.post(`${API_BASE_URL}/auth/refresh`, { refresh_token: refreshToken });
+  localStorage.setItem('token', data.token);
+  localStorage.setItem('refreshToken', data.refresh_token);
+  return data.token;
+};
+
+// Response interceptor: on 401, refresh the access token once and retry
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
+  async (error) => {
+    const original = error.config;
+    if (error.response?.status === 401 && original && !original._retried && !original.url.startsWith('/auth/')) {
+      original._retried = true;
+      try {
+        refreshing = refreshing || refreshSession().finally(() => { refreshing = null; });
+        const token = await refreshing;
+        original.headers.Authorization = `Bearer ${token}`;
+        return api(original);
+      } catch {
+        // fall through to the logout below
+      }
+    }
+    if (error.response?.status === 401 && !original?.url?.startsWith('/auth/')) {
+      clearSession();
       window.location.href = '/login';
     }
     return Promise.reject(error);
@@ -35,22 +66,76 @@ api.interceptors.response.use(
 export const authService = {
   register: (userData) => api.post('/auth/register', userData),
   login: (credentials) => api.post('/auth/login', credentials),
-  logout: () => {
-    localStorage.removeItem('token');
-    localStorage.removeItem('user');
+  verifyEmail: (token) => api.post('/auth/verify', { token }),
+  resendVerification: (email) => api.post('/auth/resend-verification', { email }),
+  forgotPassword: (email) => api.post('/auth/forgot-password', { email }),
+  resetPassword: (token, password) => api.post('/auth/reset-password', { token, password }),
+  getMe: () => api.get('/auth/me'),
+  // An email change logs out every session and returns fresh tokens for this one
+  updateMe: async (profile) => {
+    const response = await api.patch('/auth/me', profile);
+    if (response.data.token) {
+      localStorage.setItem('token', response.data.token);
+      localStorage.setItem('refreshToken', response.data.refresh_token);
+    }
+    return response;
+  },
+  // Every other session is logged out; keep the fresh tokens for this one
+  changePassword: async (currentPassword, newPassword) => {
+    const response = await api.post('/auth/change-password', {
+      current_password: currentPassword,
+      new_password: newPassword,
+    });
+    localStorage.setItem('token', response.data.token);
+    localStorage.setItem('refreshToken', response.data.refresh_token);
+    return response;
+  },
+  // Revokes the session server-side; local state is cleared even if that fails
+  logout: async () => {
+    const refreshToken = localStorage.getItem('refreshToken');
+    try {
+      await api.post('/auth/logout', { refresh_token: refreshToken || undefined });
+    } catch {
+      // the session expires on its own
+    } finally {
+      clearSession();
+    }
   },
 };
 
 export const taskService = {
-  getTasks: () => api.get('/tasks/'),
+  // One page of tasks; pass the previous page's next_cursor as params.cursor
+  // for the next one
+  getTasks: (params) => api.get('/tasks/', { params }),
+  // Number of tasks matching params, without loading them
+  countTasks: async (params = {}) => {
+    const { data } = await api.get('/tasks/', { params: { ...params, limit: 1 } });
+    return data.total;
+  },
+  searchTasks: (q, limit) => api.get('/tasks/search', { params: { q, limit } }),
   getTask: (id) => api.get(`/tasks/${id}`),
   createTask: (taskData) => api.post('/tasks/create', taskData),
+  updateTask: (id, taskData) => api.patch(`/tasks/${id}`, taskData),
   deleteTask: (id) => api.delete(`/tasks/${id}`),
-  completeStep: (taskId, stepId) => api.patch(`/tasks/${taskId}/step/${stepId}/complete`),
+  completeStep: (taskId, stepId, completed = true) =>
+    api.patch(`/tasks/${taskId}/step/${stepId}/complete`, { completed }),
+  addStep: (taskId, stepData) => api.post(`/tasks/${taskId}/step`, stepData),
+  updateStep: (taskId, stepId, stepData) => api.patch(`/tasks/${taskId}/step/${stepId}`, stepData),
+  moveStep: (taskId, stepId, position) => api.patch(`/tasks/${taskId}/step/${stepId}/move`, { position }),
+  deleteStep: (taskId, stepId) => api.delete(`/tasks/${taskId}/step/${stepId}`),
 };
 
 export const aiService = {
   getTaskBreakdown: (task) => api.post('/ai/breakdown', { task }),
+  getUsage: () => api.get('/usage/'),
+};
+
+export const notificationService = {
+  getNotifications: (params) => api.get('/notifications/', { params }),
+  markRead: (id) => api.patch(`/notifications/${id}/read`),
+  markAllRead: () => api.post('/notifications/read-all'),
+  getPreferences: () => api.get('/notifications/preferences'),
+  updatePreferences: (prefs) => api.put('/notifications/preferences', prefs),
 };
 
 export default api;